@@ -41,15 +41,29 @@ export async function fetchEncounters(params: FetchEncountersParams) {
   return data
 }
 
+export interface EncounterScene {
+  id: number | null; // null for encounters uploaded without a scene ID
+  name: string;
+  difficulty?: string;
+  encounters: number;
+  lastSeen: string;
+}
+
 export interface FetchEncounterScenesResponse {
-  scenes: string[]
+  scenes: EncounterScene[]
 }
 
-export async function fetchEncounterScenes() {
+export async function fetchEncounterSceneSummaries() {
   const { data } = await api.get<FetchEncounterScenesResponse>("/encounter/scenes");
   return data.scenes;
 }
 
+// Scene names, usable as scene_name filters
+export async function fetchEncounterScenes() {
+  const scenes = await fetchEncounterSceneSummaries();
+  return scenes.map((s) => s.name);
+}
+
 export interface FetchEncounterByIdResponse {
   encounter: Encounter;
 }
@@ -0,0 +1,126 @@
+package encounter
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+
+	apiErrors "server/controller"
+	"server/models"
+	"server/services/privacy"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// maxBatchEncounters caps how many encounters a single batch request may fetch.
+const maxBatchEncounters = 50
+
+// selectUploader preloads only the public uploader fields so token columns are never read.
+func selectUploader(db *gorm.DB) *gorm.DB {
+	return db.Select("id", "discord_username", "discord_global_name", "discord_avatar_url")
+}
+
+// batchRelations maps include names to the preload that fetches them.
+var batchRelations = map[string]func(*gorm.DB) *gorm.DB{
+	"bosses": func(db *gorm.DB) *gorm.DB { return db.Preload("Bosses") },
+	"players": func(db *gorm.DB) *gorm.DB {
+		return db.Preload("Players", func(db *gorm.DB) *gorm.DB {
+			return db.Where("actor_encounter_stats.is_player = ?", true)
+		})
+	},
+	"attempts": func(db *gorm.DB) *gorm.DB { return db.Preload("Attempts") },
+	"deaths":   func(db *gorm.DB) *gorm.DB { return db.Preload("DeathEvents") },
+	"phases":   func(db *gorm.DB) *gorm.DB { return db.Preload("Phases") },
+	"user":     func(db *gorm.DB) *gorm.DB { return db.Preload("User", selectUploader) },
+}
+
+type GetEncountersBatchResponse struct {
+	Encounters []models.Encounter `json:"encounters"`
+	Missing    []int64            `json:"missing"`
+}
+
+// GET /api/v1/encounter/batch
+// Query params:
+//   - ids: comma-separated encounter ids (max 50)
+//   - include: comma-separated relations: bosses, players, attempts, deaths, phases, user
+//
+// Encounters are returned in the order requested; unknown ids are listed in missing.
+func GetEncountersBatch(c *gin.Context) {
+	dbAny, ok := c.Get("db")
+	if !ok {
+		c.JSON(http.StatusInternalServerError, apiErrors.NewErrorResponse(http.StatusInternalServerError, "Database not available in context"))
+		return
+	}
+	db := dbAny.(*gorm.DB)
+
+	var ids []int64
+	seen := make(map[int64]bool)
+	for _, part := range strings.Split(c.Query("ids"), ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		id, err := strconv.ParseInt(part, 10, 64)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, apiErrors.NewErrorResponse(http.StatusBadRequest, "Invalid encounter id", part))
+			return
+		}
+		if !seen[id] {
+			seen[id] = true
+			ids = append(ids, id)
+		}
+	}
+	if len(ids) == 0 {
+		c.JSON(http.StatusBadRequest, apiErrors.NewErrorResponse(http.StatusBadRequest, "No encounter ids provided"))
+		return
+	}
+	if len(ids) > maxBatchEncounters {
+		c.JSON(http.StatusBadRequest, apiErrors.NewErrorResponse(http.StatusBadRequest, "Too many encounter ids (max "+strconv.Itoa(maxBatchEncounters)+")"))
+		return
+	}
+
+	q := db.Model(&models.Encounter{})
+	for _, rel := range strings.Split(c.Query("include"), ",") {
+		rel = strings.ToLower(strings.TrimSpace(rel))
+		if rel == "" {
+			continue
+		}
+		preload, ok := batchRelations[rel]
+		if !ok {
+			c.JSON(http.StatusBadRequest, apiErrors.NewErrorResponse(http.StatusBadRequest, "Unknown include", rel))
+			return
+		}
+		q = preload(q)
+	}
+
+	var encs []models.Encounter
+	if err := q.Where("encounters.id IN ?", ids).Find(&encs).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, apiErrors.NewErrorResponse(http.StatusInternalServerError, "Failed to query encounters", err.Error()))
+		return
+	}
+	// Characters who opted out of public listings stay unnamed
+	players := make([][]models.ActorEncounterStat, 0, len(encs))
+	for _, e := range encs {
+		players = append(players, e.Players)
+	}
+	if err := privacy.RedactActors(db, players...); err != nil {
+		c.JSON(http.StatusInternalServerError, apiErrors.NewErrorResponse(http.StatusInternalServerError, "Failed to query encounters", err.Error()))
+		return
+	}
+
+	byID := make(map[int64]models.Encounter, len(encs))
+	for _, e := range encs {
+		byID[e.ID] = e
+	}
+	resp := GetEncountersBatchResponse{Encounters: make([]models.Encounter, 0, len(encs)), Missing: []int64{}}
+	for _, id := range ids {
+		if e, ok := byID[id]; ok {
+			resp.Encounters = append(resp.Encounters, e)
+		} else {
+			resp.Missing = append(resp.Missing, id)
+		}
+	}
+
+	c.JSON(http.StatusOK, resp)
+}
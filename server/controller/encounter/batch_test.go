@@ -0,0 +1,102 @@
+package encounter
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	dbpkg "server/db"
+	"server/models"
+)
+
+func TestGetEncountersBatch_RejectsInvalidParams(t *testing.T) {
+	ids := make([]string, maxBatchEncounters+1)
+	for i := range ids {
+		ids[i] = strconv.Itoa(i + 1)
+	}
+	tooMany := strings.Join(ids, ",")
+	for _, query := range []string{"ids=", "ids=1,x", "ids=" + tooMany, "ids=1&include=players,secrets"} {
+		c, w, stmts := dryRunContext(t, "/api/v1/encounter/batch?"+query, nil)
+		GetEncountersBatch(c)
+		if w.Code != http.StatusBadRequest {
+			t.Errorf("%s: expected 400, got %d", query, w.Code)
+		}
+		if got := stmts.All(); len(got) != 0 {
+			t.Errorf("%s: expected no queries, got %v", query, got)
+		}
+	}
+}
+
+func TestGetEncountersBatch_DedupesIDsAndListsMissing(t *testing.T) {
+	// Duplicates count once towards the cap
+	c, w, stmts := dryRunContext(t, "/api/v1/encounter/batch?ids=3,%201,3,,2&include=Players,user", nil)
+	GetEncountersBatch(c)
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+	if got := stmts.Matching("encounters.id IN (3,1,2)"); len(got) != 1 {
+		t.Errorf("Expected one lookup of the distinct ids, got %v", stmts.All())
+	}
+	var resp GetEncountersBatchResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+		t.Fatal(err)
+	}
+	if len(resp.Encounters) != 0 || len(resp.Missing) != 3 || resp.Missing[0] != 3 || resp.Missing[1] != 1 || resp.Missing[2] != 2 {
+		t.Errorf("Expected every id missing in request order, got %+v", resp)
+	}
+}
+
+// Needs a Postgres server in TEST_DATABASE_URL; redaction reads the characters table.
+func TestGetEncountersBatch_RedactsPrivatePlayers(t *testing.T) {
+	db, drop, err := dbpkg.TestDB()
+	if errors.Is(err, dbpkg.ErrNoTestDB) {
+		t.Skip(err)
+	}
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer drop()
+	if err := db.AutoMigrate(&models.User{}, &models.Encounter{}, &models.ActorEncounterStat{}, &models.Character{}); err != nil {
+		t.Fatal(err)
+	}
+	name := func(s string) *string { return &s }
+	start := time.Date(2026, 10, 1, 12, 0, 0, 0, time.UTC)
+	seed := []interface{}{
+		&models.User{ID: 1, DiscordUserID: "1"},
+		&models.Encounter{ID: 1, StartedAt: start, UserID: 1},
+		&models.ActorEncounterStat{EncounterID: 1, ActorID: 10, Name: name("Ann"), IsPlayer: true},
+		&models.ActorEncounterStat{EncounterID: 1, ActorID: 20, Name: name("Bob"), IsPlayer: true},
+		&models.ActorEncounterStat{EncounterID: 1, ActorID: 99, Name: name("Boss"), IsPlayer: false},
+		&models.Character{ActorID: 20, Name: name("Bob"), LastSeenAt: start, IsPrivate: true},
+	}
+	for _, row := range seed {
+		if err := db.Create(row).Error; err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	c, w, _ := dryRunContext(t, "/api/v1/encounter/batch?ids=1&include=players", nil)
+	c.Set("db", db)
+	GetEncountersBatch(c)
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+	var resp GetEncountersBatchResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+		t.Fatal(err)
+	}
+	if len(resp.Encounters) != 1 {
+		t.Fatalf("Expected the encounter, got %+v", resp)
+	}
+	names := make(map[int64]*string)
+	for _, p := range resp.Encounters[0].Players {
+		names[p.ActorID] = p.Name
+	}
+	if len(names) != 2 || names[10] == nil || *names[10] != "Ann" || names[20] != nil {
+		t.Errorf("Expected only the public player to be named and no non-players, got %v", names)
+	}
+}
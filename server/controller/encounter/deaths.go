@@ -0,0 +1,302 @@
+package encounter
+
+import (
+	"net/http"
+	"sort"
+	"strconv"
+
+	apiErrors "server/controller"
+	"server/lib"
+	"server/models"
+	"server/services/privacy"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// KillingBlow describes the skill that landed the final hit.
+type KillingBlow struct {
+	KillerID   *int64  `json:"killerId,omitempty"`
+	KillerName *string `json:"killerName,omitempty"`
+	SkillID    *int64  `json:"skillId,omitempty"`
+	// The killing skill's last hit on the victim at or before the death, from the hit
+	// timeline; nil when the encounter has no timeline data
+	Hit *DamageTakenEntry `json:"hit,omitempty"`
+}
+
+// DamageTakenEntry is a single hit the victim took shortly before dying.
+type DamageTakenEntry struct {
+	TimestampMs  int64   `json:"timestampMs"`
+	OffsetMs     int64   `json:"offsetMs"` // negative offset relative to the death
+	AttackerID   int64   `json:"attackerId"`
+	AttackerName *string `json:"attackerName,omitempty"`
+	SkillID      int64   `json:"skillId"`
+	Value        int64   `json:"value"`
+	HpLoss       int64   `json:"hpLoss"`
+	ShieldLoss   int64   `json:"shieldLoss"`
+	IsCrit       bool    `json:"isCrit"`
+	IsLucky      bool    `json:"isLucky"`
+}
+
+// DeathRecap is a single death with resolved names and (when timeline data exists)
+// the damage taken in the preceding window.
+type DeathRecap struct {
+	ID               int64              `json:"id"`
+	Timestamp        int64              `json:"timestampMs"`
+	ActorID          int64              `json:"actorId"`
+	ActorName        *string            `json:"actorName,omitempty"`
+	IsLocalPlayer    bool               `json:"isLocalPlayer"`
+	AttemptIndex     int                `json:"attemptIndex"`
+	KillingBlow      KillingBlow        `json:"killingBlow"`
+	HasTimeline      bool               `json:"hasTimeline"`
+	DamageTaken      []DamageTakenEntry `json:"damageTaken"`
+	DamageTakenTotal int64              `json:"damageTakenTotal"`
+}
+
+// AttemptDeaths groups deaths belonging to the same attempt.
+type AttemptDeaths struct {
+	AttemptIndex int             `json:"attemptIndex"`
+	Attempt      *models.Attempt `json:"attempt,omitempty"`
+	Deaths       []DeathRecap    `json:"deaths"`
+}
+
+// LethalMechanic ranks killer/skill pairs by how many deaths they caused.
+type LethalMechanic struct {
+	KillerID   *int64  `json:"killerId,omitempty"`
+	KillerName *string `json:"killerName,omitempty"`
+	SkillID    *int64  `json:"skillId,omitempty"`
+	Deaths     int     `json:"deaths"`
+	Victims    int     `json:"victims"`
+}
+
+type GetEncounterDeathsResponse struct {
+	EncounterID     int64            `json:"encounterId"`
+	WindowSeconds   int              `json:"windowSeconds"`
+	TotalDeaths     int              `json:"totalDeaths"`
+	Attempts        []AttemptDeaths  `json:"attempts"`
+	LethalMechanics []LethalMechanic `json:"lethalMechanics"`
+}
+
+// GET /api/v1/encounter/:id/deaths
+// Query params: window (seconds of damage taken to include before each death, default 5, max 30)
+func GetEncounterDeaths(c *gin.Context) {
+	dbAny, ok := c.Get("db")
+	if !ok {
+		c.JSON(http.StatusInternalServerError, apiErrors.NewErrorResponse(http.StatusInternalServerError, "Database not available in context"))
+		return
+	}
+	db := dbAny.(*gorm.DB)
+
+	encID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, apiErrors.NewErrorResponse(http.StatusBadRequest, "Invalid encounter id", err.Error()))
+		return
+	}
+
+	window := 5
+	if v := c.Query("window"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n <= 0 || n > 30 {
+			c.JSON(http.StatusBadRequest, apiErrors.NewErrorResponse(http.StatusBadRequest, "Invalid window (expected 1-30 seconds)"))
+			return
+		}
+		window = n
+	}
+	windowMs := int64(window) * 1000
+
+	var enc models.Encounter
+	if err := db.Select("id").Where("id = ?", encID).First(&enc).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			c.JSON(http.StatusNotFound, apiErrors.NewErrorResponse(http.StatusNotFound, "Encounter not found"))
+			return
+		}
+		c.JSON(http.StatusInternalServerError, apiErrors.NewErrorResponse(http.StatusInternalServerError, "Failed to load encounter", err.Error()))
+		return
+	}
+
+	var deaths []models.DeathEvent
+	if err := db.Where("encounter_id = ?", encID).Order("timestamp ASC").Find(&deaths).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, apiErrors.NewErrorResponse(http.StatusInternalServerError, "Failed to query death events", err.Error()))
+		return
+	}
+
+	var attempts []models.Attempt
+	if err := db.Where("encounter_id = ?", encID).Order("attempt_index ASC").Find(&attempts).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, apiErrors.NewErrorResponse(http.StatusInternalServerError, "Failed to query attempts", err.Error()))
+		return
+	}
+
+	// Actor names come from the encounter's own actor rows (players and monsters alike)
+	var actors []models.ActorEncounterStat
+	if err := db.Select("actor_id", "name").
+		Where("encounter_id = ? AND name IS NOT NULL AND name <> ''", encID).
+		Find(&actors).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, apiErrors.NewErrorResponse(http.StatusInternalServerError, "Failed to query actors", err.Error()))
+		return
+	}
+	// Characters who opted out of public listings stay unnamed
+	if err := privacy.RedactActors(db, actors); err != nil {
+		c.JSON(http.StatusInternalServerError, apiErrors.NewErrorResponse(http.StatusInternalServerError, "Failed to query actors", err.Error()))
+		return
+	}
+	names := make(map[int64]*string, len(actors))
+	for i := range actors {
+		if actors[i].Name != nil {
+			names[actors[i].ActorID] = actors[i].Name
+		}
+	}
+
+	// Incoming damage for every victim: used for the killing blow and the pre-death timeline
+	victimIDs := make([]int64, 0, len(deaths))
+	seenVictim := make(map[int64]bool)
+	for _, d := range deaths {
+		if !seenVictim[d.ActorID] {
+			seenVictim[d.ActorID] = true
+			victimIDs = append(victimIDs, d.ActorID)
+		}
+	}
+	var incoming []models.DamageSkillStat
+	if len(victimIDs) > 0 {
+		if err := db.Where("encounter_id = ? AND defender_id IN ?", encID, victimIDs).Find(&incoming).Error; err != nil {
+			c.JSON(http.StatusInternalServerError, apiErrors.NewErrorResponse(http.StatusInternalServerError, "Failed to query damage taken", err.Error()))
+			return
+		}
+	}
+
+	type parsedStat struct {
+		stat models.DamageSkillStat
+		hits []lib.HitDetail
+	}
+	byVictim := make(map[int64][]parsedStat)
+	for _, s := range incoming {
+		// Monsters rarely have actor rows; fall back to the monster name recorded on their skills
+		if _, ok := names[s.AttackerID]; !ok && s.MonsterName != nil && *s.MonsterName != "" {
+			names[s.AttackerID] = s.MonsterName
+		}
+		// Timelines are validated at ingest
+		hits, _ := lib.ParseHitDetails(s.HitDetails)
+		byVictim[*s.DefenderID] = append(byVictim[*s.DefenderID], parsedStat{stat: s, hits: hits})
+	}
+
+	attemptByIndex := make(map[int]*models.Attempt, len(attempts))
+	for i := range attempts {
+		attemptByIndex[attempts[i].AttemptIndex] = &attempts[i]
+	}
+
+	type mechanicKey struct {
+		killer int64
+		skill  int64
+	}
+	mechanics := make(map[mechanicKey]*LethalMechanic)
+	mechanicVictims := make(map[mechanicKey]map[int64]bool)
+	mechanicOrder := make([]mechanicKey, 0)
+	grouped := make(map[int]*AttemptDeaths)
+	order := make([]int, 0)
+
+	for _, d := range deaths {
+		recap := DeathRecap{
+			ID:            d.ID,
+			Timestamp:     d.Timestamp.UnixMilli(),
+			ActorID:       d.ActorID,
+			ActorName:     names[d.ActorID],
+			IsLocalPlayer: d.IsLocalPlayer,
+			AttemptIndex:  d.AttemptIndex,
+			KillingBlow: KillingBlow{
+				KillerID: d.KillerID,
+				SkillID:  d.SkillID,
+			},
+			DamageTaken: []DamageTakenEntry{},
+		}
+		if d.KillerID != nil {
+			recap.KillingBlow.KillerName = names[*d.KillerID]
+		}
+
+		deathMs := d.Timestamp.UnixMilli()
+		for _, ps := range byVictim[d.ActorID] {
+			if len(ps.hits) == 0 {
+				continue
+			}
+			recap.HasTimeline = true
+			entry := func(h lib.HitDetail) DamageTakenEntry {
+				return DamageTakenEntry{
+					TimestampMs:  h.TimestampMs,
+					OffsetMs:     h.TimestampMs - deathMs,
+					AttackerID:   ps.stat.AttackerID,
+					AttackerName: names[ps.stat.AttackerID],
+					SkillID:      ps.stat.SkillID,
+					Value:        h.Value,
+					HpLoss:       h.HpLoss,
+					ShieldLoss:   h.ShieldLoss,
+					IsCrit:       h.IsCrit,
+					IsLucky:      h.IsLucky,
+				}
+			}
+			if d.KillerID != nil && d.SkillID != nil && ps.stat.AttackerID == *d.KillerID && ps.stat.SkillID == *d.SkillID {
+				if h, ok := lib.LastHitAtOrBefore(ps.hits, deathMs); ok && (recap.KillingBlow.Hit == nil || h.TimestampMs > recap.KillingBlow.Hit.TimestampMs) {
+					hit := entry(h)
+					recap.KillingBlow.Hit = &hit
+				}
+			}
+			for _, h := range lib.HitsInWindow(ps.hits, deathMs, windowMs) {
+				recap.DamageTaken = append(recap.DamageTaken, entry(h))
+				recap.DamageTakenTotal += h.Value
+			}
+		}
+		sort.SliceStable(recap.DamageTaken, func(i, j int) bool {
+			return recap.DamageTaken[i].TimestampMs < recap.DamageTaken[j].TimestampMs
+		})
+
+		group, ok := grouped[d.AttemptIndex]
+		if !ok {
+			group = &AttemptDeaths{AttemptIndex: d.AttemptIndex, Attempt: attemptByIndex[d.AttemptIndex], Deaths: []DeathRecap{}}
+			grouped[d.AttemptIndex] = group
+			order = append(order, d.AttemptIndex)
+		}
+		group.Deaths = append(group.Deaths, recap)
+
+		// Unknown killers/skills are grouped under -1 so they still count towards the ranking
+		key := mechanicKey{killer: -1, skill: -1}
+		if d.KillerID != nil {
+			key.killer = *d.KillerID
+		}
+		if d.SkillID != nil {
+			key.skill = *d.SkillID
+		}
+		m, ok := mechanics[key]
+		if !ok {
+			m = &LethalMechanic{KillerID: d.KillerID, KillerName: recap.KillingBlow.KillerName, SkillID: d.SkillID}
+			mechanics[key] = m
+			mechanicVictims[key] = make(map[int64]bool)
+			mechanicOrder = append(mechanicOrder, key)
+		}
+		m.Deaths++
+		mechanicVictims[key][d.ActorID] = true
+		m.Victims = len(mechanicVictims[key])
+	}
+
+	sort.Ints(order)
+	out := make([]AttemptDeaths, 0, len(order))
+	for _, idx := range order {
+		out = append(out, *grouped[idx])
+	}
+
+	// Ties keep first-occurrence order so the ranking is stable between requests
+	ranked := make([]LethalMechanic, 0, len(mechanicOrder))
+	for _, key := range mechanicOrder {
+		ranked = append(ranked, *mechanics[key])
+	}
+	sort.SliceStable(ranked, func(i, j int) bool {
+		if ranked[i].Deaths != ranked[j].Deaths {
+			return ranked[i].Deaths > ranked[j].Deaths
+		}
+		return ranked[i].Victims > ranked[j].Victims
+	})
+
+	c.JSON(http.StatusOK, GetEncounterDeathsResponse{
+		EncounterID:     encID,
+		WindowSeconds:   window,
+		TotalDeaths:     len(deaths),
+		Attempts:        out,
+		LethalMechanics: ranked,
+	})
+}
@@ -0,0 +1,41 @@
+package encounter
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	dbpkg "server/db"
+
+	"github.com/gin-gonic/gin"
+)
+
+// dryRunContext returns a test context for a GET of target against a dry-run DB, with
+// the route's params set.
+func dryRunContext(t *testing.T, target string, params gin.Params) (*gin.Context, *httptest.ResponseRecorder, *dbpkg.Statements) {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+	db, stmts, err := dbpkg.DryRun()
+	if err != nil {
+		t.Fatal(err)
+	}
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request = httptest.NewRequest(http.MethodGet, target, nil)
+	c.Params = params
+	c.Set("db", db)
+	return c, w, stmts
+}
+
+func TestGetEncounterDeaths_RejectsInvalidWindow(t *testing.T) {
+	for _, window := range []string{"abc", "0", "-5", "31"} {
+		c, w, stmts := dryRunContext(t, "/api/v1/encounter/1/deaths?window="+window, gin.Params{{Key: "id", Value: "1"}})
+		GetEncounterDeaths(c)
+		if w.Code != http.StatusBadRequest {
+			t.Errorf("window=%s: expected 400, got %d", window, w.Code)
+		}
+		if got := stmts.All(); len(got) != 0 {
+			t.Errorf("window=%s: expected no queries, got %v", window, got)
+		}
+	}
+}
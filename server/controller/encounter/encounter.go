@@ -4,9 +4,16 @@ import (
 	"net/http"
 	"strconv"
 	"strings"
+	"time"
 
 	apiErrors "server/controller"
+	"server/lib"
 	"server/models"
+	"server/services/gamedata"
+	"server/services/moderation"
+	"server/services/patches"
+	"server/services/privacy"
+	"server/services/scenes"
 
 	"github.com/gin-gonic/gin"
 	"gorm.io/gorm"
@@ -18,6 +25,8 @@ type GetEncountersResponse struct {
 }
 
 // GET /api/v1/encounter
+// Filters: user_id, scene_id, scene_name, patch (patch name), monster_name, class_id,
+// class_spec, player_name
 func GetEncounters(c *gin.Context) {
 	dbAny, ok := c.Get("db")
 	if !ok {
@@ -55,14 +64,19 @@ func GetEncounters(c *gin.Context) {
 	if sceneID := c.Query("scene_id"); sceneID != "" {
 		base = base.Where("encounters.scene_id = ?", sceneID)
 	}
-	if sceneName := c.Query("scene_name"); sceneName != "" {
-		base = base.Where("LOWER(encounters.scene_name) = LOWER(?)", sceneName)
+	if sceneName := strings.TrimSpace(c.Query("scene_name")); sceneName != "" {
+		sceneSQL, sceneArgs := scenes.NameFilter("encounters", sceneName)
+		base = base.Where(sceneSQL, sceneArgs...)
+	}
+	if patch := strings.TrimSpace(c.Query("patch")); patch != "" {
+		base = base.Where(patches.FilterSQL("encounters.patch_id"), patch)
 	}
 
 	// Filters requiring joins - use GORM's Joins for better query building
 	if monsterName := c.Query("monster_name"); monsterName != "" {
+		bossSQL, bossArgs := scenes.BossFilter("encounter_bosses", monsterName)
 		base = base.Joins("JOIN encounter_bosses ON encounter_bosses.encounter_id = encounters.id").
-			Where("LOWER(encounter_bosses.monster_name) = LOWER(?)", monsterName).
+			Where(bossSQL, bossArgs...).
 			Distinct()
 	}
 	if classID := c.Query("class_id"); classID != "" {
@@ -78,6 +92,7 @@ func GetEncounters(c *gin.Context) {
 	if playerName := c.Query("player_name"); playerName != "" {
 		base = base.Joins("JOIN actor_encounter_stats ON actor_encounter_stats.encounter_id = encounters.id").
 			Where("LOWER(actor_encounter_stats.name) LIKE LOWER(?)", "%"+playerName+"%").
+			Where(privacy.PublicSQL("actor_encounter_stats.actor_id")).
 			Distinct()
 	}
 
@@ -110,22 +125,80 @@ func GetEncounters(c *gin.Context) {
 			return db.Where("actor_encounter_stats.is_player = ?", true)
 		}).
 		Preload("Phases").
-		Preload("User", func(db *gorm.DB) *gorm.DB {
-			return db.Select("id", "discord_username", "discord_global_name", "discord_avatar_url")
-		}).
+		Preload("User", selectUploader).
 		Find(&encs).Error; err != nil {
 		c.JSON(http.StatusInternalServerError, apiErrors.NewErrorResponse(http.StatusInternalServerError, "Failed to query encounters", err.Error()))
 		return
 	}
 
+	players := make([][]models.ActorEncounterStat, 0, len(encs))
+	for _, e := range encs {
+		players = append(players, e.Players)
+	}
+	if err := privacy.RedactActors(db, players...); err != nil {
+		c.JSON(http.StatusInternalServerError, apiErrors.NewErrorResponse(http.StatusInternalServerError, "Failed to query encounters", err.Error()))
+		return
+	}
+	locale := c.GetString("locale")
+	for i := range encs {
+		localizeEncounter(&encs[i], locale)
+	}
+
 	c.JSON(http.StatusOK, GetEncountersResponse{Encounters: encs, Count: total})
 }
 
+// localizeEncounter replaces the scene and boss names an encounter was uploaded with by
+// their catalog names in locale, so encounters uploaded from different client languages
+// read the same.
+func localizeEncounter(enc *models.Encounter, locale string) {
+	catalog := gamedata.Get()
+	enc.SceneName = catalog.LocalizeScene(enc.SceneID, enc.SceneName, locale)
+	for i := range enc.Bosses {
+		b := &enc.Bosses[i]
+		b.MonsterName = catalog.LocalizeMonster(b.MonsterID, b.MonsterName, locale)
+	}
+}
+
 type GetEncounterByIDResponse struct {
-	Encounter models.Encounter `json:"encounter"`
+	Encounter models.Encounter  `json:"encounter"`
+	Segment   *EncounterSegment `json:"segment,omitempty"`
+	Names     *gamedata.Names   `json:"names,omitempty"`
+}
+
+// wantNames reports whether the client asked for ID-to-name maps (?names=true).
+func wantNames(c *gin.Context) bool {
+	v, _ := strconv.ParseBool(c.Query("names"))
+	return v
+}
+
+// SegmentActorRow is an actor's totals for part of an encounter (an attempt or the boss phases).
+type SegmentActorRow struct {
+	ActorID         int64   `gorm:"column:actor_id" json:"actorId"`
+	Name            *string `gorm:"column:name" json:"name,omitempty"`
+	ClassID         *int64  `gorm:"column:class_id" json:"classId,omitempty"`
+	ClassSpec       *int64  `gorm:"column:class_spec" json:"classSpec,omitempty"`
+	AbilityScore    *int64  `gorm:"column:ability_score" json:"abilityScore,omitempty"`
+	DamageDealt     int64   `gorm:"column:damage_dealt" json:"damageDealt"`
+	HealDealt       int64   `gorm:"column:heal_dealt" json:"healDealt"`
+	DamageTaken     int64   `gorm:"column:damage_taken" json:"damageTaken"`
+	BossDamageDealt int64   `gorm:"column:boss_damage_dealt" json:"bossDamageDealt"`
+	Duration        float64 `gorm:"column:duration" json:"duration"`
+	DPS             float64 `gorm:"column:dps" json:"dps"`
+	HPS             float64 `gorm:"-" json:"hps"`
+}
+
+// EncounterSegment holds player stats restricted to the kill attempt, a specific attempt or the boss phases.
+type EncounterSegment struct {
+	Segment      string            `json:"segment"`
+	AttemptIndex *int              `json:"attemptIndex,omitempty"`
+	Players      []SegmentActorRow `json:"players"`
 }
 
 // GET /api/v1/encounter/:id
+// Optional query params:
+//   - segment: all | kill | boss (report the kill attempt or the boss phases only)
+//   - attempt: int (report a single attempt by attemptIndex; overrides segment)
+//   - names: bool (include names for the scene, class and spec IDs in the response)
 func GetEncounterByID(c *gin.Context) {
 	dbAny, ok := c.Get("db")
 	if !ok {
@@ -136,6 +209,21 @@ func GetEncounterByID(c *gin.Context) {
 
 	id := c.Param("id")
 
+	segment, ok := lib.ParseSegment(c.Query("segment"))
+	if !ok {
+		c.JSON(http.StatusBadRequest, apiErrors.NewErrorResponse(http.StatusBadRequest, "Invalid segment (expected all, kill or boss)"))
+		return
+	}
+	var attemptIndex *int
+	if v := c.Query("attempt"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, apiErrors.NewErrorResponse(http.StatusBadRequest, "Invalid attempt", err.Error()))
+			return
+		}
+		attemptIndex = &n
+	}
+
 	var enc models.Encounter
 	// Return the raw model. preload common relations so JSON has nested data.
 	if err := db.
@@ -146,7 +234,7 @@ func GetEncounterByID(c *gin.Context) {
 		Preload("Attempts").
 		Preload("DeathEvents").
 		Preload("Phases").
-		Preload("User").
+		Preload("User", selectUploader).
 		Where("id = ?", id).
 		First(&enc).Error; err != nil {
 		if err == gorm.ErrRecordNotFound {
@@ -157,14 +245,82 @@ func GetEncounterByID(c *gin.Context) {
 		return
 	}
 
-	c.JSON(http.StatusOK, GetEncounterByIDResponse{Encounter: enc})
+	if err := privacy.RedactActors(db, enc.Players); err != nil {
+		c.JSON(http.StatusInternalServerError, apiErrors.NewErrorResponse(http.StatusInternalServerError, "Failed to load encounter", err.Error()))
+		return
+	}
+	localizeEncounter(&enc, c.GetString("locale"))
+	resp := GetEncounterByIDResponse{Encounter: enc}
+	if attemptIndex != nil || segment != lib.SegmentAll {
+		var rows []SegmentActorRow
+		q := db.Table("attempt_actor_stats").
+			Where("encounter_id = ? AND is_player = ?", enc.ID, true)
+		if attemptIndex != nil {
+			q = q.Where("attempt_index = ?", *attemptIndex)
+		} else {
+			q = db.Table("("+lib.SegmentActorStatsSQL(segment, "encounter_id = ?")+") AS seg", enc.ID).
+				Where("seg.is_player = ?", true)
+		}
+		if err := q.Order("dps DESC").Find(&rows).Error; err != nil {
+			c.JSON(http.StatusInternalServerError, apiErrors.NewErrorResponse(http.StatusInternalServerError, "Failed to load segment stats", err.Error()))
+			return
+		}
+		ids := make([]int64, len(rows))
+		for i := range rows {
+			ids[i] = rows[i].ActorID
+		}
+		private, err := privacy.PrivateActors(db, ids)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, apiErrors.NewErrorResponse(http.StatusInternalServerError, "Failed to load segment stats", err.Error()))
+			return
+		}
+		for i := range rows {
+			if rows[i].Duration > 0 {
+				rows[i].HPS = float64(rows[i].HealDealt) / rows[i].Duration
+			}
+			if private[rows[i].ActorID] {
+				rows[i].Name = nil
+			}
+		}
+		if rows == nil {
+			rows = []SegmentActorRow{}
+		}
+		resp.Segment = &EncounterSegment{Segment: segment, AttemptIndex: attemptIndex, Players: rows}
+	}
+
+	if wantNames(c) {
+		names := gamedata.Get().NewNames(c.GetString("locale"))
+		names.AddScene(enc.SceneID)
+		for _, b := range enc.Bosses {
+			names.AddMonster(b.MonsterID)
+		}
+		for _, p := range enc.Players {
+			names.AddClass(p.ClassID)
+			names.AddSpec(p.ClassSpec)
+		}
+		resp.Names = names
+	}
+
+	c.JSON(http.StatusOK, resp)
+}
+
+// EncounterScene summarises the encounters uploaded in a scene. ID is nil for encounters
+// uploaded without a scene ID, which are grouped by name.
+type EncounterScene struct {
+	ID         *int64    `gorm:"column:id" json:"id"`
+	Name       string    `gorm:"column:name" json:"name"`
+	Difficulty string    `gorm:"column:difficulty" json:"difficulty,omitempty"`
+	Encounters int64     `gorm:"column:encounters" json:"encounters"`
+	LastSeen   time.Time `gorm:"column:last_seen" json:"lastSeen"`
 }
 
 type GetEncounterScenesResponse struct {
-	Scenes []string `json:"scenes"`
+	Scenes []EncounterScene `json:"scenes"`
 }
 
 // GET /api/v1/encounter/scenes
+// Lists the scenes with listed encounters, with their encounter count and the start of
+// the latest one, ordered by name. Names are in the Accept-Language locale where known.
 func GetEncounterScenes(c *gin.Context) {
 	dbAny, ok := c.Get("db")
 	if !ok {
@@ -173,13 +329,19 @@ func GetEncounterScenes(c *gin.Context) {
 	}
 	db := dbAny.(*gorm.DB)
 
-	var rows []string
-	if err := db.
-		Table("encounters").
-		Select("DISTINCT scene_name").
-		Where("scene_name IS NOT NULL AND scene_name <> ''").
-		Order("scene_name ASC").
-		Pluck("scene_name", &rows).Error; err != nil {
+	rows := []EncounterScene{}
+	if err := db.Raw(`
+		SELECT s.id, COALESCE(NULLIF(s.names->>?, ''), s.name) AS name, s.difficulty, COUNT(*) AS encounters, MAX(e.started_at) AS last_seen
+		FROM encounters e
+		JOIN scenes s ON s.id = e.scene_id
+		WHERE `+moderation.ListedSQL("e")+`
+		GROUP BY s.id, s.name, s.difficulty
+		UNION ALL
+		SELECT NULL, MIN(e.scene_name), '', COUNT(*), MAX(e.started_at)
+		FROM encounters e
+		WHERE e.scene_id IS NULL AND e.scene_name IS NOT NULL AND e.scene_name <> '' AND `+moderation.ListedSQL("e")+`
+		GROUP BY LOWER(e.scene_name)
+		ORDER BY 2 ASC`, c.GetString("locale")).Scan(&rows).Error; err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to load scenes"})
 		return
 	}
@@ -189,9 +351,12 @@ func GetEncounterScenes(c *gin.Context) {
 type GetEncounterPlayerSkillStatsResponse struct {
 	DamageSkillStats []models.DamageSkillStat `json:"damageSkillStats"`
 	HealSkillStats   []models.HealSkillStat   `json:"healSkillStats"`
+	Names            *gamedata.Names          `json:"names,omitempty"`
 }
 
 // GET /api/v1/encounter/:id/:playerId
+// Optional query params:
+//   - names: bool (include skill names for the skill IDs in the response)
 func GetPlayerSkillStats(c *gin.Context) {
 	dbAny, ok := c.Get("db")
 	if !ok {
@@ -255,5 +420,16 @@ func GetPlayerSkillStats(c *gin.Context) {
 		return
 	}
 
-	c.JSON(http.StatusOK, GetEncounterPlayerSkillStatsResponse{DamageSkillStats: dmgStats, HealSkillStats: healStats})
+	resp := GetEncounterPlayerSkillStatsResponse{DamageSkillStats: dmgStats, HealSkillStats: healStats}
+	if wantNames(c) {
+		names := gamedata.Get().NewNames(c.GetString("locale"))
+		for _, s := range dmgStats {
+			names.AddSkill(s.SkillID)
+		}
+		for _, s := range healStats {
+			names.AddSkill(s.SkillID)
+		}
+		resp.Names = names
+	}
+	c.JSON(http.StatusOK, resp)
 }
@@ -0,0 +1,466 @@
+package encounter
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	apiErrors "server/controller"
+	"server/controller/upload"
+	"server/models"
+	"server/services/privacy"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// exportSchemaVersion is the upload schema the JSON bundle is written in.
+const exportSchemaVersion = 2
+
+// GET /api/v1/encounter/:id/export
+// Query params:
+//   - format: json | csv (default json)
+//
+// The JSON bundle is an UploadEncountersRequest holding this one encounter and can be
+// posted back to POST /upload/ as-is. The CSV format is a zip of players, damage skills,
+// heal skills, deaths, attempts and phases.
+//
+// Exports contain the same data GET /encounter/:id publishes: player actors only, with the
+// names of private characters cleared. The uploader's identity and the upload's source hash
+// are never included, and character snapshots (detailed player data) are only added to the
+// JSON bundle when the requester is the user who uploaded them and the character is not
+// private. Held encounters can only be exported by their uploader or an admin.
+func ExportEncounter(c *gin.Context) {
+	dbAny, ok := c.Get("db")
+	if !ok {
+		c.JSON(http.StatusInternalServerError, apiErrors.NewErrorResponse(http.StatusInternalServerError, "Database not available in context"))
+		return
+	}
+	db := dbAny.(*gorm.DB)
+
+	encID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, apiErrors.NewErrorResponse(http.StatusBadRequest, "Invalid encounter id", err.Error()))
+		return
+	}
+	format := strings.ToLower(c.DefaultQuery("format", "json"))
+	if format != "json" && format != "csv" {
+		c.JSON(http.StatusBadRequest, apiErrors.NewErrorResponse(http.StatusBadRequest, "Invalid format (expected json or csv)"))
+		return
+	}
+
+	var enc models.Encounter
+	if err := db.
+		Preload("Bosses").
+		Preload("Players", func(db *gorm.DB) *gorm.DB {
+			return db.Where("actor_encounter_stats.is_player = ?", true).Order("actor_encounter_stats.id ASC")
+		}).
+		Preload("Attempts", func(db *gorm.DB) *gorm.DB {
+			return db.Order("attempts.attempt_index ASC")
+		}).
+		Preload("DeathEvents", func(db *gorm.DB) *gorm.DB {
+			return db.Order("death_events.timestamp ASC, death_events.id ASC")
+		}).
+		Preload("Phases", func(db *gorm.DB) *gorm.DB {
+			return db.Order("encounter_phases.start_time ASC, encounter_phases.id ASC")
+		}).
+		Preload("DamageSkillStats", func(db *gorm.DB) *gorm.DB {
+			return db.Order("damage_skill_stats.id ASC")
+		}).
+		Preload("HealSkillStats", func(db *gorm.DB) *gorm.DB {
+			return db.Order("heal_skill_stats.id ASC")
+		}).
+		Where("id = ?", encID).
+		First(&enc).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			c.JSON(http.StatusNotFound, apiErrors.NewErrorResponse(http.StatusNotFound, "Encounter not found"))
+			return
+		}
+		c.JSON(http.StatusInternalServerError, apiErrors.NewErrorResponse(http.StatusInternalServerError, "Failed to load encounter", err.Error()))
+		return
+	}
+
+	var requester *models.User
+	if userAny, ok := c.Get("user"); ok {
+		requester, _ = userAny.(*models.User)
+	}
+	if !exportableBy(enc, requester) {
+		c.JSON(http.StatusNotFound, apiErrors.NewErrorResponse(http.StatusNotFound, "Encounter not found"))
+		return
+	}
+
+	private, err := privacy.PrivateActors(db, playerActorIDs(enc.Players))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, apiErrors.NewErrorResponse(http.StatusInternalServerError, "Failed to load encounter", err.Error()))
+		return
+	}
+	for i := range enc.Players {
+		if private[enc.Players[i].ActorID] {
+			enc.Players[i].Name = nil
+		}
+	}
+
+	var attemptStats []models.AttemptActorStat
+	if err := db.Where("encounter_id = ?", enc.ID).Order("attempt_index ASC, id ASC").Find(&attemptStats).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, apiErrors.NewErrorResponse(http.StatusInternalServerError, "Failed to load attempt stats", err.Error()))
+		return
+	}
+	var phaseStats []models.PhaseActorStat
+	if err := db.Where("encounter_id = ?", enc.ID).Order("phase_id ASC, id ASC").Find(&phaseStats).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, apiErrors.NewErrorResponse(http.StatusInternalServerError, "Failed to load phase stats", err.Error()))
+		return
+	}
+
+	filename := fmt.Sprintf("encounter-%d", enc.ID)
+	if format == "csv" {
+		body, err := buildEncounterCSVZip(enc)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, apiErrors.NewErrorResponse(http.StatusInternalServerError, "Failed to build export", err.Error()))
+			return
+		}
+		c.Header("Content-Disposition", `attachment; filename="`+filename+`.zip"`)
+		c.Data(http.StatusOK, "application/zip", body)
+		return
+	}
+
+	bundle := upload.UploadEncountersRequest{
+		SchemaVersion: func() *int { v := exportSchemaVersion; return &v }(),
+		Encounters:    []upload.EncounterIn{encounterToUpload(enc, attemptStats, phaseStats)},
+	}
+
+	// Character snapshots belong to the uploader; only hand them back to that user, and
+	// never for characters that opted out of public listings
+	if requester != nil && requester.ID == enc.UserID {
+		actorIDs := make([]int64, 0, len(enc.Players))
+		for _, id := range playerActorIDs(enc.Players) {
+			if !private[id] {
+				actorIDs = append(actorIDs, id)
+			}
+		}
+		if len(actorIDs) > 0 {
+			var pds []models.DetailedPlayerData
+			if err := db.Where("player_id IN ? AND user_id = ?", actorIDs, requester.ID).Find(&pds).Error; err != nil {
+				c.JSON(http.StatusInternalServerError, apiErrors.NewErrorResponse(http.StatusInternalServerError, "Failed to load player data", err.Error()))
+				return
+			}
+			for _, pd := range pds {
+				bundle.Encounters[0].DetailedPlayerData = append(bundle.Encounters[0].DetailedPlayerData, upload.DetailedPlayerDataIn{
+					PlayerID:           pd.PlayerID,
+					LastSeenMs:         pd.LastSeenMs,
+					CharSerializeJSON:  pd.CharSerializeJSON,
+					ProfessionListJSON: optionalString(pd.ProfessionListJSON),
+					TalentNodeIDsJSON:  optionalString(pd.TalentNodeIDsJSON),
+				})
+			}
+		}
+	}
+
+	c.Header("Content-Disposition", `attachment; filename="`+filename+`.json"`)
+	c.JSON(http.StatusOK, bundle)
+}
+
+// exportableBy reports whether user (nil when anonymous) may export enc: held encounters
+// only by their uploader or an admin.
+func exportableBy(enc models.Encounter, user *models.User) bool {
+	return !enc.Held || (user != nil && (user.ID == enc.UserID || user.Role == "admin"))
+}
+
+// playerActorIDs lists the actor IDs of an encounter's players.
+func playerActorIDs(players []models.ActorEncounterStat) []int64 {
+	ids := make([]int64, 0, len(players))
+	for _, p := range players {
+		ids = append(ids, p.ActorID)
+	}
+	return ids
+}
+
+// encounterToUpload converts a stored encounter back into the upload payload shape. The
+// source hash is left out so the bundle does not carry the original upload's identity.
+func encounterToUpload(enc models.Encounter, attemptStats []models.AttemptActorStat, phaseStats []models.PhaseActorStat) upload.EncounterIn {
+	totalDmg, totalHeal := enc.TotalDmg, enc.TotalHeal
+	e := upload.EncounterIn{
+		StartedAtMs:   enc.StartedAt.UnixMilli(),
+		EndedAtMs:     optionalMillis(enc.EndedAt),
+		LocalPlayerID: enc.LocalPlayerID,
+		TotalDmg:      &totalDmg,
+		TotalHeal:     &totalHeal,
+		SceneID:       enc.SceneID,
+		SceneName:     enc.SceneName,
+	}
+
+	attemptActors := make(map[int][]upload.SegmentActorStatIn)
+	for _, s := range attemptStats {
+		attemptActors[s.AttemptIndex] = append(attemptActors[s.AttemptIndex], segmentActorToUpload(s.ActorID, s.DamageDealt, s.HealDealt, s.DamageTaken, s.HitsDealt, s.HitsHeal, s.HitsTaken, s.BossDamageDealt, s.DPS, s.Duration))
+	}
+	for _, a := range enc.Attempts {
+		e.Attempts = append(e.Attempts, upload.AttemptIn{
+			AttemptIndex: a.AttemptIndex,
+			StartedAtMs:  a.StartedAt.UnixMilli(),
+			EndedAtMs:    optionalMillis(a.EndedAt),
+			Reason:       a.Reason,
+			BossHpStart:  a.BossHpStart,
+			BossHpEnd:    a.BossHpEnd,
+			TotalDeaths:  a.TotalDeaths,
+			ActorStats:   attemptActors[a.AttemptIndex],
+		})
+	}
+
+	phaseActors := make(map[int64][]upload.SegmentActorStatIn)
+	for _, s := range phaseStats {
+		phaseActors[s.PhaseID] = append(phaseActors[s.PhaseID], segmentActorToUpload(s.ActorID, s.DamageDealt, s.HealDealt, s.DamageTaken, s.HitsDealt, s.HitsHeal, s.HitsTaken, s.BossDamageDealt, s.DPS, s.Duration))
+	}
+	for _, p := range enc.Phases {
+		e.Phases = append(e.Phases, upload.EncounterPhaseIn{
+			PhaseType:   p.PhaseType,
+			StartTimeMs: p.StartTime.UnixMilli(),
+			EndTimeMs:   optionalMillis(p.EndTime),
+			Outcome:     p.Outcome,
+			ActorStats:  phaseActors[p.ID],
+		})
+	}
+
+	for _, d := range enc.DeathEvents {
+		e.DeathEvents = append(e.DeathEvents, upload.DeathEventIn{
+			TimestampMs:   d.Timestamp.UnixMilli(),
+			ActorID:       d.ActorID,
+			KillerID:      d.KillerID,
+			SkillID:       d.SkillID,
+			IsLocalPlayer: d.IsLocalPlayer,
+			AttemptIndex:  d.AttemptIndex,
+		})
+	}
+
+	for _, p := range enc.Players {
+		in := upload.ActorEncounterStatIn{
+			ActorID:     p.ActorID,
+			ClassSpec:   p.ClassSpec,
+			DamageDealt: p.DamageDealt,
+			HealDealt:   p.HealDealt,
+			DamageTaken: p.DamageTaken,
+			HitsDealt:   p.HitsDealt,
+			HitsHeal:    p.HitsHeal,
+			HitsTaken:   p.HitsTaken,
+
+			CritHitsDealt:  &p.CritHitsDealt,
+			CritHitsHeal:   &p.CritHitsHeal,
+			CritHitsTaken:  &p.CritHitsTaken,
+			CritTotalDealt: &p.CritTotalDealt,
+			CritTotalHeal:  &p.CritTotalHeal,
+			CritTotalTaken: &p.CritTotalTaken,
+
+			LuckyHitsDealt:  &p.LuckyHitsDealt,
+			LuckyHitsHeal:   &p.LuckyHitsHeal,
+			LuckyHitsTaken:  &p.LuckyHitsTaken,
+			LuckyTotalDealt: &p.LuckyTotalDealt,
+			LuckyTotalHeal:  &p.LuckyTotalHeal,
+			LuckyTotalTaken: &p.LuckyTotalTaken,
+
+			BossDamageDealt:     &p.BossDamageDealt,
+			BossHitsDealt:       &p.BossHitsDealt,
+			BossCritHitsDealt:   &p.BossCritHitsDealt,
+			BossLuckyHitsDealt:  &p.BossLuckyHitsDealt,
+			BossCritTotalDealt:  &p.BossCritTotalDealt,
+			BossLuckyTotalDealt: &p.BossLuckyTotalDealt,
+
+			DPS:      &p.DPS,
+			Duration: &p.Duration,
+
+			Name:          p.Name,
+			ClassID:       p.ClassID,
+			AbilityScore:  p.AbilityScore,
+			Level:         p.Level,
+			IsPlayer:      p.IsPlayer,
+			IsLocalPlayer: p.IsLocalPlayer,
+			Revives:       &p.Revives,
+		}
+		if len(p.Attributes) > 0 {
+			in.Attributes = optionalString(string(p.Attributes))
+		}
+		e.ActorEncounterStats = append(e.ActorEncounterStats, in)
+	}
+
+	for _, s := range enc.DamageSkillStats {
+		e.DamageSkillStats = append(e.DamageSkillStats, upload.DamageSkillStatIn{
+			AttackerID:      s.AttackerID,
+			DefenderID:      s.DefenderID,
+			SkillID:         s.SkillID,
+			Hits:            s.Hits,
+			TotalValue:      s.TotalValue,
+			CritHits:        s.CritHits,
+			LuckyHits:       s.LuckyHits,
+			CritTotal:       s.CritTotal,
+			LuckyTotal:      s.LuckyTotal,
+			HpLossTotal:     s.HpLossTotal,
+			ShieldLossTotal: s.ShieldLossTotal,
+			HitDetails:      json.RawMessage(s.HitDetails),
+			MonsterName:     s.MonsterName,
+		})
+	}
+
+	for _, s := range enc.HealSkillStats {
+		e.HealSkillStats = append(e.HealSkillStats, upload.HealSkillStatIn{
+			HealerID:    s.HealerID,
+			TargetID:    s.TargetID,
+			SkillID:     s.SkillID,
+			Hits:        s.Hits,
+			TotalValue:  s.TotalValue,
+			CritHits:    s.CritHits,
+			LuckyHits:   s.LuckyHits,
+			CritTotal:   s.CritTotal,
+			LuckyTotal:  s.LuckyTotal,
+			MonsterName: s.MonsterName,
+		})
+	}
+
+	for _, b := range enc.Bosses {
+		e.EncounterBosses = append(e.EncounterBosses, upload.EncounterBossIn{
+			MonsterName: b.MonsterName,
+			MonsterID:   b.MonsterID,
+			Hits:        b.Hits,
+			TotalDamage: b.TotalDamage,
+			MaxHP:       b.MaxHP,
+			IsDefeated:  b.IsDefeated,
+		})
+	}
+
+	return e
+}
+
+func segmentActorToUpload(actorID, dmg, heal, taken, hitsDealt, hitsHeal, hitsTaken, bossDmg int64, dps, dur float64) upload.SegmentActorStatIn {
+	return upload.SegmentActorStatIn{
+		ActorID:         actorID,
+		DamageDealt:     dmg,
+		HealDealt:       heal,
+		DamageTaken:     taken,
+		HitsDealt:       hitsDealt,
+		HitsHeal:        hitsHeal,
+		HitsTaken:       hitsTaken,
+		BossDamageDealt: &bossDmg,
+		DPS:             &dps,
+		Duration:        &dur,
+	}
+}
+
+// buildEncounterCSVZip writes one CSV per encounter table into an in-memory zip.
+// Timestamps are unix milliseconds, matching the upload format.
+func buildEncounterCSVZip(enc models.Encounter) ([]byte, error) {
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+
+	files := []struct {
+		name   string
+		header []string
+		rows   [][]string
+	}{
+		{"players.csv", []string{"actor_id", "name", "class_id", "class_spec", "ability_score", "level", "is_player", "is_local_player", "damage_dealt", "heal_dealt", "damage_taken", "boss_damage_dealt", "hits_dealt", "hits_heal", "hits_taken", "crit_hits_dealt", "crit_total_dealt", "lucky_hits_dealt", "lucky_total_dealt", "dps", "duration", "revives"}, nil},
+		{"damage_skills.csv", []string{"attacker_id", "defender_id", "monster_name", "skill_id", "hits", "total_value", "crit_hits", "crit_total", "lucky_hits", "lucky_total", "hp_loss_total", "shield_loss_total"}, nil},
+		{"heal_skills.csv", []string{"healer_id", "target_id", "monster_name", "skill_id", "hits", "total_value", "crit_hits", "crit_total", "lucky_hits", "lucky_total"}, nil},
+		{"deaths.csv", []string{"timestamp_ms", "attempt_index", "actor_id", "killer_id", "skill_id", "is_local_player"}, nil},
+		{"attempts.csv", []string{"attempt_index", "started_at_ms", "ended_at_ms", "reason", "boss_hp_start", "boss_hp_end", "total_deaths"}, nil},
+		{"phases.csv", []string{"phase_type", "start_time_ms", "end_time_ms", "outcome"}, nil},
+	}
+
+	for _, p := range enc.Players {
+		files[0].rows = append(files[0].rows, []string{
+			csvInt(p.ActorID), csvOptString(p.Name), csvOptInt(p.ClassID), csvOptInt(p.ClassSpec), csvOptInt(p.AbilityScore), csvOptIntN(p.Level),
+			strconv.FormatBool(p.IsPlayer), strconv.FormatBool(p.IsLocalPlayer),
+			csvInt(p.DamageDealt), csvInt(p.HealDealt), csvInt(p.DamageTaken), csvInt(p.BossDamageDealt),
+			csvInt(p.HitsDealt), csvInt(p.HitsHeal), csvInt(p.HitsTaken),
+			csvInt(p.CritHitsDealt), csvInt(p.CritTotalDealt), csvInt(p.LuckyHitsDealt), csvInt(p.LuckyTotalDealt),
+			csvFloat(p.DPS), csvFloat(p.Duration), csvInt(p.Revives),
+		})
+	}
+	for _, s := range enc.DamageSkillStats {
+		files[1].rows = append(files[1].rows, []string{
+			csvInt(s.AttackerID), csvOptInt(s.DefenderID), csvOptString(s.MonsterName), csvInt(s.SkillID),
+			csvInt(s.Hits), csvInt(s.TotalValue), csvInt(s.CritHits), csvInt(s.CritTotal), csvInt(s.LuckyHits), csvInt(s.LuckyTotal),
+			csvInt(s.HpLossTotal), csvInt(s.ShieldLossTotal),
+		})
+	}
+	for _, s := range enc.HealSkillStats {
+		files[2].rows = append(files[2].rows, []string{
+			csvInt(s.HealerID), csvOptInt(s.TargetID), csvOptString(s.MonsterName), csvInt(s.SkillID),
+			csvInt(s.Hits), csvInt(s.TotalValue), csvInt(s.CritHits), csvInt(s.CritTotal), csvInt(s.LuckyHits), csvInt(s.LuckyTotal),
+		})
+	}
+	for _, d := range enc.DeathEvents {
+		files[3].rows = append(files[3].rows, []string{
+			csvInt(d.Timestamp.UnixMilli()), strconv.Itoa(d.AttemptIndex), csvInt(d.ActorID), csvOptInt(d.KillerID), csvOptInt(d.SkillID), strconv.FormatBool(d.IsLocalPlayer),
+		})
+	}
+	for _, a := range enc.Attempts {
+		files[4].rows = append(files[4].rows, []string{
+			strconv.Itoa(a.AttemptIndex), csvInt(a.StartedAt.UnixMilli()), csvOptInt(optionalMillis(a.EndedAt)), csvOptString(a.Reason),
+			csvOptInt(a.BossHpStart), csvOptInt(a.BossHpEnd), strconv.Itoa(a.TotalDeaths),
+		})
+	}
+	for _, p := range enc.Phases {
+		files[5].rows = append(files[5].rows, []string{
+			p.PhaseType, csvInt(p.StartTime.UnixMilli()), csvOptInt(optionalMillis(p.EndTime)), p.Outcome,
+		})
+	}
+
+	for _, f := range files {
+		w, err := zw.Create(f.name)
+		if err != nil {
+			return nil, err
+		}
+		cw := csv.NewWriter(w)
+		if err := cw.Write(f.header); err != nil {
+			return nil, err
+		}
+		if err := cw.WriteAll(f.rows); err != nil {
+			return nil, err
+		}
+	}
+	if err := zw.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func optionalMillis(t *time.Time) *int64 {
+	if t == nil {
+		return nil
+	}
+	ms := t.UnixMilli()
+	return &ms
+}
+
+func optionalString(s string) *string {
+	if s == "" {
+		return nil
+	}
+	return &s
+}
+
+func csvInt(v int64) string { return strconv.FormatInt(v, 10) }
+
+func csvFloat(v float64) string { return strconv.FormatFloat(v, 'f', -1, 64) }
+
+func csvOptInt(v *int64) string {
+	if v == nil {
+		return ""
+	}
+	return csvInt(*v)
+}
+
+func csvOptIntN(v *int) string {
+	if v == nil {
+		return ""
+	}
+	return strconv.Itoa(*v)
+}
+
+func csvOptString(v *string) string {
+	if v == nil {
+		return ""
+	}
+	return *v
+}
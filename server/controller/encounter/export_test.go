@@ -0,0 +1,136 @@
+package encounter
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/csv"
+	"net/http"
+	"testing"
+	"time"
+
+	"server/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestExportEncounter_RejectsInvalidParams(t *testing.T) {
+	cases := []struct {
+		target string
+		id     string
+	}{
+		{"/api/v1/encounter/abc/export", "abc"},
+		{"/api/v1/encounter/1/export?format=xml", "1"},
+	}
+	for _, tc := range cases {
+		c, w, stmts := dryRunContext(t, tc.target, gin.Params{{Key: "id", Value: tc.id}})
+		ExportEncounter(c)
+		if w.Code != http.StatusBadRequest {
+			t.Errorf("%s: expected 400, got %d", tc.target, w.Code)
+		}
+		if got := stmts.All(); len(got) != 0 {
+			t.Errorf("%s: expected no queries, got %v", tc.target, got)
+		}
+	}
+}
+
+func TestExportableBy_HeldOnlyForUploaderAndAdmins(t *testing.T) {
+	held := models.Encounter{ID: 1, UserID: 7, Held: true}
+	cases := []struct {
+		name string
+		enc  models.Encounter
+		user *models.User
+		want bool
+	}{
+		{"listed, anonymous", models.Encounter{ID: 1, UserID: 7}, nil, true},
+		{"held, anonymous", held, nil, false},
+		{"held, another user", held, &models.User{ID: 8, Role: "user"}, false},
+		{"held, uploader", held, &models.User{ID: 7, Role: "user"}, true},
+		{"held, admin", held, &models.User{ID: 9, Role: "admin"}, true},
+	}
+	for _, tc := range cases {
+		if got := exportableBy(tc.enc, tc.user); got != tc.want {
+			t.Errorf("%s: expected %v, got %v", tc.name, tc.want, got)
+		}
+	}
+}
+
+func exportFixture() (models.Encounter, []models.AttemptActorStat, []models.PhaseActorStat) {
+	start := time.UnixMilli(1760000000000).UTC()
+	hash := "abc123"
+	name := "Ann"
+	enc := models.Encounter{
+		ID: 1, StartedAt: start, TotalDmg: 1000, SourceHash: &hash, UserID: 7,
+		Players: []models.ActorEncounterStat{
+			{ActorID: 10, Name: &name, IsPlayer: true, DamageDealt: 600, DPS: 60},
+			// A private character, redacted by the handler before export
+			{ActorID: 20, IsPlayer: true, DamageDealt: 400, DPS: 40},
+		},
+		Attempts: []models.Attempt{{AttemptIndex: 1, StartedAt: start}, {AttemptIndex: 2, StartedAt: start.Add(time.Minute)}},
+		Phases:   []models.EncounterPhase{{ID: 5, PhaseType: "boss", StartTime: start, Outcome: "success"}},
+	}
+	attemptStats := []models.AttemptActorStat{{AttemptIndex: 2, ActorID: 10, DamageDealt: 300}}
+	phaseStats := []models.PhaseActorStat{{PhaseID: 5, ActorID: 20, DamageDealt: 200}}
+	return enc, attemptStats, phaseStats
+}
+
+func TestEncounterToUpload_LeavesOutUploadIdentity(t *testing.T) {
+	enc, attemptStats, phaseStats := exportFixture()
+	e := encounterToUpload(enc, attemptStats, phaseStats)
+
+	if e.SourceHash != nil {
+		t.Errorf("Expected the source hash to be left out, got %q", *e.SourceHash)
+	}
+	if e.StartedAtMs != 1760000000000 || len(e.ActorEncounterStats) != 2 {
+		t.Fatalf("Unexpected encounter: %+v", e)
+	}
+	if e.ActorEncounterStats[1].Name != nil {
+		t.Errorf("Expected the redacted name to stay empty, got %q", *e.ActorEncounterStats[1].Name)
+	}
+	// Segment stats go back to the attempt and phase they were recorded for
+	if len(e.Attempts) != 2 || len(e.Attempts[0].ActorStats) != 0 || len(e.Attempts[1].ActorStats) != 1 {
+		t.Errorf("Expected attempt 2 to carry its actor stats, got %+v", e.Attempts)
+	}
+	if len(e.Phases) != 1 || len(e.Phases[0].ActorStats) != 1 || e.Phases[0].ActorStats[0].ActorID != 20 {
+		t.Errorf("Expected the phase to carry its actor stats, got %+v", e.Phases)
+	}
+}
+
+func TestBuildEncounterCSVZip_WritesOneFilePerTable(t *testing.T) {
+	enc, _, _ := exportFixture()
+	body, err := buildEncounterCSVZip(enc)
+	if err != nil {
+		t.Fatal(err)
+	}
+	zr, err := zip.NewReader(bytes.NewReader(body), int64(len(body)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	rows := make(map[string][][]string)
+	for _, f := range zr.File {
+		rc, err := f.Open()
+		if err != nil {
+			t.Fatal(err)
+		}
+		records, err := csv.NewReader(rc).ReadAll()
+		rc.Close()
+		if err != nil {
+			t.Fatalf("%s: %v", f.Name, err)
+		}
+		rows[f.Name] = records
+	}
+
+	want := map[string]int{"players.csv": 2, "damage_skills.csv": 0, "heal_skills.csv": 0, "deaths.csv": 0, "attempts.csv": 2, "phases.csv": 1}
+	for name, n := range want {
+		if got := len(rows[name]) - 1; got != n {
+			t.Errorf("%s: expected a header and %d rows, got %d rows", name, n, got)
+		}
+	}
+	if players := rows["players.csv"]; len(players) == 3 {
+		if players[1][0] != "10" || players[1][1] != "Ann" || players[2][1] != "" {
+			t.Errorf("Unexpected player rows: %v", players[1:])
+		}
+	}
+	if attempts := rows["attempts.csv"]; len(attempts) == 3 && attempts[1][1] != "1760000000000" {
+		t.Errorf("Expected timestamps in unix milliseconds, got %v", attempts[1])
+	}
+}
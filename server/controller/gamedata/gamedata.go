@@ -0,0 +1,76 @@
+package gamedata
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+
+	apiErrors "server/controller"
+	"server/services/gamedata"
+
+	"github.com/gin-gonic/gin"
+)
+
+// The tables only change with a deploy, so clients may reuse them for an hour and
+// revalidate with the ETag afterwards.
+const cacheControl = "public, max-age=3600"
+
+// GET /api/v1/gamedata
+// Lists the loaded reference tables with their version, ETag and entry count. Tables the
+// deployment has no data for are not listed.
+func ListTables(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{"tables": gamedata.Get().Tables()})
+}
+
+// GET /api/v1/gamedata/:table
+// Returns a whole table: skills, classes, and scenes, monsters or talents when GAMEDATA_DIR
+// provides them. Honours If-None-Match.
+func GetTable(c *gin.Context) {
+	t, ok := gamedata.Get().Table(c.Param("table"))
+	if !ok {
+		c.JSON(http.StatusNotFound, apiErrors.NewErrorResponse(http.StatusNotFound, "Unknown game data table"))
+		return
+	}
+	if notModified(c, t.ETag) {
+		return
+	}
+	c.Data(http.StatusOK, "application/json; charset=utf-8", t.Body())
+}
+
+// GET /api/v1/gamedata/:table/:id
+// Returns a single entry of a table. The ETag is the table's.
+func GetTableEntry(c *gin.Context) {
+	t, ok := gamedata.Get().Table(c.Param("table"))
+	if !ok {
+		c.JSON(http.StatusNotFound, apiErrors.NewErrorResponse(http.StatusNotFound, "Unknown game data table"))
+		return
+	}
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, apiErrors.NewErrorResponse(http.StatusBadRequest, "Invalid id"))
+		return
+	}
+	entry, ok := t.Entry(id)
+	if !ok {
+		c.JSON(http.StatusNotFound, apiErrors.NewErrorResponse(http.StatusNotFound, "Entry not found"))
+		return
+	}
+	if notModified(c, t.ETag) {
+		return
+	}
+	c.Data(http.StatusOK, "application/json; charset=utf-8", entry)
+}
+
+// notModified sets the caching headers and answers 304 when the client already holds etag.
+func notModified(c *gin.Context, etag string) bool {
+	c.Header("ETag", etag)
+	c.Header("Cache-Control", cacheControl)
+	for _, tag := range strings.Split(c.GetHeader("If-None-Match"), ",") {
+		tag = strings.TrimPrefix(strings.TrimSpace(tag), "W/")
+		if tag == etag || tag == "*" {
+			c.Status(http.StatusNotModified)
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,257 @@
+package leaderboard
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	apiErrors "server/controller"
+	"server/lib"
+	"server/services/moderation"
+	"server/services/patches"
+	"server/services/privacy"
+	"server/services/scenes"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// Leaderboard metrics. Each maps to a per-second rate over the actor's active time, except
+// boss DPS which is over the encounter's boss-active time.
+const (
+	MetricDPS     = "dps"
+	MetricBossDPS = "boss_dps"
+	MetricHPS     = "hps"
+	MetricDTPS    = "dtps" // damage taken per second
+)
+
+// metricSQL returns the SQL expression for a metric over actor_encounter_stats a
+// joined with encounters e.
+func metricSQL(metric string) (string, bool) {
+	switch metric {
+	case MetricDPS:
+		return "a.dps", true
+	case MetricBossDPS:
+		return "(CASE WHEN " + lib.BossDurationSQL("e") + " > 0 THEN a.boss_damage_dealt::double precision / " + lib.BossDurationSQL("e") + " ELSE 0 END)", true
+	case MetricHPS:
+		return lib.ActorRateSQL("a.heal_dealt", "a", "e"), true
+	case MetricDTPS:
+		return lib.ActorRateSQL("a.damage_taken", "a", "e"), true
+	}
+	return "", false
+}
+
+func parseMetric(v string) (string, bool) {
+	switch strings.ToLower(strings.TrimSpace(v)) {
+	case "", MetricDPS:
+		return MetricDPS, true
+	case MetricBossDPS, "bossdps", "boss-dps":
+		return MetricBossDPS, true
+	case MetricHPS:
+		return MetricHPS, true
+	case MetricDTPS, "damage_taken", "damagetaken":
+		return MetricDTPS, true
+	}
+	return "", false
+}
+
+// LeaderboardEntry is a single ranked parse.
+type LeaderboardEntry struct {
+	Rank         int64     `gorm:"column:rank" json:"rank"`
+	ActorID      int64     `gorm:"column:actor_id" json:"actorId"`
+	Name         *string   `gorm:"column:name" json:"name,omitempty"`
+	ClassID      *int64    `gorm:"column:class_id" json:"classId,omitempty"`
+	ClassSpec    *int64    `gorm:"column:class_spec" json:"classSpec,omitempty"`
+	AbilityScore *int64    `gorm:"column:ability_score" json:"abilityScore,omitempty"`
+	EncounterID  int64     `gorm:"column:encounter_id" json:"encounterId"`
+	SceneName    *string   `gorm:"column:scene_name" json:"sceneName,omitempty"`
+	StartedAt    time.Time `gorm:"column:started_at" json:"startedAt"`
+	Duration     float64   `gorm:"column:duration" json:"duration"`
+	Value        float64   `gorm:"column:value" json:"value"`
+}
+
+type GetLeaderboardResponse struct {
+	Metric  string             `json:"metric"`
+	Mode    string             `json:"mode"`
+	Entries []LeaderboardEntry `json:"entries"`
+	Total   int64              `json:"total"`
+	Limit   int                `json:"limit"`
+	Offset  int                `json:"offset"`
+}
+
+// parseRange parses a "min,max" query value where either side may be omitted.
+func parseRange(v string, parse func(string) (interface{}, error)) (lo, hi interface{}, err error) {
+	parts := strings.Split(v, ",")
+	if s := strings.TrimSpace(parts[0]); s != "" {
+		if lo, err = parse(s); err != nil {
+			return nil, nil, fmt.Errorf("invalid min value %q", s)
+		}
+	}
+	if len(parts) >= 2 {
+		if s := strings.TrimSpace(parts[1]); s != "" {
+			if hi, err = parse(s); err != nil {
+				return nil, nil, fmt.Errorf("invalid max value %q", s)
+			}
+		}
+	}
+	return lo, hi, nil
+}
+
+func parseInt(s string) (interface{}, error)   { return strconv.ParseInt(s, 10, 64) }
+func parseFloat(s string) (interface{}, error) { return strconv.ParseFloat(s, 64) }
+
+// buildFilters turns the shared leaderboard query params into a WHERE clause over
+// actor_encounter_stats a joined with encounters e.
+func buildFilters(c *gin.Context) (string, []interface{}, error) {
+	where := "WHERE a.is_player = true AND a.name IS NOT NULL AND a.name <> '' AND " + moderation.ListedSQL("e") +
+		" AND " + privacy.PublicSQL("a.actor_id")
+	var args []interface{}
+
+	if v := strings.TrimSpace(c.Query("scene_name")); v != "" {
+		sceneSQL, sceneArgs := scenes.NameFilter("e", v)
+		where += " AND " + sceneSQL
+		args = append(args, sceneArgs...)
+	}
+	if v := strings.TrimSpace(c.Query("patch")); v != "" {
+		where += " AND " + patches.FilterSQL("e.patch_id")
+		args = append(args, v)
+	}
+	intFilters := []struct{ param, column string }{
+		{"scene_id", "e.scene_id"},
+		{"class_id", "a.class_id"},
+		{"class_spec", "a.class_spec"},
+	}
+	for _, f := range intFilters {
+		if v := strings.TrimSpace(c.Query(f.param)); v != "" {
+			n, err := strconv.ParseInt(v, 10, 64)
+			if err != nil {
+				return "", nil, fmt.Errorf("invalid %s", f.param)
+			}
+			where += " AND " + f.column + " = ?"
+			args = append(args, n)
+		}
+	}
+
+	// ability_score and duration ranges: "min,max" where either side can be omitted
+	rangeFilters := []struct {
+		param, column string
+		parse         func(string) (interface{}, error)
+	}{
+		{"ability_score", "a.ability_score", parseInt},
+		{"duration", "e.duration", parseFloat},
+	}
+	for _, f := range rangeFilters {
+		v := strings.TrimSpace(c.Query(f.param))
+		if v == "" {
+			continue
+		}
+		lo, hi, err := parseRange(v, f.parse)
+		if err != nil {
+			return "", nil, fmt.Errorf("%s: %w", f.param, err)
+		}
+		if lo != nil {
+			where += " AND " + f.column + " >= ?"
+			args = append(args, lo)
+		}
+		if hi != nil {
+			where += " AND " + f.column + " <= ?"
+			args = append(args, hi)
+		}
+	}
+
+	return where, args, nil
+}
+
+// GET /api/v1/leaderboard
+// Query params:
+//   - metric: dps | boss_dps | hps | dtps (default dps)
+//   - mode: best (one entry per player, their best parse; default) | all (every parse)
+//   - scene_name, scene_id, class_id, class_spec (optional exact filters)
+//   - patch: patch name (see GET /patches)
+//   - ability_score, duration: "min,max" ranges, either side optional
+//   - limit (default 25, max 100), offset
+//
+// Characters who opted out of public listings are left out.
+func GetLeaderboard(c *gin.Context) {
+	dbAny, ok := c.Get("db")
+	if !ok {
+		c.JSON(http.StatusInternalServerError, apiErrors.NewErrorResponse(http.StatusInternalServerError, "Database not available in context"))
+		return
+	}
+	db := dbAny.(*gorm.DB)
+
+	metric, ok := parseMetric(c.Query("metric"))
+	if !ok {
+		c.JSON(http.StatusBadRequest, apiErrors.NewErrorResponse(http.StatusBadRequest, "Invalid metric (expected dps, boss_dps, hps or dtps)"))
+		return
+	}
+	mode := strings.ToLower(strings.TrimSpace(c.DefaultQuery("mode", "best")))
+	if mode != "best" && mode != "all" {
+		c.JSON(http.StatusBadRequest, apiErrors.NewErrorResponse(http.StatusBadRequest, "Invalid mode (expected best or all)"))
+		return
+	}
+	limit := 25
+	if v := c.Query("limit"); v != "" {
+		if n, err := strconv.Atoi(v); err == nil && n > 0 && n <= 100 {
+			limit = n
+		}
+	}
+	offset := 0
+	if v := c.Query("offset"); v != "" {
+		if n, err := strconv.Atoi(v); err == nil && n >= 0 {
+			offset = n
+		}
+	}
+
+	where, args, err := buildFilters(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, apiErrors.NewErrorResponse(http.StatusBadRequest, "Invalid filter", err.Error()))
+		return
+	}
+	valueExpr, _ := metricSQL(metric)
+
+	// In best mode DISTINCT ON keeps each actor's highest parse (ties broken by the earliest row)
+	distinct, order := "", ""
+	if mode == "best" {
+		distinct = "DISTINCT ON (a.actor_id)"
+		order = "ORDER BY a.actor_id, value DESC, a.id ASC"
+	}
+	entriesSQL := fmt.Sprintf(`
+		SELECT %s a.id, a.actor_id, a.name, a.class_id, a.class_spec, a.ability_score, a.encounter_id,
+			   e.scene_name, e.started_at, e.duration, %s AS value
+		FROM actor_encounter_stats a
+		JOIN encounters e ON e.id = a.encounter_id
+		%s
+		%s`, distinct, valueExpr, where, order)
+
+	var total int64
+	if err := db.Raw("SELECT COUNT(*) FROM ("+entriesSQL+") t", args...).Scan(&total).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, apiErrors.NewErrorResponse(http.StatusInternalServerError, "Failed to count leaderboard entries", err.Error()))
+		return
+	}
+
+	pageSQL := `
+		SELECT RANK() OVER (ORDER BY t.value DESC) AS rank, t.*
+		FROM (` + entriesSQL + `) t
+		ORDER BY t.value DESC, t.id ASC
+		LIMIT ? OFFSET ?`
+	var entries []LeaderboardEntry
+	if err := db.Raw(pageSQL, append(args, limit, offset)...).Scan(&entries).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, apiErrors.NewErrorResponse(http.StatusInternalServerError, "Failed to query leaderboard", err.Error()))
+		return
+	}
+	if entries == nil {
+		entries = []LeaderboardEntry{}
+	}
+
+	c.JSON(http.StatusOK, GetLeaderboardResponse{
+		Metric:  metric,
+		Mode:    mode,
+		Entries: entries,
+		Total:   total,
+		Limit:   limit,
+		Offset:  offset,
+	})
+}
@@ -0,0 +1,178 @@
+package leaderboard
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	dbpkg "server/db"
+	"server/models"
+	"server/services/rollups"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+func TestMetricSQL_RatesUseActorDuration(t *testing.T) {
+	for _, metric := range []string{MetricHPS, MetricDTPS} {
+		expr, ok := metricSQL(metric)
+		if !ok {
+			t.Fatalf("Expected %s to be a known metric", metric)
+		}
+		if !strings.Contains(expr, "NULLIF(a.duration, 0)") {
+			t.Errorf("Expected %s to divide by the actor's duration, got %s", metric, expr)
+		}
+		if strings.Contains(expr, "/ e.duration") {
+			t.Errorf("Expected %s not to divide by the encounter's duration directly, got %s", metric, expr)
+		}
+	}
+
+	// DPS is the stored per-actor rate, which the client computes over the actor's duration
+	if expr, _ := metricSQL(MetricDPS); expr != "a.dps" {
+		t.Errorf("Expected dps to use a.dps, got %s", expr)
+	}
+
+	// The statistics endpoints rate HPS on the same basis
+	if expr, _ := metricSQL(MetricHPS); expr != rollups.SampleHPSSQL {
+		t.Errorf("Expected the leaderboard and statistics HPS to match, got %s and %s", expr, rollups.SampleHPSSQL)
+	}
+}
+
+func TestParseMetric(t *testing.T) {
+	cases := map[string]string{
+		"":             MetricDPS,
+		"DPS":          MetricDPS,
+		"boss-dps":     MetricBossDPS,
+		"hps":          MetricHPS,
+		"damage_taken": MetricDTPS,
+	}
+	for in, want := range cases {
+		got, ok := parseMetric(in)
+		if !ok || got != want {
+			t.Errorf("parseMetric(%q) = %q, %v; want %q", in, got, ok, want)
+		}
+	}
+	if _, ok := parseMetric("tps"); ok {
+		t.Error("Expected unknown metric to be rejected")
+	}
+}
+
+// seedLeaderboard creates the tables the leaderboard reads and fills them with:
+//   - actor 10: 100 DPS in encounter 1 and 300 DPS in encounter 2
+//   - actors 20 and 50: 200 DPS each, in encounters 1 and 2
+//   - actor 30: 500 DPS in encounter 1, but a private character
+//   - actor 40: 1000 DPS in encounter 3, which is held
+func seedLeaderboard(t *testing.T, db *gorm.DB) {
+	t.Helper()
+	if err := db.AutoMigrate(&models.User{}, &models.Scene{}, &models.Encounter{}, &models.ActorEncounterStat{}, &models.Character{}); err != nil {
+		t.Fatal(err)
+	}
+	if err := db.Create(&models.User{ID: 1, DiscordUserID: "1"}).Error; err != nil {
+		t.Fatal(err)
+	}
+	scene := "Dragon's Lair"
+	start := time.Date(2026, 10, 1, 12, 0, 0, 0, time.UTC)
+	for _, e := range []models.Encounter{
+		{ID: 1, StartedAt: start, Duration: 60, SceneName: &scene, UserID: 1},
+		{ID: 2, StartedAt: start.Add(time.Hour), Duration: 60, SceneName: &scene, UserID: 1},
+		{ID: 3, StartedAt: start.Add(2 * time.Hour), Duration: 60, SceneName: &scene, UserID: 1, Held: true},
+	} {
+		if err := db.Create(&e).Error; err != nil {
+			t.Fatal(err)
+		}
+	}
+	name := func(s string) *string { return &s }
+	for _, a := range []models.ActorEncounterStat{
+		// Healed 600 over 30 active seconds: 20 HPS
+		{EncounterID: 1, ActorID: 10, Name: name("Ann"), IsPlayer: true, DPS: 100, Duration: 30, HealDealt: 600},
+		// No active time reported, so rated over the encounter's 60 seconds: 10 HPS
+		{EncounterID: 1, ActorID: 20, Name: name("Bob"), IsPlayer: true, DPS: 200, HealDealt: 600},
+		{EncounterID: 1, ActorID: 30, Name: name("Cid"), IsPlayer: true, DPS: 500, Duration: 60},
+		{EncounterID: 2, ActorID: 10, Name: name("Ann"), IsPlayer: true, DPS: 300, Duration: 60},
+		{EncounterID: 2, ActorID: 50, Name: name("Eve"), IsPlayer: true, DPS: 200, Duration: 60},
+		{EncounterID: 3, ActorID: 40, Name: name("Dan"), IsPlayer: true, DPS: 1000, Duration: 60},
+	} {
+		if err := db.Create(&a).Error; err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := db.Create(&models.Character{ActorID: 30, Name: name("Cid"), LastSeenAt: start, IsPrivate: true}).Error; err != nil {
+		t.Fatal(err)
+	}
+}
+
+func getLeaderboard(t *testing.T, db *gorm.DB, query string) GetLeaderboardResponse {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request = httptest.NewRequest(http.MethodGet, "/api/v1/leaderboard?"+query, nil)
+	c.Set("db", db)
+	GetLeaderboard(c)
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected 200 for %q, got %d: %s", query, w.Code, w.Body.String())
+	}
+	var resp GetLeaderboardResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+		t.Fatal(err)
+	}
+	return resp
+}
+
+type rankedEntry struct {
+	rank, actorID, encounterID int64
+	value                      float64
+}
+
+func checkEntries(t *testing.T, query string, resp GetLeaderboardResponse, total int64, want []rankedEntry) {
+	t.Helper()
+	if resp.Total != total {
+		t.Errorf("%s: expected %d entries in total, got %d", query, total, resp.Total)
+	}
+	if len(resp.Entries) != len(want) {
+		t.Fatalf("%s: expected %d entries, got %+v", query, len(want), resp.Entries)
+	}
+	for i, w := range want {
+		got := resp.Entries[i]
+		if got.Rank != w.rank || got.ActorID != w.actorID || got.EncounterID != w.encounterID || got.Value != w.value {
+			t.Errorf("%s: entry %d is rank %d, actor %d, encounter %d, value %v; want %+v",
+				query, i, got.Rank, got.ActorID, got.EncounterID, got.Value, w)
+		}
+	}
+}
+
+// Needs a Postgres server in TEST_DATABASE_URL; the query can't be checked without one.
+func TestGetLeaderboard_RanksBestParsePerPlayer(t *testing.T) {
+	db, drop, err := dbpkg.TestDB()
+	if errors.Is(err, dbpkg.ErrNoTestDB) {
+		t.Skip(err)
+	}
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer drop()
+	seedLeaderboard(t, db)
+
+	// One entry per player, their best parse; tied values share a rank
+	checkEntries(t, "best", getLeaderboard(t, db, "mode=best"), 3, []rankedEntry{
+		{rank: 1, actorID: 10, encounterID: 2, value: 300},
+		{rank: 2, actorID: 20, encounterID: 1, value: 200},
+		{rank: 2, actorID: 50, encounterID: 2, value: 200},
+	})
+
+	// Every parse, paged after the ranks are assigned
+	checkEntries(t, "all", getLeaderboard(t, db, "mode=all&limit=2&offset=2"), 4, []rankedEntry{
+		{rank: 2, actorID: 50, encounterID: 2, value: 200},
+		{rank: 4, actorID: 10, encounterID: 1, value: 100},
+	})
+
+	// HPS over the actor's active time, or the encounter's when none was reported
+	checkEntries(t, "hps", getLeaderboard(t, db, "metric=hps&limit=2"), 3, []rankedEntry{
+		{rank: 1, actorID: 10, encounterID: 1, value: 20},
+		{rank: 2, actorID: 20, encounterID: 1, value: 10},
+	})
+}
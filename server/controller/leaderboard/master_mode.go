@@ -0,0 +1,133 @@
+package leaderboard
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	apiErrors "server/controller"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// MasterModeEntry is a character's highest master-mode clear.
+type MasterModeEntry struct {
+	Rank             int64   `gorm:"column:rank" json:"rank"`
+	ActorID          int64   `gorm:"column:actor_id" json:"actorId"`
+	Name             *string `gorm:"column:name" json:"name,omitempty"`
+	ClassID          *int64  `gorm:"column:class_id" json:"classId,omitempty"`
+	ClassSpec        *int64  `gorm:"column:class_spec" json:"classSpec,omitempty"`
+	AbilityScore     *int64  `gorm:"column:ability_score" json:"abilityScore,omitempty"`
+	DungeonID        int64   `gorm:"column:dungeon_id" json:"dungeonId"`
+	MasterDifficulty int64   `gorm:"column:master_difficulty" json:"masterDifficulty"`
+	MasterPassCount  int64   `gorm:"column:master_pass_count" json:"masterPassCount"`
+	MasterPassTime   int64   `gorm:"column:master_pass_time" json:"masterPassTime"`
+	LastSeenMs       int64   `gorm:"column:last_seen_ms" json:"lastSeenMs"`
+}
+
+type GetMasterModeLeaderboardResponse struct {
+	SeasonID int64             `json:"seasonId"`
+	Entries  []MasterModeEntry `json:"entries"`
+	Total    int64             `json:"total"`
+	Limit    int               `json:"limit"`
+	Offset   int               `json:"offset"`
+}
+
+// masterModeOrderSQL ranks clears by difficulty, then by clear time (unknown times last).
+const masterModeOrderSQL = "p.master_difficulty DESC, NULLIF(p.master_pass_time, 0) ASC NULLS LAST"
+
+// GET /api/v1/leaderboard/master-mode
+// Query params:
+//   - season_id: int (default: the latest season with a recorded clear)
+//   - dungeon_id: int (rank clears of this dungeon; default: each character's best clear
+//     in any dungeon)
+//   - limit (default 25, max 100), offset
+//
+// Progress comes from uploaded character snapshots. Private characters are left out.
+func GetMasterModeLeaderboard(c *gin.Context) {
+	dbAny, ok := c.Get("db")
+	if !ok {
+		c.JSON(http.StatusInternalServerError, apiErrors.NewErrorResponse(http.StatusInternalServerError, "Database not available in context"))
+		return
+	}
+	db := dbAny.(*gorm.DB)
+
+	var seasonID int64
+	if v := strings.TrimSpace(c.Query("season_id")); v != "" {
+		n, err := strconv.ParseInt(v, 10, 64)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, apiErrors.NewErrorResponse(http.StatusBadRequest, "Invalid season_id"))
+			return
+		}
+		seasonID = n
+	} else if err := db.Raw("SELECT COALESCE(MAX(master_season_id), 0) FROM dungeon_progress WHERE master_difficulty > 0").Scan(&seasonID).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, apiErrors.NewErrorResponse(http.StatusInternalServerError, "Failed to load latest season", err.Error()))
+		return
+	}
+
+	where := "WHERE p.master_difficulty > 0 AND p.master_season_id = ? AND COALESCE(ch.is_private, false) = false"
+	args := []interface{}{seasonID}
+	if v := strings.TrimSpace(c.Query("dungeon_id")); v != "" {
+		n, err := strconv.ParseInt(v, 10, 64)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, apiErrors.NewErrorResponse(http.StatusBadRequest, "Invalid dungeon_id"))
+			return
+		}
+		where += " AND p.dungeon_id = ?"
+		args = append(args, n)
+	}
+
+	limit := 25
+	if v := c.Query("limit"); v != "" {
+		if n, err := strconv.Atoi(v); err == nil && n > 0 && n <= 100 {
+			limit = n
+		}
+	}
+	offset := 0
+	if v := c.Query("offset"); v != "" {
+		if n, err := strconv.Atoi(v); err == nil && n >= 0 {
+			offset = n
+		}
+	}
+
+	// Each character's best clear among the matched dungeons
+	bestSQL := fmt.Sprintf(`
+		SELECT DISTINCT ON (p.player_id)
+			   p.player_id AS actor_id, ch.name, ch.class_id, ch.class_spec, ch.ability_score,
+			   p.dungeon_id, p.master_difficulty, p.master_pass_count, p.master_pass_time, p.last_seen_ms
+		FROM dungeon_progress p
+		LEFT JOIN characters ch ON ch.actor_id = p.player_id
+		%s
+		ORDER BY p.player_id, %s, p.dungeon_id ASC`, where, masterModeOrderSQL)
+
+	var total int64
+	if err := db.Raw("SELECT COUNT(*) FROM ("+bestSQL+") t", args...).Scan(&total).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, apiErrors.NewErrorResponse(http.StatusInternalServerError, "Failed to count master-mode clears", err.Error()))
+		return
+	}
+
+	order := strings.ReplaceAll(masterModeOrderSQL, "p.", "t.")
+	pageSQL := `
+		SELECT RANK() OVER (ORDER BY ` + order + `) AS rank, t.*
+		FROM (` + bestSQL + `) t
+		ORDER BY ` + order + `, t.actor_id ASC
+		LIMIT ? OFFSET ?`
+	var entries []MasterModeEntry
+	if err := db.Raw(pageSQL, append(args, limit, offset)...).Scan(&entries).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, apiErrors.NewErrorResponse(http.StatusInternalServerError, "Failed to query master-mode clears", err.Error()))
+		return
+	}
+	if entries == nil {
+		entries = []MasterModeEntry{}
+	}
+
+	c.JSON(http.StatusOK, GetMasterModeLeaderboardResponse{
+		SeasonID: seasonID,
+		Entries:  entries,
+		Total:    total,
+		Limit:    limit,
+		Offset:   offset,
+	})
+}
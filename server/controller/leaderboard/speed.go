@@ -0,0 +1,237 @@
+package leaderboard
+
+import (
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	apiErrors "server/controller"
+	"server/lib"
+	"server/services/gamedata"
+	"server/services/moderation"
+	"server/services/patches"
+	"server/services/privacy"
+	"server/services/scenes"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// PartyMember is one player in a speed-clear party.
+type PartyMember struct {
+	EncounterID  int64   `gorm:"column:encounter_id" json:"-"`
+	ActorID      int64   `gorm:"column:actor_id" json:"actorId"`
+	Name         *string `gorm:"column:name" json:"name,omitempty"`
+	ClassID      *int64  `gorm:"column:class_id" json:"classId,omitempty"`
+	ClassSpec    *int64  `gorm:"column:class_spec" json:"classSpec,omitempty"`
+	AbilityScore *int64  `gorm:"column:ability_score" json:"abilityScore,omitempty"`
+	DPS          float64 `gorm:"column:dps" json:"dps"`
+}
+
+// SpeedEntry is a ranked boss kill.
+type SpeedEntry struct {
+	Rank         int64         `gorm:"column:rank" json:"rank"`
+	EncounterID  int64         `gorm:"column:encounter_id" json:"encounterId"`
+	SceneID      *int64        `gorm:"column:scene_id" json:"sceneId,omitempty"`
+	SceneName    *string       `gorm:"column:scene_name" json:"sceneName,omitempty"`
+	StartedAt    time.Time     `gorm:"column:started_at" json:"startedAt"`
+	Duration     float64       `gorm:"column:duration" json:"duration"`
+	BossDuration float64       `gorm:"column:boss_duration" json:"bossDuration"`
+	KillTime     float64       `gorm:"column:kill_time" json:"killTime"`
+	PartySize    int           `gorm:"column:party_size" json:"partySize"`
+	Party        []PartyMember `gorm:"-" json:"party"`
+}
+
+type GetSpeedLeaderboardResponse struct {
+	Timing  string       `json:"timing"`
+	Entries []SpeedEntry `json:"entries"`
+	Total   int64        `json:"total"`
+	Limit   int          `json:"limit"`
+	Offset  int          `json:"offset"`
+}
+
+// GET /api/v1/leaderboard/speed
+// Query params:
+//   - scene_name or scene_id (one is required)
+//   - patch: patch name (see GET /patches)
+//   - timing: full (encounter duration; default) | boss (boss-active time only)
+//   - party_size: int (exact number of players)
+//   - composition: comma-separated class_spec ids the party must include; repeat an id to
+//     require it more than once (e.g. "3,3" = at least two of spec 3)
+//   - limit (default 25, max 100), offset
+//
+// A kill is an encounter whose bosses were all defeated, or that has a successful boss
+// phase. Only the fastest kill per unique party (player_set_hash) is kept. Party members
+// who opted out of public listings are listed without their name.
+func GetSpeedLeaderboard(c *gin.Context) {
+	dbAny, ok := c.Get("db")
+	if !ok {
+		c.JSON(http.StatusInternalServerError, apiErrors.NewErrorResponse(http.StatusInternalServerError, "Database not available in context"))
+		return
+	}
+	db := dbAny.(*gorm.DB)
+
+	where := "WHERE " + moderation.ListedSQL("e")
+	var args []interface{}
+
+	sceneName := strings.TrimSpace(c.Query("scene_name"))
+	sceneID := strings.TrimSpace(c.Query("scene_id"))
+	if sceneName == "" && sceneID == "" {
+		c.JSON(http.StatusBadRequest, apiErrors.NewErrorResponse(http.StatusBadRequest, "Missing required query param: scene_name or scene_id"))
+		return
+	}
+	if sceneName != "" {
+		sceneSQL, sceneArgs := scenes.NameFilter("e", sceneName)
+		where += " AND " + sceneSQL
+		args = append(args, sceneArgs...)
+	}
+	if sceneID != "" {
+		n, err := strconv.ParseInt(sceneID, 10, 64)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, apiErrors.NewErrorResponse(http.StatusBadRequest, "Invalid scene_id"))
+			return
+		}
+		where += " AND e.scene_id = ?"
+		args = append(args, n)
+	}
+	if v := strings.TrimSpace(c.Query("patch")); v != "" {
+		where += " AND " + patches.FilterSQL("e.patch_id")
+		args = append(args, v)
+	}
+
+	timing := strings.ToLower(strings.TrimSpace(c.DefaultQuery("timing", "full")))
+	killTimeExpr := "e.duration"
+	switch timing {
+	case "full":
+	case "boss":
+		killTimeExpr = lib.BossDurationSQL("e")
+	default:
+		c.JSON(http.StatusBadRequest, apiErrors.NewErrorResponse(http.StatusBadRequest, "Invalid timing (expected full or boss)"))
+		return
+	}
+
+	// Kill: every boss defeated, or a boss phase that ended in success
+	where += ` AND e.duration > 0 AND (
+		(EXISTS (SELECT 1 FROM encounter_bosses b WHERE b.encounter_id = e.id)
+			AND NOT EXISTS (SELECT 1 FROM encounter_bosses b WHERE b.encounter_id = e.id AND b.is_defeated = false))
+		OR EXISTS (SELECT 1 FROM encounter_phases ph WHERE ph.encounter_id = e.id AND ph.phase_type = 'boss' AND ph.outcome = 'success')
+	)`
+
+	partySizeExpr := "(SELECT COUNT(*) FROM actor_encounter_stats p WHERE p.encounter_id = e.id AND p.is_player = true)"
+	if v := strings.TrimSpace(c.Query("party_size")); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n <= 0 {
+			c.JSON(http.StatusBadRequest, apiErrors.NewErrorResponse(http.StatusBadRequest, "Invalid party_size"))
+			return
+		}
+		where += " AND " + partySizeExpr + " = ?"
+		args = append(args, n)
+	}
+	if v := strings.TrimSpace(c.Query("composition")); v != "" {
+		required := make(map[int64]int)
+		for _, part := range strings.Split(v, ",") {
+			part = strings.TrimSpace(part)
+			if part == "" {
+				continue
+			}
+			spec, err := strconv.ParseInt(part, 10, 64)
+			if err != nil {
+				c.JSON(http.StatusBadRequest, apiErrors.NewErrorResponse(http.StatusBadRequest, "Invalid composition", part))
+				return
+			}
+			required[spec]++
+		}
+		specs := make([]int64, 0, len(required))
+		for spec := range required {
+			specs = append(specs, spec)
+		}
+		sort.Slice(specs, func(i, j int) bool { return specs[i] < specs[j] })
+		for _, spec := range specs {
+			where += " AND (SELECT COUNT(*) FROM actor_encounter_stats p WHERE p.encounter_id = e.id AND p.is_player = true AND p.class_spec = ?) >= ?"
+			args = append(args, spec, required[spec])
+		}
+	}
+
+	limit := 25
+	if v := c.Query("limit"); v != "" {
+		if n, err := strconv.Atoi(v); err == nil && n > 0 && n <= 100 {
+			limit = n
+		}
+	}
+	offset := 0
+	if v := c.Query("offset"); v != "" {
+		if n, err := strconv.Atoi(v); err == nil && n >= 0 {
+			offset = n
+		}
+	}
+
+	// Encounters without a player_set_hash can't be grouped, so each stands alone
+	killsSQL := fmt.Sprintf(`
+		SELECT DISTINCT ON (COALESCE(e.player_set_hash, e.id::text))
+			   e.id AS encounter_id, e.scene_id, e.scene_name, e.started_at, e.duration, e.boss_duration,
+			   %s AS kill_time, %s AS party_size
+		FROM encounters e
+		%s
+		ORDER BY COALESCE(e.player_set_hash, e.id::text), %s ASC, e.id ASC`, killTimeExpr, partySizeExpr, where, killTimeExpr)
+
+	var total int64
+	if err := db.Raw("SELECT COUNT(*) FROM ("+killsSQL+") t", args...).Scan(&total).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, apiErrors.NewErrorResponse(http.StatusInternalServerError, "Failed to count speed clears", err.Error()))
+		return
+	}
+
+	pageSQL := `
+		SELECT RANK() OVER (ORDER BY t.kill_time ASC) AS rank, t.*
+		FROM (` + killsSQL + `) t
+		ORDER BY t.kill_time ASC, t.encounter_id ASC
+		LIMIT ? OFFSET ?`
+	var entries []SpeedEntry
+	if err := db.Raw(pageSQL, append(args, limit, offset)...).Scan(&entries).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, apiErrors.NewErrorResponse(http.StatusInternalServerError, "Failed to query speed clears", err.Error()))
+		return
+	}
+	if entries == nil {
+		entries = []SpeedEntry{}
+	}
+
+	if len(entries) > 0 {
+		ids := make([]int64, len(entries))
+		for i, e := range entries {
+			ids[i] = e.EncounterID
+			entries[i].Party = []PartyMember{}
+		}
+		var members []PartyMember
+		if err := db.Table("actor_encounter_stats").
+			Select("encounter_id, actor_id, "+privacy.NameSQL("actor_encounter_stats.actor_id", "name")+" AS name, class_id, class_spec, ability_score, dps").
+			Where("encounter_id IN ? AND is_player = ?", ids, true).
+			Order("dps DESC").
+			Scan(&members).Error; err != nil {
+			c.JSON(http.StatusInternalServerError, apiErrors.NewErrorResponse(http.StatusInternalServerError, "Failed to load parties", err.Error()))
+			return
+		}
+		byEncounter := make(map[int64]int, len(entries))
+		for i, e := range entries {
+			byEncounter[e.EncounterID] = i
+		}
+		for _, m := range members {
+			i := byEncounter[m.EncounterID]
+			entries[i].Party = append(entries[i].Party, m)
+		}
+	}
+
+	locale := c.GetString("locale")
+	for i := range entries {
+		entries[i].SceneName = gamedata.Get().LocalizeScene(entries[i].SceneID, entries[i].SceneName, locale)
+	}
+
+	c.JSON(http.StatusOK, GetSpeedLeaderboardResponse{
+		Timing:  timing,
+		Entries: entries,
+		Total:   total,
+		Limit:   limit,
+		Offset:  offset,
+	})
+}
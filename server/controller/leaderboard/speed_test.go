@@ -0,0 +1,170 @@
+package leaderboard
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	dbpkg "server/db"
+	"server/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+func getSpeedLeaderboard(t *testing.T, query string) (*httptest.ResponseRecorder, *dbpkg.Statements) {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+	db, stmts, err := dbpkg.DryRun()
+	if err != nil {
+		t.Fatal(err)
+	}
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request = httptest.NewRequest(http.MethodGet, "/api/v1/leaderboard/speed?"+query, nil)
+	c.Set("db", db)
+	GetSpeedLeaderboard(c)
+	return w, stmts
+}
+
+func TestGetSpeedLeaderboard_RejectsInvalidParams(t *testing.T) {
+	for _, query := range []string{
+		"",
+		"scene_id=abc",
+		"scene_id=1&timing=fastest",
+		"scene_id=1&party_size=0",
+		"scene_id=1&party_size=four",
+		"scene_id=1&composition=3,tank",
+	} {
+		w, stmts := getSpeedLeaderboard(t, query)
+		if w.Code != http.StatusBadRequest {
+			t.Errorf("%q: expected 400, got %d", query, w.Code)
+		}
+		if got := stmts.All(); len(got) != 0 {
+			t.Errorf("%q: expected no queries, got %v", query, got)
+		}
+	}
+}
+
+func TestGetSpeedLeaderboard_CountsCompositionPerSpec(t *testing.T) {
+	// Spec 3 is required twice, spec 5 once; blanks are ignored. The dry run can't count,
+	// so the handler stops after the count query.
+	w, stmts := getSpeedLeaderboard(t, "scene_id=1&composition=5,3,,3")
+	if w.Code == http.StatusBadRequest {
+		t.Fatalf("Expected the params to be accepted, got %s", w.Body.String())
+	}
+	counts := stmts.Matching("SELECT COUNT(*) FROM (")
+	if len(counts) != 1 {
+		t.Fatalf("Expected one count query, got %v", stmts.All())
+	}
+	q := counts[0]
+	for _, want := range []string{
+		"p.class_spec = 3) >= 2",
+		"p.class_spec = 5) >= 1",
+		"e.scene_id = 1",
+		"e.held = false",
+	} {
+		if !strings.Contains(q, want) {
+			t.Errorf("Expected the query to contain %q, got %s", want, q)
+		}
+	}
+	if strings.Count(q, "p.class_spec =") != 2 {
+		t.Errorf("Expected one condition per distinct spec, got %s", q)
+	}
+}
+
+func TestGetSpeedLeaderboard_BossTiming(t *testing.T) {
+	w, stmts := getSpeedLeaderboard(t, "scene_name=Dragon's%20Lair&timing=boss&party_size=4")
+	if w.Code == http.StatusBadRequest {
+		t.Fatalf("Expected the params to be accepted, got %s", w.Body.String())
+	}
+	counts := stmts.Matching("SELECT COUNT(*) FROM (")
+	if len(counts) != 1 {
+		t.Fatalf("Expected one count query, got %v", stmts.All())
+	}
+	q := counts[0]
+	if !strings.Contains(q, "COALESCE(NULLIF(e.boss_duration, 0), e.duration) AS kill_time") {
+		t.Errorf("Expected kills to be timed by boss-active time, got %s", q)
+	}
+	if !strings.Contains(q, "p.is_player = true) = 4") {
+		t.Errorf("Expected an exact party size, got %s", q)
+	}
+	if !strings.Contains(q, "DISTINCT ON (COALESCE(e.player_set_hash, e.id::text))") {
+		t.Errorf("Expected the fastest kill per party, got %s", q)
+	}
+}
+
+// Needs a Postgres server in TEST_DATABASE_URL; the query can't be checked without one.
+func TestGetSpeedLeaderboard_RanksFastestKillPerParty(t *testing.T) {
+	db, drop, err := dbpkg.TestDB()
+	if errors.Is(err, dbpkg.ErrNoTestDB) {
+		t.Skip(err)
+	}
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer drop()
+	if err := db.AutoMigrate(&models.User{}, &models.Encounter{}, &models.EncounterBoss{}, &models.EncounterPhase{},
+		&models.ActorEncounterStat{}, &models.Character{}); err != nil {
+		t.Fatal(err)
+	}
+
+	str := func(s string) *string { return &s }
+	sceneID := int64(1)
+	start := time.Date(2026, 10, 1, 12, 0, 0, 0, time.UTC)
+	seed := []interface{}{&models.User{ID: 1, DiscordUserID: "1"}}
+	for _, e := range []struct {
+		id       int64
+		duration float64
+		party    string
+		held     bool
+		killed   bool
+	}{
+		{1, 120, "A", false, true},
+		{2, 90, "A", false, true},  // the same party's faster kill
+		{3, 60, "B", true, true},   // held
+		{4, 50, "C", false, false}, // a wipe
+		{5, 110, "D", false, true},
+	} {
+		seed = append(seed,
+			&models.Encounter{ID: e.id, StartedAt: start, Duration: e.duration, SceneID: &sceneID, PlayerSetHash: str(e.party), Held: e.held, UserID: 1},
+			&models.EncounterBoss{EncounterID: e.id, MonsterName: "Tina", IsDefeated: e.killed})
+	}
+	seed = append(seed,
+		&models.ActorEncounterStat{EncounterID: 2, ActorID: 10, Name: str("Ann"), IsPlayer: true, DPS: 200},
+		&models.ActorEncounterStat{EncounterID: 2, ActorID: 20, Name: str("Bob"), IsPlayer: true, DPS: 100},
+		&models.Character{ActorID: 20, Name: str("Bob"), LastSeenAt: start, IsPrivate: true})
+	for _, row := range seed {
+		if err := db.Create(row).Error; err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	gin.SetMode(gin.TestMode)
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request = httptest.NewRequest(http.MethodGet, "/api/v1/leaderboard/speed?scene_id=1", nil)
+	c.Set("db", db)
+	GetSpeedLeaderboard(c)
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+	var resp GetSpeedLeaderboardResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+		t.Fatal(err)
+	}
+	if resp.Total != 2 || len(resp.Entries) != 2 {
+		t.Fatalf("Expected the kills of parties A and D, got %+v", resp)
+	}
+	first, second := resp.Entries[0], resp.Entries[1]
+	if first.Rank != 1 || first.EncounterID != 2 || first.KillTime != 90 || second.Rank != 2 || second.EncounterID != 5 {
+		t.Errorf("Unexpected ranking: %+v", resp.Entries)
+	}
+	party := first.Party
+	if first.PartySize != 2 || len(party) != 2 || party[0].Name == nil || *party[0].Name != "Ann" || party[1].Name != nil {
+		t.Errorf("Expected the private party member to be unnamed, got %+v", party)
+	}
+}
@@ -0,0 +1,138 @@
+package moderation
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+
+	apiErrors "server/controller"
+	"server/models"
+	"server/services/privacy"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+type ListClaimsResponse struct {
+	Claims []models.CharacterClaim `json:"claims"`
+	Total  int64                   `json:"total"`
+	Limit  int                     `json:"limit"`
+	Offset int                     `json:"offset"`
+}
+
+// GET /api/v1/admin/claims
+// Query params: status (pending | approved | rejected | all; default pending), limit (default 25, max 100), offset
+//
+// Lists character claims, oldest first.
+func ListClaims(c *gin.Context) {
+	dbAny, ok := c.Get("db")
+	if !ok {
+		c.JSON(http.StatusInternalServerError, apiErrors.NewErrorResponse(http.StatusInternalServerError, "Database not available in context"))
+		return
+	}
+	db := dbAny.(*gorm.DB)
+
+	status := strings.ToLower(c.DefaultQuery("status", models.ClaimPending))
+	switch status {
+	case models.ClaimPending, models.ClaimApproved, models.ClaimRejected, "all":
+	default:
+		c.JSON(http.StatusBadRequest, apiErrors.NewErrorResponse(http.StatusBadRequest, "Invalid status (expected pending, approved, rejected or all)"))
+		return
+	}
+	limit := 25
+	if v := c.Query("limit"); v != "" {
+		if n, err := strconv.Atoi(v); err == nil && n > 0 && n <= 100 {
+			limit = n
+		}
+	}
+	offset := 0
+	if v := c.Query("offset"); v != "" {
+		if n, err := strconv.Atoi(v); err == nil && n >= 0 {
+			offset = n
+		}
+	}
+
+	base := db.Model(&models.CharacterClaim{})
+	if status != "all" {
+		base = base.Where("status = ?", status)
+	}
+	var total int64
+	if err := base.Count(&total).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, apiErrors.NewErrorResponse(http.StatusInternalServerError, "Failed to count claims", err.Error()))
+		return
+	}
+	var claims []models.CharacterClaim
+	if err := base.Order("created_at ASC, id ASC").Limit(limit).Offset(offset).Find(&claims).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, apiErrors.NewErrorResponse(http.StatusInternalServerError, "Failed to fetch claims", err.Error()))
+		return
+	}
+	if claims == nil {
+		claims = []models.CharacterClaim{}
+	}
+
+	c.JSON(http.StatusOK, ListClaimsResponse{Claims: claims, Total: total, Limit: limit, Offset: offset})
+}
+
+// POST /api/v1/admin/claims/:id/approve
+// Links the character to the claimant.
+func ApproveClaim(c *gin.Context) {
+	resolveClaim(c, models.ClaimApproved)
+}
+
+// POST /api/v1/admin/claims/:id/reject
+// Unlinks the claimant if the claim had been approved.
+func RejectClaim(c *gin.Context) {
+	resolveClaim(c, models.ClaimRejected)
+}
+
+func resolveClaim(c *gin.Context, status string) {
+	dbAny, ok := c.Get("db")
+	if !ok {
+		c.JSON(http.StatusInternalServerError, apiErrors.NewErrorResponse(http.StatusInternalServerError, "Database not available in context"))
+		return
+	}
+	db := dbAny.(*gorm.DB)
+	user := c.MustGet("user").(*models.User)
+
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, apiErrors.NewErrorResponse(http.StatusBadRequest, "Invalid claim id"))
+		return
+	}
+	var req ResolveReviewRequest
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, apiErrors.NewErrorResponse(http.StatusBadRequest, "Invalid request body", err.Error()))
+			return
+		}
+	}
+	var note *string
+	if n := strings.TrimSpace(req.Note); n != "" {
+		note = &n
+	}
+
+	var claim models.CharacterClaim
+	if err := db.First(&claim, id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(http.StatusNotFound, apiErrors.NewErrorResponse(http.StatusNotFound, "Claim not found"))
+			return
+		}
+		c.JSON(http.StatusInternalServerError, apiErrors.NewErrorResponse(http.StatusInternalServerError, "Failed to fetch claim", err.Error()))
+		return
+	}
+
+	if err := privacy.ResolveClaim(db, &claim, status, user.ID, note); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(http.StatusNotFound, apiErrors.NewErrorResponse(http.StatusNotFound, "Player not found"))
+			return
+		}
+		c.JSON(http.StatusInternalServerError, apiErrors.NewErrorResponse(http.StatusInternalServerError, "Failed to resolve claim", err.Error()))
+		return
+	}
+	if err := db.First(&claim, id).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, apiErrors.NewErrorResponse(http.StatusInternalServerError, "Failed to fetch claim", err.Error()))
+		return
+	}
+	c.JSON(http.StatusOK, claim)
+}
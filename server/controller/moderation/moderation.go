@@ -0,0 +1,163 @@
+package moderation
+
+import (
+	"errors"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+
+	apiErrors "server/controller"
+	"server/models"
+	moderationService "server/services/moderation"
+	"server/services/parses"
+	"server/services/rollups"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+type ListReviewsResponse struct {
+	Reviews []models.EncounterReview `json:"reviews"`
+	Total   int64                    `json:"total"`
+	Limit   int                      `json:"limit"`
+	Offset  int                      `json:"offset"`
+}
+
+// ResolveReviewRequest is the optional body of the approve and reject endpoints.
+type ResolveReviewRequest struct {
+	Note string `json:"note"`
+}
+
+// GET /api/v1/admin/reviews
+// Query params: status (pending | approved | rejected | all; default pending), limit (default 25, max 100), offset
+//
+// Lists the moderation queue, oldest first, with each encounter's players so moderators
+// can judge the flags.
+func ListReviews(c *gin.Context) {
+	dbAny, ok := c.Get("db")
+	if !ok {
+		c.JSON(http.StatusInternalServerError, apiErrors.NewErrorResponse(http.StatusInternalServerError, "Database not available in context"))
+		return
+	}
+	db := dbAny.(*gorm.DB)
+
+	status := strings.ToLower(c.DefaultQuery("status", models.ReviewPending))
+	switch status {
+	case models.ReviewPending, models.ReviewApproved, models.ReviewRejected, "all":
+	default:
+		c.JSON(http.StatusBadRequest, apiErrors.NewErrorResponse(http.StatusBadRequest, "Invalid status (expected pending, approved, rejected or all)"))
+		return
+	}
+	limit := 25
+	if v := c.Query("limit"); v != "" {
+		if n, err := strconv.Atoi(v); err == nil && n > 0 && n <= 100 {
+			limit = n
+		}
+	}
+	offset := 0
+	if v := c.Query("offset"); v != "" {
+		if n, err := strconv.Atoi(v); err == nil && n >= 0 {
+			offset = n
+		}
+	}
+
+	base := db.Model(&models.EncounterReview{})
+	if status != "all" {
+		base = base.Where("status = ?", status)
+	}
+	var total int64
+	if err := base.Count(&total).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, apiErrors.NewErrorResponse(http.StatusInternalServerError, "Failed to count reviews", err.Error()))
+		return
+	}
+
+	var reviews []models.EncounterReview
+	if err := base.
+		Preload("Encounter").
+		Preload("Encounter.Players", func(db *gorm.DB) *gorm.DB {
+			return db.Where("actor_encounter_stats.is_player = ?", true)
+		}).
+		Order("created_at ASC, id ASC").
+		Limit(limit).Offset(offset).
+		Find(&reviews).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, apiErrors.NewErrorResponse(http.StatusInternalServerError, "Failed to fetch reviews", err.Error()))
+		return
+	}
+
+	c.JSON(http.StatusOK, ListReviewsResponse{Reviews: reviews, Total: total, Limit: limit, Offset: offset})
+}
+
+// POST /api/v1/admin/reviews/:id/approve
+// Returns the encounter to leaderboards.
+func ApproveReview(c *gin.Context) {
+	resolveReview(c, models.ReviewApproved)
+}
+
+// POST /api/v1/admin/reviews/:id/reject
+// Keeps the encounter off leaderboards for good.
+func RejectReview(c *gin.Context) {
+	resolveReview(c, models.ReviewRejected)
+}
+
+func resolveReview(c *gin.Context, status string) {
+	dbAny, ok := c.Get("db")
+	if !ok {
+		c.JSON(http.StatusInternalServerError, apiErrors.NewErrorResponse(http.StatusInternalServerError, "Database not available in context"))
+		return
+	}
+	db := dbAny.(*gorm.DB)
+	user := c.MustGet("user").(*models.User)
+
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, apiErrors.NewErrorResponse(http.StatusBadRequest, "Invalid review id"))
+		return
+	}
+	var req ResolveReviewRequest
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, apiErrors.NewErrorResponse(http.StatusBadRequest, "Invalid request body", err.Error()))
+			return
+		}
+	}
+	var note *string
+	if n := strings.TrimSpace(req.Note); n != "" {
+		note = &n
+	}
+
+	var review models.EncounterReview
+	if err := db.First(&review, id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(http.StatusNotFound, apiErrors.NewErrorResponse(http.StatusNotFound, "Review not found"))
+			return
+		}
+		c.JSON(http.StatusInternalServerError, apiErrors.NewErrorResponse(http.StatusInternalServerError, "Failed to fetch review", err.Error()))
+		return
+	}
+
+	if err := moderationService.NewModerationService(db).Resolve(&review, status, user.ID, note); err != nil {
+		c.JSON(http.StatusInternalServerError, apiErrors.NewErrorResponse(http.StatusInternalServerError, "Failed to resolve review", err.Error()))
+		return
+	}
+	refreshListing(db, review.EncounterID)
+	if err := db.First(&review, id).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, apiErrors.NewErrorResponse(http.StatusInternalServerError, "Failed to fetch review", err.Error()))
+		return
+	}
+	c.JSON(http.StatusOK, review)
+}
+
+// refreshListing updates what depends on whether an encounter is listed after a review is
+// resolved: its parses are ranked (or unranked) and the statistics rollups re-derived. The
+// periodic recompute and catch-up repair any failure here. It lives here rather than in the
+// moderation service, which the parses and rollups services import.
+func refreshListing(db *gorm.DB, encounterID int64) {
+	encounterIDs := []int64{encounterID}
+	if err := parses.NewParseService(db).RankEncounters(encounterIDs); err != nil {
+		log.Printf("moderation: %v", err)
+	}
+	if err := rollups.NewRollupService(db).RefreshEncounters(encounterIDs); err != nil {
+		log.Printf("moderation: %v", err)
+	}
+}
@@ -0,0 +1,110 @@
+package moderation
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	dbpkg "server/db"
+	"server/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+// dryRunContext returns a test context for method and target against a dry-run DB,
+// authenticated as an admin.
+func dryRunContext(t *testing.T, method, target string) (*gin.Context, *httptest.ResponseRecorder, *dbpkg.Statements) {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+	db, stmts, err := dbpkg.DryRun()
+	if err != nil {
+		t.Fatal(err)
+	}
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request = httptest.NewRequest(method, target, nil)
+	c.Set("db", db)
+	c.Set("user", &models.User{ID: 2, Role: "admin"})
+	return c, w, stmts
+}
+
+func TestListReviews_FiltersByStatus(t *testing.T) {
+	c, w, stmts := dryRunContext(t, http.MethodGet, "/api/v1/admin/reviews?status=rejected")
+	ListReviews(c)
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected 200, got %d", w.Code)
+	}
+	counts := stmts.Matching(`SELECT count(*) FROM "encounter_reviews"`)
+	if len(counts) == 0 || !strings.Contains(counts[0], "status = 'rejected'") {
+		t.Errorf("Expected the queue to be filtered by status, got %v", stmts.All())
+	}
+}
+
+func TestListReviews_RejectsUnknownStatus(t *testing.T) {
+	c, w, stmts := dryRunContext(t, http.MethodGet, "/api/v1/admin/reviews?status=held")
+	ListReviews(c)
+	if w.Code != http.StatusBadRequest {
+		t.Fatalf("Expected 400, got %d", w.Code)
+	}
+	if got := stmts.All(); len(got) != 0 {
+		t.Errorf("Expected no queries, got %v", got)
+	}
+}
+
+func TestApproveReview_RejectsInvalidID(t *testing.T) {
+	c, w, _ := dryRunContext(t, http.MethodPost, "/api/v1/admin/reviews/abc/approve")
+	c.Params = gin.Params{{Key: "id", Value: "abc"}}
+	ApproveReview(c)
+	if w.Code != http.StatusBadRequest {
+		t.Fatalf("Expected 400, got %d", w.Code)
+	}
+}
+
+func TestRefreshListing_RanksAndRefreshesRollups(t *testing.T) {
+	db, stmts, err := dbpkg.DryRun()
+	if err != nil {
+		t.Fatal(err)
+	}
+	refreshListing(db, 7)
+
+	ranks := stmts.Matching("cume_dist()")
+	if len(ranks) != 1 || !strings.Contains(ranks[0], "a.encounter_id IN (7)") {
+		t.Errorf("Expected the encounter's peer groups to be re-ranked, got %v", stmts.All())
+	}
+	if len(stmts.Matching("SET percentile = NULL")) != 1 {
+		t.Errorf("Expected a held encounter's percentiles to be cleared, got %v", stmts.All())
+	}
+	if len(stmts.Matching("DELETE FROM stat_rollup_encounters WHERE encounter_id IN (7)")) != 1 {
+		t.Errorf("Expected the encounter's rollups to be refreshed, got %v", stmts.All())
+	}
+	if len(stmts.Matching("INSERT INTO stat_actor_latest")) != 1 {
+		t.Errorf("Expected the latest rows of its players to be rebuilt, got %v", stmts.All())
+	}
+}
+
+func TestListClaims_FiltersByStatus(t *testing.T) {
+	c, w, stmts := dryRunContext(t, http.MethodGet, "/api/v1/admin/claims?status=approved")
+	ListClaims(c)
+	counts := stmts.Matching(`SELECT count(*) FROM "character_claims"`)
+	if len(counts) == 0 || !strings.Contains(counts[0], "status = 'approved'") {
+		t.Errorf("Expected the claims to be filtered by status, got %v (%d)", stmts.All(), w.Code)
+	}
+
+	c, w, stmts = dryRunContext(t, http.MethodGet, "/api/v1/admin/claims?status=open")
+	ListClaims(c)
+	if w.Code != http.StatusBadRequest || len(stmts.All()) != 0 {
+		t.Errorf("Expected an unknown status to be rejected without queries, got %d, %v", w.Code, stmts.All())
+	}
+}
+
+func TestResolveClaim_RejectsInvalidID(t *testing.T) {
+	for _, handler := range []gin.HandlerFunc{ApproveClaim, RejectClaim} {
+		c, w, stmts := dryRunContext(t, http.MethodPost, "/api/v1/admin/claims/abc/approve")
+		c.Params = gin.Params{{Key: "id", Value: "abc"}}
+		handler(c)
+		if w.Code != http.StatusBadRequest || len(stmts.All()) != 0 {
+			t.Errorf("Expected 400 without queries, got %d, %v", w.Code, stmts.All())
+		}
+	}
+}
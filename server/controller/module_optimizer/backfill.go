@@ -1,9 +1,9 @@
 package module_optimizer
 
 import (
-	"encoding/json"
 	"fmt"
 	"net/http"
+	"server/lib/charserialize"
 	"server/models"
 	"server/services/module_optimizer"
 
@@ -23,40 +23,6 @@ type BackfillModulesResponse struct {
 	Message string        `json:"message"`
 }
 
-// CharSerializeData represents the parsed CharSerialize JSON structure
-// This is a simplified version - expand as needed based on actual protobuf structure
-type CharSerializeData struct {
-	Mod         *ModData         `json:"Mod"`
-	ItemPackage *ItemPackageData `json:"ItemPackage"`
-}
-
-type ModData struct {
-	ModInfos map[string]*ModInfo `json:"ModInfos"`
-}
-
-type ModInfo struct {
-	InitLinkNums []int `json:"InitLinkNums"`
-}
-
-type ItemPackageData struct {
-	Packages map[string]*PackageData `json:"Packages"`
-}
-
-type PackageData struct {
-	Items map[string]*ItemData `json:"Items"`
-}
-
-type ItemData struct {
-	ConfigId   int             `json:"ConfigId"`
-	Uuid       string          `json:"Uuid"` // Changed to string to handle both numeric and string UUIDs in JSON
-	Quality    int             `json:"Quality"`
-	ModNewAttr *ModNewAttrData `json:"ModNewAttr"`
-}
-
-type ModNewAttrData struct {
-	ModParts []int `json:"ModParts"`
-}
-
 // BackfillModules extracts modules from stored CharSerializeJSON and imports them
 // This allows users to sync their modules from previously uploaded encounters
 func BackfillModules(c *gin.Context) {
@@ -127,8 +93,8 @@ func BackfillModules(c *gin.Context) {
 	}
 
 	// Parse CharSerializeJSON
-	var charSerialize CharSerializeData
-	if err := json.Unmarshal([]byte(playerData.CharSerializeJSON), &charSerialize); err != nil {
+	charSerialize, err := charserialize.DecodeString(playerData.CharSerializeJSON)
+	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{
 			"error": gin.H{
 				"code":    "parse_error",
@@ -139,7 +105,7 @@ func BackfillModules(c *gin.Context) {
 	}
 
 	// Extract modules from the parsed data
-	modules := extractModulesFromCharSerialize(&charSerialize)
+	modules := extractModulesFromCharSerialize(charSerialize)
 
 	if len(modules) == 0 {
 		c.JSON(http.StatusOK, BackfillModulesResponse{
@@ -179,80 +145,28 @@ func BackfillModules(c *gin.Context) {
 }
 
 // extractModulesFromCharSerialize parses CharSerialize and extracts module information
-func extractModulesFromCharSerialize(charSerialize *CharSerializeData) []ModuleImportPayload {
+func extractModulesFromCharSerialize(charSerialize *charserialize.Document) []ModuleImportPayload {
 	var modules []ModuleImportPayload
-
-	if charSerialize == nil || charSerialize.ItemPackage == nil || charSerialize.Mod == nil {
-		return modules
-	}
-
-	modInfos := charSerialize.Mod.ModInfos
-	if modInfos == nil {
-		return modules
-	}
-
-	// Iterate through all packages (inventories)
-	for _, pkg := range charSerialize.ItemPackage.Packages {
-		if pkg == nil || pkg.Items == nil {
-			continue
+	for _, m := range charSerialize.Modules() {
+		module := ModuleImportPayload{
+			UUID:     m.UUID,
+			ConfigID: m.ConfigID,
+			Quality:  m.Quality,
+			Name:     module_optimizer.GetModuleName(m.ConfigID),
+			Category: module_optimizer.GetModuleCategory(m.ConfigID),
+			Parts:    []PartImportPayload{},
 		}
-
-		// Iterate through items in this package
-		for key, item := range pkg.Items {
-			if item == nil || item.ModNewAttr == nil {
-				continue
-			}
-
-			// Check if this is a module (has ModParts)
-			if len(item.ModNewAttr.ModParts) == 0 {
-				continue
-			}
-
-			// Get the module info for this item
-			modInfo := modInfos[key]
-			if modInfo == nil {
-				continue
-			}
-
-			// Build module payload
-			module := ModuleImportPayload{
-				UUID:     item.Uuid, // Already a string
-				ConfigID: item.ConfigId,
-				Quality:  item.Quality,
-				Name:     module_optimizer.GetModuleName(item.ConfigId),
-				Category: module_optimizer.GetModuleCategory(item.ConfigId),
-				Parts:    []PartImportPayload{},
-			}
-
-			// Extract parts (attributes)
-			partCount := len(item.ModNewAttr.ModParts)
-			valueCount := len(modInfo.InitLinkNums)
-			maxParts := partCount
-			if valueCount < maxParts {
-				maxParts = valueCount
-			}
-
-			for i := 0; i < maxParts; i++ {
-				partID := item.ModNewAttr.ModParts[i]
-				value := modInfo.InitLinkNums[i]
-
-				part := PartImportPayload{
-					PartID: partID,
-					Name:   module_optimizer.GetAttributeName(partID),
-					Value:  value,
-					Type:   module_optimizer.GetAttributeType(module_optimizer.GetAttributeName(partID)),
-				}
-
-				module.Parts = append(module.Parts, part)
-			}
-
-			// Only add if we have at least one part
-			if len(module.Parts) > 0 {
-				modules = append(modules, module)
-			}
+		for _, p := range m.Parts {
+			name := module_optimizer.GetAttributeName(p.PartID)
+			module.Parts = append(module.Parts, PartImportPayload{
+				PartID: p.PartID,
+				Name:   name,
+				Value:  p.Value,
+				Type:   module_optimizer.GetAttributeType(name),
+			})
 		}
+		modules = append(modules, module)
 	}
-
 	return modules
 }
 
@@ -0,0 +1,254 @@
+package patch
+
+import (
+	"errors"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	apiErrors "server/controller"
+	"server/models"
+	"server/services/patches"
+	"server/services/rollups"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// PatchRequest is the body of the admin create and update endpoints. Omit endsAt for the
+// current patch.
+type PatchRequest struct {
+	Name     string     `json:"name"`
+	StartsAt time.Time  `json:"startsAt"`
+	EndsAt   *time.Time `json:"endsAt,omitempty"`
+}
+
+// PatchMutationResponse is returned by the admin endpoints.
+type PatchMutationResponse struct {
+	Patch    *models.Patch `json:"patch,omitempty"`
+	Retagged int64         `json:"retagged"` // encounters whose patch changed
+}
+
+var errPatchConflict = errors.New("a patch with this name or an overlapping window already exists")
+
+// validate normalises the request and checks the window.
+func (r *PatchRequest) validate() error {
+	r.Name = strings.TrimSpace(r.Name)
+	if r.Name == "" {
+		return errors.New("name is required")
+	}
+	if r.StartsAt.IsZero() {
+		return errors.New("startsAt is required")
+	}
+	if r.EndsAt != nil && !r.EndsAt.After(r.StartsAt) {
+		return errors.New("endsAt must be after startsAt")
+	}
+	return nil
+}
+
+// checkConflicts rejects a duplicate name (case-insensitive) or a window overlapping
+// another patch. id is the patch being updated, 0 on create.
+func checkConflicts(tx *gorm.DB, id int64, req PatchRequest) error {
+	var count int64
+	if err := tx.Model(&models.Patch{}).Where("id <> ? AND LOWER(name) = LOWER(?)", id, req.Name).Count(&count).Error; err != nil {
+		return err
+	}
+	if count > 0 {
+		return errPatchConflict
+	}
+
+	q := tx.Model(&models.Patch{}).Where("id <> ? AND (ends_at IS NULL OR ends_at > ?)", id, req.StartsAt)
+	if req.EndsAt != nil {
+		q = q.Where("starts_at < ?", *req.EndsAt)
+	}
+	if err := q.Count(&count).Error; err != nil {
+		return err
+	}
+	if count > 0 {
+		return errPatchConflict
+	}
+	return nil
+}
+
+// retag re-tags encounters after the patches table changed and rebuilds the statistics
+// rollups in the background, since they are split by patch.
+func retag(db *gorm.DB) (int64, error) {
+	n, err := patches.NewPatchService(db).RetagAll()
+	if err != nil {
+		return 0, err
+	}
+	if n > 0 {
+		go func() {
+			if err := rollups.NewRollupService(db).RebuildAll(); err != nil {
+				log.Printf("[Patches] %v", err)
+			}
+		}()
+	}
+	return n, nil
+}
+
+func getDB(c *gin.Context) (*gorm.DB, bool) {
+	dbAny, ok := c.Get("db")
+	if !ok {
+		c.JSON(http.StatusInternalServerError, apiErrors.NewErrorResponse(http.StatusInternalServerError, "Database not available in context"))
+		return nil, false
+	}
+	return dbAny.(*gorm.DB), true
+}
+
+// GET /api/v1/patches
+// Lists all patches, newest first. Their names are the values accepted by the patch filter
+// of the statistics, leaderboard and encounter search endpoints.
+func ListPatches(c *gin.Context) {
+	db, ok := getDB(c)
+	if !ok {
+		return
+	}
+
+	var rows []models.Patch
+	if err := db.Order("starts_at DESC").Find(&rows).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, apiErrors.NewErrorResponse(http.StatusInternalServerError, "Failed to load patches", err.Error()))
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"patches": rows})
+}
+
+// POST /api/v1/admin/patches
+// Creates a patch. When the new patch is open-ended (no endsAt), a still-open patch that
+// started earlier is closed at its start, so announcing a new patch is a single call. A
+// patch with its own end (e.g. a historical window) leaves open patches alone and is
+// rejected if it overlaps them.
+func CreatePatch(c *gin.Context) {
+	db, ok := getDB(c)
+	if !ok {
+		return
+	}
+
+	var req PatchRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, apiErrors.NewErrorResponse(http.StatusBadRequest, "Invalid request body", err.Error()))
+		return
+	}
+	if err := req.validate(); err != nil {
+		c.JSON(http.StatusBadRequest, apiErrors.NewErrorResponse(http.StatusBadRequest, err.Error()))
+		return
+	}
+
+	p := models.Patch{Name: req.Name, StartsAt: req.StartsAt, EndsAt: req.EndsAt}
+	err := db.Transaction(func(tx *gorm.DB) error {
+		if req.EndsAt == nil {
+			if err := tx.Model(&models.Patch{}).
+				Where("ends_at IS NULL AND starts_at < ?", req.StartsAt).
+				Update("ends_at", req.StartsAt).Error; err != nil {
+				return err
+			}
+		}
+		if err := checkConflicts(tx, 0, req); err != nil {
+			return err
+		}
+		return tx.Create(&p).Error
+	})
+	if errors.Is(err, errPatchConflict) {
+		c.JSON(http.StatusConflict, apiErrors.NewErrorResponse(http.StatusConflict, err.Error()))
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, apiErrors.NewErrorResponse(http.StatusInternalServerError, "Failed to create patch", err.Error()))
+		return
+	}
+
+	n, err := retag(db)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, apiErrors.NewErrorResponse(http.StatusInternalServerError, "Patch created but retagging encounters failed", err.Error()))
+		return
+	}
+	c.JSON(http.StatusCreated, PatchMutationResponse{Patch: &p, Retagged: n})
+}
+
+// PUT /api/v1/admin/patches/:id
+// Replaces a patch's name and window.
+func UpdatePatch(c *gin.Context) {
+	db, ok := getDB(c)
+	if !ok {
+		return
+	}
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, apiErrors.NewErrorResponse(http.StatusBadRequest, "Invalid patch id"))
+		return
+	}
+
+	var req PatchRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, apiErrors.NewErrorResponse(http.StatusBadRequest, "Invalid request body", err.Error()))
+		return
+	}
+	if err := req.validate(); err != nil {
+		c.JSON(http.StatusBadRequest, apiErrors.NewErrorResponse(http.StatusBadRequest, err.Error()))
+		return
+	}
+
+	var p models.Patch
+	err = db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.First(&p, id).Error; err != nil {
+			return err
+		}
+		if err := checkConflicts(tx, id, req); err != nil {
+			return err
+		}
+		p.Name, p.StartsAt, p.EndsAt = req.Name, req.StartsAt, req.EndsAt
+		return tx.Save(&p).Error
+	})
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		c.JSON(http.StatusNotFound, apiErrors.NewErrorResponse(http.StatusNotFound, "Patch not found"))
+		return
+	}
+	if errors.Is(err, errPatchConflict) {
+		c.JSON(http.StatusConflict, apiErrors.NewErrorResponse(http.StatusConflict, err.Error()))
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, apiErrors.NewErrorResponse(http.StatusInternalServerError, "Failed to update patch", err.Error()))
+		return
+	}
+
+	n, err := retag(db)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, apiErrors.NewErrorResponse(http.StatusInternalServerError, "Patch updated but retagging encounters failed", err.Error()))
+		return
+	}
+	c.JSON(http.StatusOK, PatchMutationResponse{Patch: &p, Retagged: n})
+}
+
+// DELETE /api/v1/admin/patches/:id
+// Deletes a patch; its encounters become untagged.
+func DeletePatch(c *gin.Context) {
+	db, ok := getDB(c)
+	if !ok {
+		return
+	}
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, apiErrors.NewErrorResponse(http.StatusBadRequest, "Invalid patch id"))
+		return
+	}
+
+	res := db.Delete(&models.Patch{}, id)
+	if res.Error != nil {
+		c.JSON(http.StatusInternalServerError, apiErrors.NewErrorResponse(http.StatusInternalServerError, "Failed to delete patch", res.Error.Error()))
+		return
+	}
+	if res.RowsAffected == 0 {
+		c.JSON(http.StatusNotFound, apiErrors.NewErrorResponse(http.StatusNotFound, "Patch not found"))
+		return
+	}
+
+	n, err := retag(db)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, apiErrors.NewErrorResponse(http.StatusInternalServerError, "Patch deleted but retagging encounters failed", err.Error()))
+		return
+	}
+	c.JSON(http.StatusOK, PatchMutationResponse{Retagged: n})
+}
@@ -0,0 +1,74 @@
+package patch
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	dbpkg "server/db"
+
+	"github.com/gin-gonic/gin"
+)
+
+// createPatch posts body to CreatePatch against a dry-run DB and returns the response code
+// and the statements it issued.
+func createPatch(t *testing.T, body string) (int, *dbpkg.Statements) {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+	db, stmts, err := dbpkg.DryRun()
+	if err != nil {
+		t.Fatal(err)
+	}
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request = httptest.NewRequest(http.MethodPost, "/api/v1/admin/patches", strings.NewReader(body))
+	c.Request.Header.Set("Content-Type", "application/json")
+	c.Set("db", db)
+	CreatePatch(c)
+	return w.Code, stmts
+}
+
+func TestCreatePatch_OpenEndedClosesOpenPatches(t *testing.T) {
+	code, stmts := createPatch(t, `{"name": "2.1", "startsAt": "2025-07-01T00:00:00Z"}`)
+	if code != http.StatusCreated {
+		t.Fatalf("Expected 201, got %d", code)
+	}
+	closes := stmts.Matching(`UPDATE "patches" SET "ends_at"`)
+	if len(closes) != 1 || !strings.Contains(closes[0], "ends_at IS NULL AND starts_at <") {
+		t.Errorf("Expected the open patch to be closed at the new start, got %v", stmts.All())
+	}
+	if len(stmts.Matching(`INSERT INTO "patches"`)) != 1 {
+		t.Errorf("Expected the patch to be created, got %v", stmts.All())
+	}
+}
+
+func TestCreatePatch_WithEndLeavesOpenPatches(t *testing.T) {
+	code, stmts := createPatch(t, `{"name": "1.5", "startsAt": "2025-01-01T00:00:00Z", "endsAt": "2025-02-01T00:00:00Z"}`)
+	if code != http.StatusCreated {
+		t.Fatalf("Expected 201, got %d", code)
+	}
+	if closes := stmts.Matching(`UPDATE "patches"`); len(closes) != 0 {
+		t.Errorf("Expected open patches to stay open for a bounded patch, got %v", closes)
+	}
+	// The overlap check still covers the open patch
+	if len(stmts.Matching("ends_at IS NULL OR ends_at >")) != 1 {
+		t.Errorf("Expected an overlap check, got %v", stmts.All())
+	}
+}
+
+func TestCreatePatch_RejectsInvalidWindow(t *testing.T) {
+	for _, body := range []string{
+		`{"name": "", "startsAt": "2025-01-01T00:00:00Z"}`,
+		`{"name": "1.5"}`,
+		`{"name": "1.5", "startsAt": "2025-02-01T00:00:00Z", "endsAt": "2025-01-01T00:00:00Z"}`,
+	} {
+		code, stmts := createPatch(t, body)
+		if code != http.StatusBadRequest {
+			t.Errorf("Expected 400 for %s, got %d", body, code)
+		}
+		if len(stmts.All()) != 0 {
+			t.Errorf("Expected no statements for %s, got %v", body, stmts.All())
+		}
+	}
+}
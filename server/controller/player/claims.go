@@ -0,0 +1,86 @@
+package player
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+
+	apiErrors "server/controller"
+	"server/models"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+type ClaimCharacterRequest struct {
+	// How the reviewer can verify the claim, e.g. a screenshot link showing the character
+	Evidence string `json:"evidence"`
+}
+
+// POST /api/v1/players/:actorId/claim
+// Requires authentication. Asks an admin to link the character to the caller's account,
+// which lets them change its privacy opt-out. Claiming again reopens a rejected claim.
+func ClaimCharacter(c *gin.Context) {
+	userVal, ok := c.Get("user")
+	if !ok {
+		c.JSON(http.StatusUnauthorized, apiErrors.NewErrorResponse(http.StatusUnauthorized, "Not authenticated"))
+		return
+	}
+	user := userVal.(*models.User)
+
+	dbAny, ok := c.Get("db")
+	if !ok {
+		c.JSON(http.StatusInternalServerError, apiErrors.NewErrorResponse(http.StatusInternalServerError, "Database not available in context"))
+		return
+	}
+	db := dbAny.(*gorm.DB)
+
+	actorID, err := strconv.ParseInt(c.Param("actorId"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, apiErrors.NewErrorResponse(http.StatusBadRequest, "Invalid actorId", err.Error()))
+		return
+	}
+	var req ClaimCharacterRequest
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, apiErrors.NewErrorResponse(http.StatusBadRequest, "Invalid request payload", err.Error()))
+			return
+		}
+	}
+	var evidence *string
+	if e := strings.TrimSpace(req.Evidence); e != "" {
+		evidence = &e
+	}
+
+	var char models.Character
+	if err := db.Select("actor_id").Where("actor_id = ?", actorID).First(&char).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(http.StatusNotFound, apiErrors.NewErrorResponse(http.StatusNotFound, "Player not found"))
+			return
+		}
+		c.JSON(http.StatusInternalServerError, apiErrors.NewErrorResponse(http.StatusInternalServerError, "Failed to load character", err.Error()))
+		return
+	}
+
+	var claim models.CharacterClaim
+	err = db.Where("actor_id = ? AND user_id = ?", actorID, user.ID).First(&claim).Error
+	switch {
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		claim = models.CharacterClaim{ActorID: actorID, UserID: user.ID, Status: models.ClaimPending, Evidence: evidence}
+		err = db.Create(&claim).Error
+	case err == nil && claim.Status == models.ClaimRejected:
+		err = db.Model(&claim).Updates(map[string]interface{}{
+			"status":         models.ClaimPending,
+			"evidence":       evidence,
+			"note":           nil,
+			"reviewed_by_id": nil,
+			"reviewed_at":    nil,
+		}).Error
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, apiErrors.NewErrorResponse(http.StatusInternalServerError, "Failed to save claim", err.Error()))
+		return
+	}
+	c.JSON(http.StatusOK, claim)
+}
@@ -0,0 +1,120 @@
+package player
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	apiErrors "server/controller"
+	"server/services/gamedata"
+	"server/services/parses"
+	"server/services/scenes"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// ParseRow is a single ranked performance in a character's parse history.
+type ParseRow struct {
+	EncounterID    int64     `gorm:"column:encounter_id" json:"encounterId"`
+	SceneID        *int64    `gorm:"column:scene_id" json:"sceneId,omitempty"`
+	SceneName      *string   `gorm:"column:scene_name" json:"sceneName,omitempty"`
+	StartedAt      time.Time `gorm:"column:started_at" json:"startedAt"`
+	Duration       float64   `gorm:"column:duration" json:"duration"`
+	Name           *string   `gorm:"column:name" json:"name,omitempty"`
+	ClassID        *int64    `gorm:"column:class_id" json:"classId,omitempty"`
+	ClassSpec      *int64    `gorm:"column:class_spec" json:"classSpec,omitempty"`
+	AbilityScore   *int64    `gorm:"column:ability_score" json:"abilityScore,omitempty"`
+	AbilityBracket int64     `gorm:"column:ability_bracket" json:"abilityBracket"`
+	DPS            float64   `gorm:"column:dps" json:"dps"`
+	Percentile     *float64  `gorm:"column:percentile" json:"percentile,omitempty"`
+}
+
+type GetPlayerParsesResponse struct {
+	ActorID int64      `json:"actorId"`
+	Parses  []ParseRow `json:"parses"`
+	Count   int64      `json:"count"`
+}
+
+// GET /api/v1/players/:actorId/parses
+// Query params: scene_name (optional), class_spec (optional), limit (default 50, max 200), offset
+// Private characters return 404 unless requested by their owner.
+func GetPlayerParses(c *gin.Context) {
+	dbAny, ok := c.Get("db")
+	if !ok {
+		c.JSON(http.StatusInternalServerError, apiErrors.NewErrorResponse(http.StatusInternalServerError, "Database not available in context"))
+		return
+	}
+	db := dbAny.(*gorm.DB)
+
+	actorID, err := strconv.ParseInt(c.Param("actorId"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, apiErrors.NewErrorResponse(http.StatusBadRequest, "Invalid actorId", err.Error()))
+		return
+	}
+
+	visible, err := characterVisible(c, db, actorID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, apiErrors.NewErrorResponse(http.StatusInternalServerError, "Failed to load character", err.Error()))
+		return
+	}
+	if !visible {
+		c.JSON(http.StatusNotFound, apiErrors.NewErrorResponse(http.StatusNotFound, "Player not found"))
+		return
+	}
+
+	limit := 50
+	if v := c.Query("limit"); v != "" {
+		if n, err := strconv.Atoi(v); err == nil && n > 0 && n <= 200 {
+			limit = n
+		}
+	}
+	offset := 0
+	if v := c.Query("offset"); v != "" {
+		if n, err := strconv.Atoi(v); err == nil && n >= 0 {
+			offset = n
+		}
+	}
+
+	q := db.Table("actor_encounter_stats AS a").
+		Joins("JOIN encounters e ON e.id = a.encounter_id").
+		Where("a.actor_id = ? AND a.is_player = ?", actorID, true)
+	if v := strings.TrimSpace(c.Query("scene_name")); v != "" {
+		sceneSQL, sceneArgs := scenes.NameFilter("e", v)
+		q = q.Where(sceneSQL, sceneArgs...)
+	}
+	if v := strings.TrimSpace(c.Query("class_spec")); v != "" {
+		n, err := strconv.ParseInt(v, 10, 64)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, apiErrors.NewErrorResponse(http.StatusBadRequest, "Invalid class_spec"))
+			return
+		}
+		q = q.Where("a.class_spec = ?", n)
+	}
+
+	var total int64
+	if err := q.Count(&total).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, apiErrors.NewErrorResponse(http.StatusInternalServerError, "Failed to count parses", err.Error()))
+		return
+	}
+
+	var rows []ParseRow
+	if err := q.Select("a.encounter_id, e.scene_id, e.scene_name, e.started_at, e.duration, a.name, a.class_id, a.class_spec, a.ability_score, a.dps, a.percentile, "+
+		"(FLOOR(COALESCE(a.ability_score, 0)::double precision / ?) * ?)::bigint AS ability_bracket", parses.AbilityBracketSize, parses.AbilityBracketSize).
+		Order("e.started_at DESC").
+		Limit(limit).Offset(offset).
+		Scan(&rows).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, apiErrors.NewErrorResponse(http.StatusInternalServerError, "Failed to query parses", err.Error()))
+		return
+	}
+	if rows == nil {
+		rows = []ParseRow{}
+	}
+	locale := c.GetString("locale")
+	for i := range rows {
+		rows[i].SceneName = gamedata.Get().LocalizeScene(rows[i].SceneID, rows[i].SceneName, locale)
+	}
+
+	c.JSON(http.StatusOK, GetPlayerParsesResponse{ActorID: actorID, Parses: rows, Count: total})
+}
@@ -8,7 +8,12 @@ import (
 	"time"
 
 	apiErrors "server/controller"
+	"server/lib"
+	"server/lib/charserialize"
 	"server/models"
+	"server/services/moderation"
+	"server/services/privacy"
+	"server/services/scenes"
 
 	"github.com/gin-gonic/gin"
 	"gorm.io/gorm"
@@ -19,6 +24,10 @@ type PlayerTopRow struct {
 	HPS       *float64   `json:"hps,omitempty"`
 	SceneName *string    `json:"sceneName,omitempty"`
 	StartedAt *time.Time `json:"startedAt,omitempty"`
+
+	// Set when ranking a segment (kill attempt or boss phases) instead of the whole encounter
+	SegmentDPS      *float64 `json:"segmentDps,omitempty"`
+	SegmentDuration *float64 `json:"segmentDuration,omitempty"`
 }
 
 type GetTop10PlayersResponse struct {
@@ -26,7 +35,9 @@ type GetTop10PlayersResponse struct {
 }
 
 // GET /api/v1/player/top10
-// Query params: scene_name (required), class_id (optional), class_spec (optional)
+// Query params: scene_name or scene_id (one is required), class_id (optional), class_spec (optional),
+// segment (optional: all | kill | boss — rank the kill attempt or boss phases only).
+// Characters who opted out of public listings are left out.
 func GetTop10Players(c *gin.Context) {
 	dbAny, ok := c.Get("db")
 	if !ok {
@@ -36,8 +47,17 @@ func GetTop10Players(c *gin.Context) {
 	db := dbAny.(*gorm.DB)
 
 	sceneName := strings.TrimSpace(c.Query("scene_name"))
-	if sceneName == "" {
-		c.JSON(http.StatusBadRequest, apiErrors.NewErrorResponse(http.StatusBadRequest, "Missing required query param: scene_name"))
+	var sceneID *int64
+	if v := strings.TrimSpace(c.Query("scene_id")); v != "" {
+		n, err := strconv.ParseInt(v, 10, 64)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, apiErrors.NewErrorResponse(http.StatusBadRequest, "Invalid scene_id"))
+			return
+		}
+		sceneID = &n
+	}
+	if sceneName == "" && sceneID == nil {
+		c.JSON(http.StatusBadRequest, apiErrors.NewErrorResponse(http.StatusBadRequest, "Missing required query param: scene_name or scene_id"))
 		return
 	}
 
@@ -61,13 +81,44 @@ func GetTop10Players(c *gin.Context) {
 		}
 	}
 
+	segment, ok := lib.ParseSegment(c.Query("segment"))
+	if !ok {
+		c.JSON(http.StatusBadRequest, apiErrors.NewErrorResponse(http.StatusBadRequest, "Invalid segment (expected all, kill or boss)"))
+		return
+	}
+
+	// Metric expressions: whole encounter by default, or the joined segment row
+	dpsExpr := "actor_encounter_stats.dps"
+	hpsExpr := lib.ActorRateSQL("actor_encounter_stats.heal_dealt", "actor_encounter_stats", "encounters")
+	// Boss DPS uses boss-active time so slow trash clears don't lower it
+	bossDpsExpr := "(CASE WHEN " + lib.BossDurationSQL("encounters") + " > 0 THEN CAST(actor_encounter_stats.boss_damage_dealt AS double precision) / " + lib.BossDurationSQL("encounters") + " ELSE 0 END)"
+	if segment != lib.SegmentAll {
+		dpsExpr = "seg.dps"
+		hpsExpr = "(CASE WHEN seg.duration > 0 THEN CAST(seg.heal_dealt AS double precision) / seg.duration ELSE 0 END)"
+		bossDpsExpr = "(CASE WHEN seg.duration > 0 THEN CAST(seg.boss_damage_dealt AS double precision) / seg.duration ELSE 0 END)"
+	}
+
 	// Build base query joining encounters. Use Model so GORM knows the destination
 	// model and can map selected columns into the embedded struct fields.
 	q := db.Model(&models.ActorEncounterStat{}).
 		Joins("JOIN encounters ON encounters.id = actor_encounter_stats.encounter_id").
 		Where("actor_encounter_stats.is_player = ?", true).
-		Where("LOWER(encounters.scene_name) = LOWER(?)", sceneName).
-		Where("actor_encounter_stats.name IS NOT NULL AND actor_encounter_stats.name <> ''")
+		Where("actor_encounter_stats.name IS NOT NULL AND actor_encounter_stats.name <> ''").
+		Where(moderation.ListedSQL("encounters")).
+		Where(privacy.PublicSQL("actor_encounter_stats.actor_id"))
+	if sceneName != "" {
+		sceneSQL, sceneArgs := scenes.NameFilter("encounters", sceneName)
+		q = q.Where(sceneSQL, sceneArgs...)
+	}
+	if sceneID != nil {
+		q = q.Where("encounters.scene_id = ?", *sceneID)
+	}
+
+	if segment != lib.SegmentAll {
+		// Correlated per player row, so only the candidates' segment rows are read
+		segSQL := lib.SegmentActorStatsSQL(segment, "encounter_id = actor_encounter_stats.encounter_id AND actor_id = actor_encounter_stats.actor_id")
+		q = q.Joins("JOIN LATERAL (" + segSQL + ") seg ON true")
+	}
 
 	if classID != nil {
 		q = q.Where("actor_encounter_stats.class_id = ?", *classID)
@@ -133,8 +184,7 @@ func GetTop10Players(c *gin.Context) {
 		if f, err := strconv.ParseFloat(v, 64); err == nil {
 			hpsVal = f
 			hasHPSFilter = true
-			// compute hps as heal_dealt / encounters.duration (guard against zero duration)
-			hpsExpr := "(CASE WHEN encounters.duration > 0 THEN CAST(actor_encounter_stats.heal_dealt AS double precision) / encounters.duration ELSE 0 END)"
+			// hps is heal_dealt over the actor's active time, like the leaderboard's
 			q = q.Where(hpsExpr+" >= ?", hpsVal)
 		} else {
 			c.JSON(http.StatusBadRequest, apiErrors.NewErrorResponse(http.StatusBadRequest, "Invalid hps value"))
@@ -146,17 +196,14 @@ func GetTop10Players(c *gin.Context) {
 	// If not provided, fall back to ordering by HPS when an HPS filter is present, otherwise by stored DPS.
 	orderByParam := strings.ToLower(strings.TrimSpace(c.Query("orderBy")))
 
-	hpsExpr := "(CASE WHEN encounters.duration > 0 THEN CAST(actor_encounter_stats.heal_dealt AS double precision) / encounters.duration ELSE 0 END)"
-	bossDpsExpr := "(CASE WHEN encounters.duration > 0 THEN CAST(actor_encounter_stats.boss_damage_dealt AS double precision) / encounters.duration ELSE 0 END)"
-
-	orderExpr := "actor_encounter_stats.dps DESC"
+	orderExpr := dpsExpr + " DESC"
 	switch orderByParam {
 	case "hps":
 		orderExpr = hpsExpr + " DESC"
 	case "bossdps", "boss_dps", "boss-dps":
 		orderExpr = bossDpsExpr + " DESC"
 	case "dps":
-		orderExpr = "actor_encounter_stats.dps DESC"
+		orderExpr = dpsExpr + " DESC"
 	case "":
 		if hasHPSFilter {
 			orderExpr = hpsExpr + " DESC"
@@ -170,7 +217,11 @@ func GetTop10Players(c *gin.Context) {
 
 	// Select all actor columns plus encounter scene/start and computed HPS.
 	// Alias encounter columns explicitly so mapping to `PlayerTopRow` fields is deterministic.
-	q = q.Select("actor_encounter_stats.*, encounters.scene_name AS scene_name, encounters.started_at AS started_at, (CASE WHEN encounters.duration > 0 THEN CAST(actor_encounter_stats.heal_dealt AS double precision) / encounters.duration ELSE 0 END) AS hps")
+	selectExpr := "actor_encounter_stats.*, encounters.scene_name AS scene_name, encounters.started_at AS started_at, " + hpsExpr + " AS hps"
+	if segment != lib.SegmentAll {
+		selectExpr += ", seg.dps AS segment_dps, seg.duration AS segment_duration"
+	}
+	q = q.Select(selectExpr)
 
 	var rows []PlayerTopRow
 	if err := q.Order(orderExpr).Limit(10).Find(&rows).Error; err != nil {
@@ -183,29 +234,64 @@ func GetTop10Players(c *gin.Context) {
 
 // CharBaseData represents the essential character information
 type CharBaseData struct {
-	Name            string      `json:"name,omitempty"`
-	CreateTime      string      `json:"createTime,omitempty"`
-	CharId          string      `json:"charId,omitempty"`
-	TotalOnlineTime string      `json:"totalOnlineTime,omitempty"`
-	LastOfflineTime string      `json:"lastOfflineTime,omitempty"`
-	AvatarInfo      interface{} `json:"avatarInfo,omitempty"`
+	Name            string                    `json:"name,omitempty"`
+	CreateTime      string                    `json:"createTime,omitempty"`
+	CharId          string                    `json:"charId,omitempty"`
+	TotalOnlineTime string                    `json:"totalOnlineTime,omitempty"`
+	LastOfflineTime string                    `json:"lastOfflineTime,omitempty"`
+	AvatarInfo      *charserialize.AvatarInfo `json:"avatarInfo,omitempty"`
+}
+
+// newCharBaseData converts the snapshot's CharBase section to its response form.
+func newCharBaseData(b *charserialize.CharBase) *CharBaseData {
+	if b == nil {
+		return nil
+	}
+	formatInt := func(n charserialize.Int64) string {
+		if n == 0 {
+			return ""
+		}
+		return strconv.FormatInt(int64(n), 10)
+	}
+	return &CharBaseData{
+		Name:            b.Name,
+		CreateTime:      formatInt(b.CreateTime),
+		CharId:          string(b.CharId),
+		TotalOnlineTime: formatInt(b.TotalOnlineTime),
+		LastOfflineTime: formatInt(b.LastOfflineTime),
+		AvatarInfo:      b.AvatarInfo,
+	}
+}
+
+// typedOrRaw returns a typed section, or the section as sent when it is present but didn't
+// decode, so snapshots the typed models don't cover still return it. nil when absent.
+func typedOrRaw[T any](doc *charserialize.Document, typed *T, name string) interface{} {
+	if typed != nil {
+		return typed
+	}
+	if raw := doc.Raw(name); raw != nil {
+		return raw
+	}
+	return nil
 }
 
-// DetailedPlayerDataResponse represents player data with flattened charSerialize fields
+// DetailedPlayerDataResponse represents player data with flattened charSerialize fields.
+// Sections are returned as they were uploaded, including typed sections the decoder could
+// not read.
 type DetailedPlayerDataResponse struct {
-	PlayerID              int64         `json:"playerId"`
-	LastSeenMs            int64         `json:"lastSeenMs"`
-	CharBase              *CharBaseData `json:"charBase,omitempty"`
-	CharStatisticsData    interface{}   `json:"charStatisticsData,omitempty"`
-	DungeonList           interface{}   `json:"dungeonList,omitempty"`
-	Equip                 interface{}   `json:"equip,omitempty"`
-	FightPoint            interface{}   `json:"fightPoint,omitempty"`
-	GashaData             interface{}   `json:"gashaData,omitempty"`
-	ItemCurrency          interface{}   `json:"itemCurrency,omitempty"`
-	LifeProfession        interface{}   `json:"lifeProfession,omitempty"`
-	MasterModeDungeonInfo interface{}   `json:"masterModeDungeonInfo,omitempty"`
-	ProfessionList        interface{}   `json:"professionList,omitempty"`
-	NewbieData            interface{}   `json:"newbieData,omitempty"`
+	PlayerID              int64           `json:"playerId"`
+	LastSeenMs            int64           `json:"lastSeenMs"`
+	CharBase              *CharBaseData   `json:"charBase,omitempty"`
+	CharStatisticsData    json.RawMessage `json:"charStatisticsData,omitempty"`
+	DungeonList           interface{}     `json:"dungeonList,omitempty"`
+	Equip                 interface{}     `json:"equip,omitempty"`
+	FightPoint            json.RawMessage `json:"fightPoint,omitempty"`
+	GashaData             json.RawMessage `json:"gashaData,omitempty"`
+	ItemCurrency          json.RawMessage `json:"itemCurrency,omitempty"`
+	LifeProfession        json.RawMessage `json:"lifeProfession,omitempty"`
+	MasterModeDungeonInfo interface{}     `json:"masterModeDungeonInfo,omitempty"`
+	ProfessionList        interface{}     `json:"professionList,omitempty"`
+	NewbieData            json.RawMessage `json:"newbieData,omitempty"`
 }
 
 // GET /api/v1/player/detailed-playerdata/:id
@@ -261,68 +347,20 @@ func GetDetailedPlayerData(c *gin.Context) {
 			LastSeenMs: pd.LastSeenMs,
 		}
 
-		// Parse CharSerializeJSON and extract specific fields
+		// Decode CharSerializeJSON and extract specific fields
 		if pd.CharSerializeJSON != "" {
-			var charData map[string]interface{}
-			if err := json.Unmarshal([]byte(pd.CharSerializeJSON), &charData); err == nil {
-				// Extract and parse CharBase with specific fields (PascalCase in source data)
-				if charBaseRaw, ok := charData["CharBase"]; ok {
-					if charBaseMap, ok := charBaseRaw.(map[string]interface{}); ok {
-						charBase := &CharBaseData{}
-
-						if name, ok := charBaseMap["Name"].(string); ok {
-							charBase.Name = name
-						}
-						if createTime, ok := charBaseMap["CreateTime"].(string); ok {
-							charBase.CreateTime = createTime
-						}
-						if charId, ok := charBaseMap["CharId"].(string); ok {
-							charBase.CharId = charId
-						}
-						if totalOnlineTime, ok := charBaseMap["TotalOnlineTime"].(string); ok {
-							charBase.TotalOnlineTime = totalOnlineTime
-						}
-						if lastOfflineTime, ok := charBaseMap["LastOfflineTime"].(string); ok {
-							charBase.LastOfflineTime = lastOfflineTime
-						}
-						if avatarInfo, ok := charBaseMap["AvatarInfo"]; ok {
-							charBase.AvatarInfo = avatarInfo
-						}
-
-						responseItem.CharBase = charBase
-					}
-				}
-
-				if val, ok := charData["CharStatisticsData"]; ok {
-					responseItem.CharStatisticsData = val
-				}
-				if val, ok := charData["DungeonList"]; ok {
-					responseItem.DungeonList = val
-				}
-				if val, ok := charData["Equip"]; ok {
-					responseItem.Equip = val
-				}
-				if val, ok := charData["FightPoint"]; ok {
-					responseItem.FightPoint = val
-				}
-				if val, ok := charData["GashaData"]; ok {
-					responseItem.GashaData = val
-				}
-				if val, ok := charData["ItemCurrency"]; ok {
-					responseItem.ItemCurrency = val
-				}
-				if val, ok := charData["LifeProfession"]; ok {
-					responseItem.LifeProfession = val
-				}
-				if val, ok := charData["MasterModeDungeonInfo"]; ok {
-					responseItem.MasterModeDungeonInfo = val
-				}
-				if val, ok := charData["ProfessionList"]; ok {
-					responseItem.ProfessionList = val
-				}
-				if val, ok := charData["NewbieData"]; ok {
-					responseItem.NewbieData = val
-				}
+			if doc, err := charserialize.DecodeString(pd.CharSerializeJSON); err == nil {
+				responseItem.CharBase = newCharBaseData(doc.CharBase)
+				responseItem.DungeonList = typedOrRaw(doc, doc.DungeonList, "DungeonList")
+				responseItem.Equip = typedOrRaw(doc, doc.Equip, "Equip")
+				responseItem.MasterModeDungeonInfo = typedOrRaw(doc, doc.MasterModeDungeonInfo, "MasterModeDungeonInfo")
+				responseItem.ProfessionList = typedOrRaw(doc, doc.ProfessionList, "ProfessionList")
+				responseItem.CharStatisticsData = doc.Raw("CharStatisticsData")
+				responseItem.FightPoint = doc.Raw("FightPoint")
+				responseItem.GashaData = doc.Raw("GashaData")
+				responseItem.ItemCurrency = doc.Raw("ItemCurrency")
+				responseItem.LifeProfession = doc.Raw("LifeProfession")
+				responseItem.NewbieData = doc.Raw("NewbieData")
 			}
 		}
 
@@ -0,0 +1,29 @@
+package player
+
+import (
+	"encoding/json"
+	"testing"
+
+	"server/lib/charserialize"
+)
+
+func TestTypedOrRaw_KeepsSectionsThatDontDecode(t *testing.T) {
+	doc, err := charserialize.DecodeString(`{"Equip": "not an object", "ProfessionList": {"CurProfessionId": 4}}`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if doc.Equip != nil || len(doc.Warnings) == 0 {
+		t.Fatalf("Expected Equip not to decode, got %+v", doc.Equip)
+	}
+
+	equip, err := json.Marshal(typedOrRaw(doc, doc.Equip, "Equip"))
+	if err != nil || string(equip) != `"not an object"` {
+		t.Errorf("Expected the Equip section as sent, got %s (%v)", equip, err)
+	}
+	if _, ok := typedOrRaw(doc, doc.ProfessionList, "ProfessionList").(*charserialize.ProfessionList); !ok {
+		t.Error("Expected the typed ProfessionList")
+	}
+	if got := typedOrRaw(doc, doc.DungeonList, "DungeonList"); got != nil {
+		t.Errorf("Expected no DungeonList, got %v", got)
+	}
+}
@@ -0,0 +1,286 @@
+package player
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	apiErrors "server/controller"
+	"server/lib"
+	"server/models"
+	"server/services/gamedata"
+	"server/services/scenes"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// ownsCharacter reports whether the user is the character's verified owner. Uploads don't
+// count: the uploader and local player of a log are whatever the client claims, so owners
+// are only linked through an approved CharacterClaim.
+func ownsCharacter(db *gorm.DB, userID uint, actorID int64) (bool, error) {
+	var n int64
+	if err := db.Model(&models.Character{}).
+		Where("actor_id = ? AND owner_user_id = ?", actorID, userID).
+		Count(&n).Error; err != nil {
+		return false, err
+	}
+	return n > 0, nil
+}
+
+// characterVisible reports whether the requester may see the character's profile.
+// Private characters are only visible to their owner.
+func characterVisible(c *gin.Context, db *gorm.DB, actorID int64) (bool, error) {
+	var char models.Character
+	err := db.Select("actor_id", "is_private").Where("actor_id = ?", actorID).First(&char).Error
+	if err == gorm.ErrRecordNotFound || (err == nil && !char.IsPrivate) {
+		return true, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	userAny, ok := c.Get("user")
+	if !ok {
+		return false, nil
+	}
+	user, ok := userAny.(*models.User)
+	if !ok {
+		return false, nil
+	}
+	return ownsCharacter(db, user.ID, actorID)
+}
+
+// NameHistoryEntry is a name a character has appeared under.
+type NameHistoryEntry struct {
+	Name       *string   `gorm:"column:name" json:"name,omitempty"`
+	FirstSeen  time.Time `gorm:"column:first_seen" json:"firstSeen"`
+	LastSeen   time.Time `gorm:"column:last_seen" json:"lastSeen"`
+	Encounters int64     `gorm:"column:encounters" json:"encounters"`
+}
+
+// ClassHistoryEntry is a class/spec combination a character has played.
+type ClassHistoryEntry struct {
+	ClassID    *int64    `gorm:"column:class_id" json:"classId,omitempty"`
+	ClassSpec  *int64    `gorm:"column:class_spec" json:"classSpec,omitempty"`
+	FirstSeen  time.Time `gorm:"column:first_seen" json:"firstSeen"`
+	LastSeen   time.Time `gorm:"column:last_seen" json:"lastSeen"`
+	Encounters int64     `gorm:"column:encounters" json:"encounters"`
+}
+
+// AbilityScorePoint is the highest ability score seen on a given day.
+type AbilityScorePoint struct {
+	Day          time.Time `gorm:"column:day" json:"day"`
+	AbilityScore int64     `gorm:"column:ability_score" json:"abilityScore"`
+}
+
+// ProfileEncounter is one of the character's performances.
+type ProfileEncounter struct {
+	EncounterID int64     `gorm:"column:encounter_id" json:"encounterId"`
+	SceneID     *int64    `gorm:"column:scene_id" json:"sceneId,omitempty"`
+	SceneName   *string   `gorm:"column:scene_name" json:"sceneName,omitempty"`
+	StartedAt   time.Time `gorm:"column:started_at" json:"startedAt"`
+	Duration    float64   `gorm:"column:duration" json:"duration"`
+	ClassSpec   *int64    `gorm:"column:class_spec" json:"classSpec,omitempty"`
+	DPS         float64   `gorm:"column:dps" json:"dps"`
+	HPS         float64   `gorm:"column:hps" json:"hps"`
+	Percentile  *float64  `gorm:"column:percentile" json:"percentile,omitempty"`
+}
+
+type ProfileTotals struct {
+	Encounters  int64      `gorm:"column:encounters" json:"encounters"`
+	DamageDealt int64      `gorm:"column:damage_dealt" json:"damageDealt"`
+	HealDealt   int64      `gorm:"column:heal_dealt" json:"healDealt"`
+	DamageTaken int64      `gorm:"column:damage_taken" json:"damageTaken"`
+	Duration    float64    `gorm:"column:duration" json:"duration"`
+	Deaths      int64      `gorm:"column:deaths" json:"deaths"`
+	FirstSeen   *time.Time `gorm:"column:first_seen" json:"firstSeen,omitempty"`
+	LastSeen    *time.Time `gorm:"column:last_seen" json:"lastSeen,omitempty"`
+}
+
+type GetPlayerProfileResponse struct {
+	Character         models.Character    `json:"character"`
+	NameHistory       []NameHistoryEntry  `json:"nameHistory"`
+	ClassHistory      []ClassHistoryEntry `json:"classHistory"`
+	AbilityScores     []AbilityScorePoint `json:"abilityScores"`
+	RecentEncounters  []ProfileEncounter  `json:"recentEncounters"`
+	BestParsesByScene []ProfileEncounter  `json:"bestParsesByScene"`
+	Totals            ProfileTotals       `json:"totals"`
+}
+
+// GET /api/v1/players/:actorId
+// Returns a character's name and class history, ability-score progression, recent
+// encounters, best parse per scene and lifetime totals. Private characters return
+// 404 unless requested by their owner.
+func GetPlayerProfile(c *gin.Context) {
+	dbAny, ok := c.Get("db")
+	if !ok {
+		c.JSON(http.StatusInternalServerError, apiErrors.NewErrorResponse(http.StatusInternalServerError, "Database not available in context"))
+		return
+	}
+	db := dbAny.(*gorm.DB)
+
+	actorID, err := strconv.ParseInt(c.Param("actorId"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, apiErrors.NewErrorResponse(http.StatusBadRequest, "Invalid actorId", err.Error()))
+		return
+	}
+
+	visible, err := characterVisible(c, db, actorID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, apiErrors.NewErrorResponse(http.StatusInternalServerError, "Failed to load character", err.Error()))
+		return
+	}
+	if !visible {
+		c.JSON(http.StatusNotFound, apiErrors.NewErrorResponse(http.StatusNotFound, "Player not found"))
+		return
+	}
+
+	var resp GetPlayerProfileResponse
+	if err := db.Where("actor_id = ?", actorID).First(&resp.Character).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			c.JSON(http.StatusNotFound, apiErrors.NewErrorResponse(http.StatusNotFound, "Player not found"))
+			return
+		}
+		c.JSON(http.StatusInternalServerError, apiErrors.NewErrorResponse(http.StatusInternalServerError, "Failed to load character", err.Error()))
+		return
+	}
+
+	base := func() *gorm.DB {
+		return db.Table("actor_encounter_stats AS a").
+			Joins("JOIN encounters e ON e.id = a.encounter_id").
+			Where("a.actor_id = ? AND a.is_player = ?", actorID, true)
+	}
+	hpsExpr := lib.ActorRateSQL("a.heal_dealt", "a", "e")
+
+	queries := []struct {
+		what string
+		run  func() error
+	}{
+		{"name history", func() error {
+			return base().Select("a.name, MIN(e.started_at) AS first_seen, MAX(e.started_at) AS last_seen, COUNT(*) AS encounters").
+				Where("a.name IS NOT NULL AND a.name <> ''").
+				Group("a.name").Order("first_seen ASC").Scan(&resp.NameHistory).Error
+		}},
+		{"class history", func() error {
+			return base().Select("a.class_id, a.class_spec, MIN(e.started_at) AS first_seen, MAX(e.started_at) AS last_seen, COUNT(*) AS encounters").
+				Group("a.class_id, a.class_spec").Order("first_seen ASC").Scan(&resp.ClassHistory).Error
+		}},
+		{"ability scores", func() error {
+			return base().Select("date_trunc('day', e.started_at) AS day, MAX(a.ability_score) AS ability_score").
+				Where("a.ability_score IS NOT NULL AND a.ability_score > 0").
+				Group("day").Order("day ASC").Scan(&resp.AbilityScores).Error
+		}},
+		{"recent encounters", func() error {
+			return base().Select("a.encounter_id, e.scene_id, e.scene_name, e.started_at, e.duration, a.class_spec, a.dps, a.percentile, " + hpsExpr + " AS hps").
+				Order("e.started_at DESC").Limit(10).Scan(&resp.RecentEncounters).Error
+		}},
+		{"best parses", func() error {
+			return db.Raw(`
+				SELECT DISTINCT ON (`+scenes.KeySQL("e")+`) a.encounter_id, e.scene_id, e.scene_name, e.started_at, e.duration, a.class_spec, a.dps, a.percentile, `+hpsExpr+` AS hps
+				FROM actor_encounter_stats a
+				JOIN encounters e ON e.id = a.encounter_id
+				WHERE a.actor_id = ? AND a.is_player = true AND (e.scene_id IS NOT NULL OR (e.scene_name IS NOT NULL AND e.scene_name <> ''))
+				ORDER BY `+scenes.KeySQL("e")+`, a.dps DESC, a.id ASC`, actorID).Scan(&resp.BestParsesByScene).Error
+		}},
+		{"totals", func() error {
+			return db.Raw(`
+				SELECT COUNT(*) AS encounters,
+					   COALESCE(SUM(a.damage_dealt), 0) AS damage_dealt,
+					   COALESCE(SUM(a.heal_dealt), 0) AS heal_dealt,
+					   COALESCE(SUM(a.damage_taken), 0) AS damage_taken,
+					   COALESCE(SUM(e.duration), 0) AS duration,
+					   (SELECT COUNT(*) FROM death_events d WHERE d.actor_id = ?) AS deaths,
+					   MIN(e.started_at) AS first_seen,
+					   MAX(e.started_at) AS last_seen
+				FROM actor_encounter_stats a
+				JOIN encounters e ON e.id = a.encounter_id
+				WHERE a.actor_id = ? AND a.is_player = true`, actorID, actorID).Scan(&resp.Totals).Error
+		}},
+	}
+	for _, q := range queries {
+		if err := q.run(); err != nil {
+			c.JSON(http.StatusInternalServerError, apiErrors.NewErrorResponse(http.StatusInternalServerError, "Failed to load "+q.what, err.Error()))
+			return
+		}
+	}
+
+	if resp.NameHistory == nil {
+		resp.NameHistory = []NameHistoryEntry{}
+	}
+	if resp.ClassHistory == nil {
+		resp.ClassHistory = []ClassHistoryEntry{}
+	}
+	if resp.AbilityScores == nil {
+		resp.AbilityScores = []AbilityScorePoint{}
+	}
+	if resp.RecentEncounters == nil {
+		resp.RecentEncounters = []ProfileEncounter{}
+	}
+	if resp.BestParsesByScene == nil {
+		resp.BestParsesByScene = []ProfileEncounter{}
+	}
+	locale := c.GetString("locale")
+	for _, list := range [][]ProfileEncounter{resp.RecentEncounters, resp.BestParsesByScene} {
+		for i := range list {
+			list[i].SceneName = gamedata.Get().LocalizeScene(list[i].SceneID, list[i].SceneName, locale)
+		}
+	}
+
+	c.JSON(http.StatusOK, resp)
+}
+
+type SetPlayerPrivacyRequest struct {
+	Private *bool `json:"private" binding:"required"`
+}
+
+// PUT /api/v1/players/:actorId/privacy
+// Requires authentication. Only the character's verified owner (see
+// POST /players/:actorId/claim) may change the opt-out.
+func SetPlayerPrivacy(c *gin.Context) {
+	userVal, ok := c.Get("user")
+	if !ok {
+		c.JSON(http.StatusUnauthorized, apiErrors.NewErrorResponse(http.StatusUnauthorized, "Not authenticated"))
+		return
+	}
+	user := userVal.(*models.User)
+
+	dbAny, ok := c.Get("db")
+	if !ok {
+		c.JSON(http.StatusInternalServerError, apiErrors.NewErrorResponse(http.StatusInternalServerError, "Database not available in context"))
+		return
+	}
+	db := dbAny.(*gorm.DB)
+
+	actorID, err := strconv.ParseInt(c.Param("actorId"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, apiErrors.NewErrorResponse(http.StatusBadRequest, "Invalid actorId", err.Error()))
+		return
+	}
+	var req SetPlayerPrivacyRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, apiErrors.NewErrorResponse(http.StatusBadRequest, "Invalid request payload", err.Error()))
+		return
+	}
+
+	owns, err := ownsCharacter(db, user.ID, actorID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, apiErrors.NewErrorResponse(http.StatusInternalServerError, "Failed to verify ownership", err.Error()))
+		return
+	}
+	if !owns {
+		c.JSON(http.StatusForbidden, apiErrors.NewErrorResponse(http.StatusForbidden, "Forbidden: character is not linked to this account"))
+		return
+	}
+
+	res := db.Model(&models.Character{}).Where("actor_id = ?", actorID).Update("is_private", *req.Private)
+	if res.Error != nil {
+		c.JSON(http.StatusInternalServerError, apiErrors.NewErrorResponse(http.StatusInternalServerError, "Failed to update privacy", res.Error.Error()))
+		return
+	}
+	if res.RowsAffected == 0 {
+		c.JSON(http.StatusNotFound, apiErrors.NewErrorResponse(http.StatusNotFound, "Player not found"))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"actorId": actorID, "isPrivate": *req.Private})
+}
@@ -0,0 +1,144 @@
+package player
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	dbpkg "server/db"
+	"server/models"
+	"server/services/privacy"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// playerRequest runs handler for a request as user (nil for anonymous) against db, with
+// the actorId route param set.
+func playerRequest(t *testing.T, db *gorm.DB, handler gin.HandlerFunc, method, actorID, body string, user *models.User) *httptest.ResponseRecorder {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	var r io.Reader
+	if body != "" {
+		r = strings.NewReader(body)
+	}
+	c.Request = httptest.NewRequest(method, "/api/v1/players/"+actorID, r)
+	c.Request.Header.Set("Content-Type", "application/json")
+	c.Params = gin.Params{{Key: "actorId", Value: actorID}}
+	c.Set("db", db)
+	if user != nil {
+		c.Set("user", user)
+	}
+	handler(c)
+	return w
+}
+
+func TestPlayerHandlers_RejectInvalidParams(t *testing.T) {
+	user := &models.User{ID: 1}
+	cases := []struct {
+		name    string
+		handler gin.HandlerFunc
+		method  string
+		actorID string
+		body    string
+		user    *models.User
+		want    int
+	}{
+		{"profile, bad id", GetPlayerProfile, http.MethodGet, "abc", "", nil, http.StatusBadRequest},
+		{"privacy, anonymous", SetPlayerPrivacy, http.MethodPut, "10", `{"private": true}`, nil, http.StatusUnauthorized},
+		{"privacy, bad id", SetPlayerPrivacy, http.MethodPut, "abc", `{"private": true}`, user, http.StatusBadRequest},
+		{"privacy, no value", SetPlayerPrivacy, http.MethodPut, "10", `{}`, user, http.StatusBadRequest},
+		{"claim, anonymous", ClaimCharacter, http.MethodPost, "10", "", nil, http.StatusUnauthorized},
+		{"claim, bad id", ClaimCharacter, http.MethodPost, "abc", "", user, http.StatusBadRequest},
+		{"claim, bad body", ClaimCharacter, http.MethodPost, "10", `{"evidence": 5}`, user, http.StatusBadRequest},
+	}
+	for _, tc := range cases {
+		db, stmts, err := dbpkg.DryRun()
+		if err != nil {
+			t.Fatal(err)
+		}
+		w := playerRequest(t, db, tc.handler, tc.method, tc.actorID, tc.body, tc.user)
+		if w.Code != tc.want {
+			t.Errorf("%s: expected %d, got %d", tc.name, tc.want, w.Code)
+		}
+		if got := stmts.All(); len(got) != 0 {
+			t.Errorf("%s: expected no queries, got %v", tc.name, got)
+		}
+	}
+}
+
+// Needs a Postgres server in TEST_DATABASE_URL; ownership and visibility are read from it.
+func TestPlayerPrivacy_OnlyTheVerifiedOwnerDecides(t *testing.T) {
+	db, drop, err := dbpkg.TestDB()
+	if errors.Is(err, dbpkg.ErrNoTestDB) {
+		t.Skip(err)
+	}
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer drop()
+	if err := db.AutoMigrate(&models.User{}, &models.Encounter{}, &models.ActorEncounterStat{}, &models.DeathEvent{},
+		&models.Character{}, &models.CharacterClaim{}); err != nil {
+		t.Fatal(err)
+	}
+	owner, other, admin := &models.User{ID: 1, DiscordUserID: "1"}, &models.User{ID: 2, DiscordUserID: "2"}, &models.User{ID: 3, DiscordUserID: "3", Role: "admin"}
+	name := "Ann"
+	for _, row := range []interface{}{owner, other, admin, &models.Character{ActorID: 10, Name: &name, LastSeenAt: time.Now()}} {
+		if err := db.Create(row).Error; err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	expect := func(what string, w *httptest.ResponseRecorder, code int) {
+		t.Helper()
+		if w.Code != code {
+			t.Fatalf("%s: expected %d, got %d: %s", what, code, w.Code, w.Body.String())
+		}
+	}
+	claim := func(user *models.User) models.CharacterClaim {
+		t.Helper()
+		w := playerRequest(t, db, ClaimCharacter, http.MethodPost, "10", `{"evidence": " screenshot "}`, user)
+		expect("claim", w, http.StatusOK)
+		var out models.CharacterClaim
+		if err := json.Unmarshal(w.Body.Bytes(), &out); err != nil {
+			t.Fatal(err)
+		}
+		return out
+	}
+
+	expect("claim of an unknown character", playerRequest(t, db, ClaimCharacter, http.MethodPost, "99", "", owner), http.StatusNotFound)
+	ownerClaim, otherClaim := claim(owner), claim(other)
+	if ownerClaim.Status != models.ClaimPending || ownerClaim.Evidence == nil || *ownerClaim.Evidence != "screenshot" {
+		t.Errorf("Expected a pending claim with trimmed evidence, got %+v", ownerClaim)
+	}
+
+	// Claiming alone doesn't make the user the owner
+	expect("privacy before approval", playerRequest(t, db, SetPlayerPrivacy, http.MethodPut, "10", `{"private": true}`, owner), http.StatusForbidden)
+
+	if err := privacy.ResolveClaim(db, &ownerClaim, models.ClaimApproved, admin.ID, nil); err != nil {
+		t.Fatal(err)
+	}
+	if err := db.First(&otherClaim, otherClaim.ID).Error; err != nil || otherClaim.Status != models.ClaimRejected {
+		t.Errorf("Expected the competing claim to be rejected, got %+v (%v)", otherClaim, err)
+	}
+
+	expect("privacy by another user", playerRequest(t, db, SetPlayerPrivacy, http.MethodPut, "10", `{"private": true}`, other), http.StatusForbidden)
+	expect("privacy by the owner", playerRequest(t, db, SetPlayerPrivacy, http.MethodPut, "10", `{"private": true}`, owner), http.StatusOK)
+
+	// A private profile is only visible to its owner
+	expect("anonymous profile", playerRequest(t, db, GetPlayerProfile, http.MethodGet, "10", "", nil), http.StatusNotFound)
+	expect("another user's view", playerRequest(t, db, GetPlayerProfile, http.MethodGet, "10", "", other), http.StatusNotFound)
+	expect("owner's view", playerRequest(t, db, GetPlayerProfile, http.MethodGet, "10", "", owner), http.StatusOK)
+
+	// A rejected claimant may try again
+	if reopened := claim(other); reopened.ID != otherClaim.ID || reopened.Status != models.ClaimPending {
+		t.Errorf("Expected the rejected claim to be reopened, got %+v", reopened)
+	}
+}
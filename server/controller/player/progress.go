@@ -0,0 +1,68 @@
+package player
+
+import (
+	"net/http"
+	"strconv"
+
+	apiErrors "server/controller"
+	"server/models"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+type GetPlayerProgressResponse struct {
+	ActorID  int64                    `json:"actorId"`
+	Dungeons []models.DungeonProgress `json:"dungeons"`
+	// Highest master-mode difficulty cleared in any dungeon in the latest season recorded
+	// for the character; 0 when they have no master-mode clear.
+	MasterSeasonID   int64 `json:"masterSeasonId"`
+	MasterDifficulty int64 `json:"masterDifficulty"`
+}
+
+// GET /api/v1/players/:actorId/progress
+// Dungeon clears and master-mode progress from the character's latest uploaded snapshot,
+// ordered by dungeon ID. Private characters return 404 unless requested by their owner.
+func GetPlayerProgress(c *gin.Context) {
+	dbAny, ok := c.Get("db")
+	if !ok {
+		c.JSON(http.StatusInternalServerError, apiErrors.NewErrorResponse(http.StatusInternalServerError, "Database not available in context"))
+		return
+	}
+	db := dbAny.(*gorm.DB)
+
+	actorID, err := strconv.ParseInt(c.Param("actorId"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, apiErrors.NewErrorResponse(http.StatusBadRequest, "Invalid actorId", err.Error()))
+		return
+	}
+
+	visible, err := characterVisible(c, db, actorID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, apiErrors.NewErrorResponse(http.StatusInternalServerError, "Failed to load character", err.Error()))
+		return
+	}
+	if !visible {
+		c.JSON(http.StatusNotFound, apiErrors.NewErrorResponse(http.StatusNotFound, "Player not found"))
+		return
+	}
+
+	resp := GetPlayerProgressResponse{ActorID: actorID}
+	if err := db.Where("player_id = ?", actorID).Order("dungeon_id ASC").Find(&resp.Dungeons).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, apiErrors.NewErrorResponse(http.StatusInternalServerError, "Failed to load dungeon progress", err.Error()))
+		return
+	}
+	if resp.Dungeons == nil {
+		resp.Dungeons = []models.DungeonProgress{}
+	}
+	for _, d := range resp.Dungeons {
+		if d.MasterSeasonID > resp.MasterSeasonID {
+			resp.MasterSeasonID, resp.MasterDifficulty = d.MasterSeasonID, 0
+		}
+		if d.MasterSeasonID == resp.MasterSeasonID && d.MasterDifficulty > resp.MasterDifficulty {
+			resp.MasterDifficulty = d.MasterDifficulty
+		}
+	}
+
+	c.JSON(http.StatusOK, resp)
+}
@@ -0,0 +1,92 @@
+package player
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	apiErrors "server/controller"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// PlayerSearchResult is a character matching a search query.
+type PlayerSearchResult struct {
+	ActorID      int64     `gorm:"column:actor_id" json:"actorId"`
+	Name         string    `gorm:"column:name" json:"name"`
+	ClassID      *int64    `gorm:"column:class_id" json:"classId,omitempty"`
+	ClassSpec    *int64    `gorm:"column:class_spec" json:"classSpec,omitempty"`
+	AbilityScore *int64    `gorm:"column:ability_score" json:"abilityScore,omitempty"`
+	LastSeenAt   time.Time `gorm:"column:last_seen_at" json:"lastSeenAt"`
+	Score        float64   `gorm:"column:score" json:"score"`
+}
+
+type SearchPlayersResponse struct {
+	Query   string               `json:"query"`
+	Players []PlayerSearchResult `json:"players"`
+}
+
+// escapeLike escapes LIKE wildcards so user input is matched literally.
+func escapeLike(s string) string {
+	return strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`).Replace(s)
+}
+
+// GET /api/v1/players/search
+// Query params: q (required, at least 2 characters), limit (default 10, max 25)
+//
+// Characters are matched by name prefix or trigram similarity (pg_trgm) and ranked exact
+// match first, then prefix matches, then by similarity and recency. Each character appears
+// once with its latest class and ability score; private characters are never returned.
+func SearchPlayers(c *gin.Context) {
+	dbAny, ok := c.Get("db")
+	if !ok {
+		c.JSON(http.StatusInternalServerError, apiErrors.NewErrorResponse(http.StatusInternalServerError, "Database not available in context"))
+		return
+	}
+	db := dbAny.(*gorm.DB)
+
+	q := strings.ToLower(strings.TrimSpace(c.Query("q")))
+	if len([]rune(q)) < 2 {
+		c.JSON(http.StatusBadRequest, apiErrors.NewErrorResponse(http.StatusBadRequest, "Query param q must be at least 2 characters"))
+		return
+	}
+	limit := 10
+	if v := c.Query("limit"); v != "" {
+		if n, err := strconv.Atoi(v); err == nil && n > 0 && n <= 25 {
+			limit = n
+		}
+	}
+	prefix := escapeLike(q) + "%"
+
+	var rows []PlayerSearchResult
+	err := db.Raw(`
+		SELECT actor_id, name, class_id, class_spec, ability_score, last_seen_at,
+			   similarity(LOWER(name), ?) AS score
+		FROM characters
+		WHERE is_private = false AND name IS NOT NULL
+		  AND (LOWER(name) LIKE ? OR LOWER(name) % ?)
+		ORDER BY (LOWER(name) = ?) DESC, (LOWER(name) LIKE ?) DESC, score DESC, last_seen_at DESC
+		LIMIT ?`, q, prefix, q, q, prefix, limit).Scan(&rows).Error
+	if err != nil {
+		// pg_trgm may be unavailable (extension not installed); fall back to substring matching
+		contains := "%" + escapeLike(q) + "%"
+		rows = nil
+		err = db.Raw(`
+			SELECT actor_id, name, class_id, class_spec, ability_score, last_seen_at, 0 AS score
+			FROM characters
+			WHERE is_private = false AND name IS NOT NULL AND LOWER(name) LIKE ?
+			ORDER BY (LOWER(name) = ?) DESC, (LOWER(name) LIKE ?) DESC, last_seen_at DESC
+			LIMIT ?`, contains, q, prefix, limit).Scan(&rows).Error
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, apiErrors.NewErrorResponse(http.StatusInternalServerError, "Failed to search players", err.Error()))
+		return
+	}
+	if rows == nil {
+		rows = []PlayerSearchResult{}
+	}
+
+	c.JSON(http.StatusOK, SearchPlayersResponse{Query: q, Players: rows})
+}
@@ -0,0 +1,75 @@
+package player
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	dbpkg "server/db"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+func searchRequest(t *testing.T, db *gorm.DB, q string) *httptest.ResponseRecorder {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request = httptest.NewRequest(http.MethodGet, "/api/v1/players/search?q="+q, nil)
+	c.Set("db", db)
+	SearchPlayers(c)
+	return w
+}
+
+func TestSearchPlayers_RejectsShortQueries(t *testing.T) {
+	for _, q := range []string{"", "a", "%20%20b%20", "é"} {
+		db, stmts, err := dbpkg.DryRun()
+		if err != nil {
+			t.Fatal(err)
+		}
+		w := searchRequest(t, db, q)
+		if w.Code != http.StatusBadRequest {
+			t.Errorf("q=%q: expected 400, got %d", q, w.Code)
+		}
+		if got := stmts.All(); len(got) != 0 {
+			t.Errorf("q=%q: expected no queries, got %v", q, got)
+		}
+	}
+}
+
+func TestSearchPlayers_EscapesWildcardsAndFallsBack(t *testing.T) {
+	db, stmts, err := dbpkg.DryRun()
+	if err != nil {
+		t.Fatal(err)
+	}
+	// The dry run fails the trigram query like a server without pg_trgm would (and the
+	// fallback too, so the handler answers 500)
+	w := searchRequest(t, db, "A_n%25")
+	if w.Code == http.StatusBadRequest {
+		t.Fatalf("Expected the query to be accepted, got %s", w.Body.String())
+	}
+	all := stmts.All()
+	if len(all) != 2 {
+		t.Fatalf("Expected the trigram query and the fallback, got %v", all)
+	}
+	trigram, fallback := all[0], all[1]
+	if !strings.Contains(trigram, `similarity(LOWER(name), 'a_n%')`) || !strings.Contains(trigram, `LIKE 'a\_n\%%'`) {
+		t.Errorf("Expected a literal prefix match, got %s", trigram)
+	}
+	if !strings.Contains(fallback, `LOWER(name) LIKE '%a\_n\%%'`) || strings.Contains(fallback, "similarity") {
+		t.Errorf("Expected a literal substring match without pg_trgm, got %s", fallback)
+	}
+	for _, q := range all {
+		if !strings.Contains(q, "is_private = false") {
+			t.Errorf("Expected private characters to be left out, got %s", q)
+		}
+	}
+}
+
+func TestEscapeLike(t *testing.T) {
+	if got := escapeLike(`50%_off\`); got != `50\%\_off\\` {
+		t.Errorf("Unexpected escape: %s", got)
+	}
+}
@@ -0,0 +1,173 @@
+package statistics
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"server/services/moderation"
+	"server/services/patches"
+	"server/services/rollups"
+	"server/services/scenes"
+
+	"github.com/gin-gonic/gin"
+)
+
+// statsFilters is the filter set shared by the statistics endpoints.
+// Query params:
+//   - since_days: int (include encounters started within last N days)
+//   - min_duration, max_duration: float seconds (encounter duration)
+//   - scene_id: int (filter encounters by scene)
+//   - scene_name: string (filter by scene name, in any language the scene was uploaded in)
+//   - boss: string (only encounters with this boss, matched by name in any language)
+//   - patch: string (patch name, see GET /patches)
+//   - min_ability_score, max_ability_score: int (player ability score)
+//
+// Unparseable values are ignored, matching the endpoints' degrade-gracefully behaviour.
+type statsFilters struct {
+	SinceDays       int
+	MinDuration     *float64
+	MaxDuration     *float64
+	SceneID         *int64
+	SceneName       string
+	Boss            string
+	Patch           string
+	MinAbilityScore *int64
+	MaxAbilityScore *int64
+}
+
+// parseStatsFilters reads the shared filters from the query string. defaultSinceDays
+// applies when since_days is not given (0 = all time).
+func parseStatsFilters(c *gin.Context, defaultSinceDays int) statsFilters {
+	f := statsFilters{
+		SinceDays: defaultSinceDays,
+		SceneName: strings.TrimSpace(c.Query("scene_name")),
+		Boss:      strings.TrimSpace(c.Query("boss")),
+		Patch:     c.Query("patch"),
+	}
+	if v := c.Query("since_days"); v != "" {
+		if days, err := strconv.Atoi(v); err == nil && days > 0 {
+			f.SinceDays = days
+		}
+	}
+	if v, err := strconv.ParseInt(c.Query("scene_id"), 10, 64); err == nil {
+		f.SceneID = &v
+	}
+	if v, err := strconv.ParseFloat(c.Query("min_duration"), 64); err == nil {
+		f.MinDuration = &v
+	}
+	if v, err := strconv.ParseFloat(c.Query("max_duration"), 64); err == nil {
+		f.MaxDuration = &v
+	}
+	if v, err := strconv.ParseInt(c.Query("min_ability_score"), 10, 64); err == nil {
+		f.MinAbilityScore = &v
+	}
+	if v, err := strconv.ParseInt(c.Query("max_ability_score"), 10, 64); err == nil {
+		f.MaxAbilityScore = &v
+	}
+	return f
+}
+
+// IsZero reports whether no filter is set.
+func (f statsFilters) IsZero() bool {
+	return f == statsFilters{}
+}
+
+// encounterConds returns the conditions over encounters e, each starting with " AND ".
+// Encounters held for review are always left out.
+func (f statsFilters) encounterConds() (string, []interface{}) {
+	conds := " AND " + moderation.ListedSQL("e")
+	var args []interface{}
+	if f.SinceDays > 0 {
+		// embed literal days into interval; safe since SinceDays is a parsed positive int
+		conds += fmt.Sprintf(" AND e.started_at >= NOW() - INTERVAL '%d days'", f.SinceDays)
+	}
+	if f.MinDuration != nil {
+		conds += " AND e.duration >= ?"
+		args = append(args, *f.MinDuration)
+	}
+	if f.MaxDuration != nil {
+		conds += " AND e.duration <= ?"
+		args = append(args, *f.MaxDuration)
+	}
+	if f.SceneID != nil {
+		conds += " AND e.scene_id = ?"
+		args = append(args, *f.SceneID)
+	}
+	if f.SceneName != "" {
+		sceneSQL, sceneArgs := scenes.NameFilter("e", f.SceneName)
+		conds += " AND " + sceneSQL
+		args = append(args, sceneArgs...)
+	}
+	if f.Boss != "" {
+		bossSQL, bossArgs := scenes.BossFilter("b", f.Boss)
+		conds += " AND EXISTS (SELECT 1 FROM encounter_bosses b WHERE b.encounter_id = e.id AND " + bossSQL + ")"
+		args = append(args, bossArgs...)
+	}
+	if f.Patch != "" {
+		conds += " AND " + patches.FilterSQL("e.patch_id")
+		args = append(args, f.Patch)
+	}
+	return conds, args
+}
+
+// playerConds returns the conditions over actor_encounter_stats a, each starting with " AND ".
+func (f statsFilters) playerConds() (string, []interface{}) {
+	conds := ""
+	var args []interface{}
+	if f.MinAbilityScore != nil {
+		conds += " AND a.ability_score >= ?"
+		args = append(args, *f.MinAbilityScore)
+	}
+	if f.MaxAbilityScore != nil {
+		conds += " AND a.ability_score <= ?"
+		args = append(args, *f.MaxAbilityScore)
+	}
+	return conds, args
+}
+
+// playerWhere returns a WHERE clause selecting the matching player rows of a joined with e.
+func (f statsFilters) playerWhere() (string, []interface{}) {
+	encConds, encArgs := f.encounterConds()
+	playerConds, playerArgs := f.playerConds()
+	return "WHERE a.is_player = true" + encConds + playerConds, append(encArgs, playerArgs...)
+}
+
+// rollupWhere returns a WHERE clause over stat_rollups r equivalent to the filters, except
+// since_days which callers split by day. ok is false when rollups can't answer exactly:
+// they are split by UTC day, patch, scene, spec and ability bracket, so filters cutting
+// through another dimension need the live rows.
+func (f statsFilters) rollupWhere() (where string, args []interface{}, ok bool) {
+	if f.MinDuration != nil || f.MaxDuration != nil || f.Boss != "" {
+		return "", nil, false
+	}
+	where = "WHERE TRUE"
+	if f.SceneID != nil {
+		where += " AND r.scene_id = ?"
+		args = append(args, *f.SceneID)
+	}
+	if f.SceneName != "" {
+		sceneSQL, sceneArgs := scenes.NameFilter("r", f.SceneName)
+		where += " AND " + sceneSQL
+		args = append(args, sceneArgs...)
+	}
+	if f.Patch != "" {
+		where += " AND " + patches.FilterSQL("r.patch_id")
+		args = append(args, f.Patch)
+	}
+	if v := f.MinAbilityScore; v != nil {
+		if *v%rollups.BracketSize != 0 {
+			return "", nil, false
+		}
+		where += " AND r.ability_bracket >= ?"
+		args = append(args, *v)
+	}
+	if v := f.MaxAbilityScore; v != nil {
+		if (*v+1)%rollups.BracketSize != 0 {
+			return "", nil, false
+		}
+		where += " AND r.ability_bracket <= ?"
+		args = append(args, *v+1-rollups.BracketSize)
+	}
+	return where, args, true
+}
@@ -0,0 +1,117 @@
+package statistics
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	dbpkg "server/db"
+
+	"github.com/gin-gonic/gin"
+)
+
+// statsRequest runs handler for a GET of target against a dry-run DB.
+func statsRequest(t *testing.T, handler gin.HandlerFunc, target string) (*httptest.ResponseRecorder, *dbpkg.Statements) {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+	db, stmts, err := dbpkg.DryRun()
+	if err != nil {
+		t.Fatal(err)
+	}
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request = httptest.NewRequest(http.MethodGet, target, nil)
+	c.Set("db", db)
+	handler(c)
+	return w, stmts
+}
+
+func TestParseStatsFilters_IgnoresUnparseableValues(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	c, _ := gin.CreateTestContext(httptest.NewRecorder())
+	c.Request = httptest.NewRequest(http.MethodGet, "/?since_days=-3&min_duration=abc&max_duration=90.5&scene_id=x&min_ability_score=2000&patch=1.2", nil)
+	f := parseStatsFilters(c, 30)
+	if f.SinceDays != 30 || f.MinDuration != nil || f.SceneID != nil {
+		t.Errorf("Expected unparseable values to be ignored, got %+v", f)
+	}
+	if f.MaxDuration == nil || *f.MaxDuration != 90.5 || f.MinAbilityScore == nil || *f.MinAbilityScore != 2000 || f.Patch != "1.2" {
+		t.Errorf("Expected the valid values to be read, got %+v", f)
+	}
+	if f.IsZero() || !(statsFilters{}).IsZero() {
+		t.Error("Unexpected IsZero")
+	}
+}
+
+func TestStatsFilters_RollupWhereOnlyWhenExact(t *testing.T) {
+	ptr := func(v int64) *int64 { return &v }
+	dur := 60.0
+	cases := []struct {
+		name string
+		f    statsFilters
+		ok   bool
+	}{
+		{"unfiltered", statsFilters{}, true},
+		{"whole brackets", statsFilters{MinAbilityScore: ptr(2000), MaxAbilityScore: ptr(3999)}, true},
+		{"partial min bracket", statsFilters{MinAbilityScore: ptr(2500)}, false},
+		{"partial max bracket", statsFilters{MaxAbilityScore: ptr(3000)}, false},
+		{"duration", statsFilters{MinDuration: &dur}, false},
+		{"boss", statsFilters{Boss: "Tina"}, false},
+	}
+	for _, tc := range cases {
+		where, args, ok := tc.f.rollupWhere()
+		if ok != tc.ok {
+			t.Errorf("%s: expected ok=%v, got %v (%s)", tc.name, tc.ok, ok, where)
+		}
+		if tc.name == "whole brackets" && (len(args) != 2 || args[0] != int64(2000) || args[1] != int64(3000)) {
+			t.Errorf("Expected brackets 2000 to 3000, got %v", args)
+		}
+	}
+}
+
+func TestGetOverview_AppliesFilters(t *testing.T) {
+	_, stmts := statsRequest(t, GetOverview, "/api/v1/statistics/overview?scene_id=3&min_ability_score=2000&since_days=7")
+	all := stmts.All()
+	if len(all) == 0 {
+		t.Fatal("Expected the totals query")
+	}
+	q := all[0]
+	for _, want := range []string{
+		"FROM encounters e",
+		"e.held = false",
+		"e.scene_id = 3",
+		"INTERVAL '7 days'",
+		// Encounters with at least one player in range
+		"EXISTS (SELECT 1 FROM actor_encounter_stats a WHERE a.encounter_id = e.id AND a.is_player = true AND a.ability_score >= 2000)",
+	} {
+		if !strings.Contains(q, want) {
+			t.Errorf("Expected the totals query to contain %q, got %s", want, q)
+		}
+	}
+}
+
+func TestGetTotals_CountsMatchingPlayers(t *testing.T) {
+	w, stmts := statsRequest(t, GetTotals, "/api/v1/statistics/totals?boss=Tina&max_ability_score=2999")
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected 200, got %d", w.Code)
+	}
+	all := stmts.All()
+	if len(all) == 0 {
+		t.Fatal("Expected the totals query")
+	}
+	q := all[0]
+	for _, want := range []string{
+		"DISTINCT ON (a.actor_id)",
+		"a.is_player = true",
+		"e.held = false",
+		"LOWER(b.monster_name) = LOWER('Tina')",
+		"a.ability_score <= 2999",
+	} {
+		if !strings.Contains(q, want) {
+			t.Errorf("Expected the player count to contain %q, got %s", want, q)
+		}
+	}
+	if strings.Contains(q, "stat_actor_latest") {
+		t.Errorf("Expected filtered totals to read the live rows, got %s", q)
+	}
+}
@@ -0,0 +1,337 @@
+package statistics
+
+import (
+	"fmt"
+	"math"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+
+	apiErrors "server/controller"
+	"server/lib"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+const (
+	histogramDefaultBins = 30
+	histogramMaxBins     = 200
+)
+
+// HistogramBin is one bin's range. With scale=log the edges are still in metric units.
+type HistogramBin struct {
+	Lower float64 `json:"lower"`
+	Upper float64 `json:"upper"`
+}
+
+// ClassHistogram holds one spec's counts, aligned with HistogramResponse.Bins.
+type ClassHistogram struct {
+	ClassSpec int64   `json:"class_spec"`
+	Total     int64   `json:"total"`
+	Counts    []int64 `json:"counts"`
+}
+
+type HistogramResponse struct {
+	Metric   string           `json:"metric"`
+	Scale    string           `json:"scale"`
+	Bins     []HistogramBin   `json:"bins"`
+	Classes  []ClassHistogram `json:"classes"`
+	Excluded int64            `json:"excluded"` // samples that can't be placed on a log scale (value <= 0)
+}
+
+// GetHistogram returns per-spec binned counts of a metric. All specs share the same bins
+// so their shapes can be compared directly.
+// Query params:
+//   - metric: dps | hps | boss_dps (default dps)
+//   - bins: int bin count (default 30, max 200)
+//   - bin_width: float bin width in metric units (linear scale only; overrides bins)
+//   - scale: linear | log (log10-spaced bins; default linear)
+//   - class_spec: int (only this spec)
+//   - the shared statistics filters (see statsFilters)
+func GetHistogram(c *gin.Context) {
+	metric := strings.ToLower(c.DefaultQuery("metric", "dps"))
+	switch metric {
+	case "dps", "hps", "boss_dps":
+	default:
+		c.JSON(http.StatusBadRequest, apiErrors.NewErrorResponse(http.StatusBadRequest, "Invalid metric (expected dps, hps or boss_dps)"))
+		return
+	}
+	scale := strings.ToLower(c.DefaultQuery("scale", "linear"))
+	if scale != "linear" && scale != "log" {
+		c.JSON(http.StatusBadRequest, apiErrors.NewErrorResponse(http.StatusBadRequest, "Invalid scale (expected linear or log)"))
+		return
+	}
+	bins := histogramDefaultBins
+	if v := c.Query("bins"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 1 || n > histogramMaxBins {
+			c.JSON(http.StatusBadRequest, apiErrors.NewErrorResponse(http.StatusBadRequest, fmt.Sprintf("Invalid bins (expected 1-%d)", histogramMaxBins)))
+			return
+		}
+		bins = n
+	}
+	binWidth := 0.0
+	if v := c.Query("bin_width"); v != "" {
+		w, err := strconv.ParseFloat(v, 64)
+		if err != nil || w <= 0 || math.IsInf(w, 0) {
+			c.JSON(http.StatusBadRequest, apiErrors.NewErrorResponse(http.StatusBadRequest, "Invalid bin_width (expected a positive number)"))
+			return
+		}
+		if scale == "log" {
+			c.JSON(http.StatusBadRequest, apiErrors.NewErrorResponse(http.StatusBadRequest, "bin_width is only supported with scale=linear; use bins"))
+			return
+		}
+		binWidth = w
+	}
+
+	resp := HistogramResponse{Metric: metric, Scale: scale, Bins: []HistogramBin{}, Classes: []ClassHistogram{}}
+	dbAny, ok := c.Get("db")
+	if !ok {
+		c.JSON(http.StatusOK, resp)
+		return
+	}
+	db, ok := dbAny.(*gorm.DB)
+	if !ok {
+		c.JSON(http.StatusOK, resp)
+		return
+	}
+
+	f := parseStatsFilters(c, 0)
+	var classSpec *int64
+	if v := c.Query("class_spec"); v != "" {
+		if n, err := strconv.ParseInt(v, 10, 64); err == nil {
+			classSpec = &n
+		}
+	}
+
+	var h histogramSource
+	days, fromRollups, err := rollupDistributions(db, f)
+	if fromRollups {
+		h = newRollupHistogram(days, metric, scale, classSpec)
+	} else {
+		samplesQuery, samplesArgs := classSamples(f)
+		h = &sqlHistogram{db: db, metric: metric, scale: scale, classSpec: classSpec, samplesQuery: samplesQuery, samplesArgs: samplesArgs}
+	}
+	var total int64
+	var lo, hi float64
+	if err == nil {
+		total, lo, hi, ok, err = h.bounds()
+	}
+	if err != nil || !ok {
+		// No samples (or a failed query): empty histogram, as the other statistics do
+		c.JSON(http.StatusOK, resp)
+		return
+	}
+
+	if binWidth > 0 {
+		if lo, hi, bins, ok = fixedWidthBins(lo, hi, binWidth); !ok {
+			c.JSON(http.StatusBadRequest, apiErrors.NewErrorResponse(http.StatusBadRequest, fmt.Sprintf("bin_width too small: more than %d bins", histogramMaxBins)))
+			return
+		}
+	} else if hi <= lo {
+		// Every sample has the same value; width_bucket needs a non-empty range
+		hi = lo + 1
+	}
+
+	rows, err := h.counts(lo, hi, bins)
+	if err != nil {
+		c.JSON(http.StatusOK, resp)
+		return
+	}
+
+	width := (hi - lo) / float64(bins)
+	for i := 0; i < bins; i++ {
+		lower, upper := lo+float64(i)*width, lo+float64(i+1)*width
+		if scale == "log" {
+			lower, upper = math.Pow(10, lower), math.Pow(10, upper)
+		}
+		resp.Bins = append(resp.Bins, HistogramBin{Lower: lower, Upper: upper})
+	}
+
+	placed := int64(0)
+	for _, r := range rows {
+		if n := len(resp.Classes); n == 0 || resp.Classes[n-1].ClassSpec != r.ClassSpec {
+			resp.Classes = append(resp.Classes, ClassHistogram{ClassSpec: r.ClassSpec, Counts: make([]int64, bins)})
+		}
+		cls := &resp.Classes[len(resp.Classes)-1]
+		if r.Bin >= 1 && r.Bin <= bins {
+			cls.Counts[r.Bin-1] += r.Count
+			cls.Total += r.Count
+			placed += r.Count
+		}
+	}
+	resp.Excluded = total - placed
+
+	c.JSON(http.StatusOK, resp)
+}
+
+// fixedWidthBins returns the range and number of bins of the given width covering [lo, hi],
+// with edges on multiples of the width. A maximum sitting exactly on the last edge gets a
+// bin of its own, so every bin holds its lower edge and not its upper one. ok is false when
+// more than histogramMaxBins bins would be needed.
+func fixedWidthBins(lo, hi, width float64) (binsLo, binsHi float64, bins int, ok bool) {
+	binsLo = math.Floor(lo/width) * width
+	n := math.Max(1, math.Ceil((hi-binsLo)/width))
+	if hi >= binsLo+n*width {
+		n++
+	}
+	if n > histogramMaxBins {
+		return 0, 0, 0, false
+	}
+	return binsLo, binsLo + n*width, int(n), true
+}
+
+// histogramCount is the number of a spec's samples in a bin (1-based).
+type histogramCount struct {
+	ClassSpec int64 `gorm:"column:class_spec"`
+	Bin       int   `gorm:"column:bin"`
+	Count     int64 `gorm:"column:cnt"`
+}
+
+// histogramSource bins the binned values x of one metric: the metric itself, or its log10
+// with scale=log (values <= 0 have none and are left out).
+type histogramSource interface {
+	// bounds returns the number of samples and the range of x; ok is false without any x
+	bounds() (total int64, lo, hi float64, ok bool, err error)
+	// counts bins x into bins equal-width bins over [lo, hi], ordered by spec and bin
+	counts(lo, hi float64, bins int) ([]histogramCount, error)
+}
+
+// sqlHistogram bins live samples.
+type sqlHistogram struct {
+	db            *gorm.DB
+	metric, scale string
+	classSpec     *int64
+	samplesQuery  string
+	samplesArgs   []interface{}
+}
+
+// values selects each sample's spec and x.
+func (h *sqlHistogram) values() (string, []interface{}) {
+	args := append([]interface{}{}, h.samplesArgs...)
+	specWhere := ""
+	if h.classSpec != nil {
+		specWhere = "WHERE s.class_spec = ?"
+		args = append(args, *h.classSpec)
+	}
+	x := "s." + h.metric
+	if h.scale == "log" {
+		x = fmt.Sprintf("(CASE WHEN s.%[1]s > 0 THEN LOG(s.%[1]s) END)", h.metric)
+	}
+	return fmt.Sprintf("SELECT s.class_spec, %s AS x FROM (%s) s %s", x, h.samplesQuery, specWhere), args
+}
+
+func (h *sqlHistogram) bounds() (int64, float64, float64, bool, error) {
+	values, args := h.values()
+	var b struct {
+		Total int64    `gorm:"column:total"`
+		Lo    *float64 `gorm:"column:lo"`
+		Hi    *float64 `gorm:"column:hi"`
+	}
+	if err := h.db.Raw("SELECT COUNT(*) AS total, MIN(v.x) AS lo, MAX(v.x) AS hi FROM ("+values+") v", args...).Scan(&b).Error; err != nil {
+		return 0, 0, 0, false, err
+	}
+	if b.Lo == nil {
+		return b.Total, 0, 0, false, nil
+	}
+	return b.Total, *b.Lo, *b.Hi, true, nil
+}
+
+func (h *sqlHistogram) counts(lo, hi float64, bins int) ([]histogramCount, error) {
+	values, valuesArgs := h.values()
+	// width_bucket puts x == hi into bin n+1, so fold it into the last bin
+	query := fmt.Sprintf(`
+		SELECT v.class_spec, LEAST(width_bucket(v.x, ?, ?, ?), ?) AS bin, COUNT(*) AS cnt
+		FROM (%s) v
+		WHERE v.x IS NOT NULL
+		GROUP BY 1, 2
+		ORDER BY 1, 2`, values)
+	var rows []histogramCount
+	err := h.db.Raw(query, append([]interface{}{lo, hi, bins, bins}, valuesArgs...)...).Scan(&rows).Error
+	return rows, err
+}
+
+// rollupHistogram bins distributions read from the rollups. They hold the same values as
+// the live samples, placed in bins the way width_bucket places them, so the counts match
+// the live query's.
+type rollupHistogram struct {
+	specs []specDay
+	scale string
+	get   func(*specDay) *lib.Distribution
+}
+
+func newRollupHistogram(days []specDay, metric, scale string, classSpec *int64) *rollupHistogram {
+	h := &rollupHistogram{scale: scale, get: func(d *specDay) *lib.Distribution { return d.metric(metric) }}
+	index := make(map[int64]int)
+	for _, d := range days {
+		if classSpec != nil && d.ClassSpec != *classSpec {
+			continue
+		}
+		if i, ok := index[d.ClassSpec]; ok {
+			h.specs[i].merge(d)
+			continue
+		}
+		index[d.ClassSpec] = len(h.specs)
+		h.specs = append(h.specs, specDay{ClassSpec: d.ClassSpec})
+		h.specs[len(h.specs)-1].merge(d)
+	}
+	sort.Slice(h.specs, func(i, j int) bool { return h.specs[i].ClassSpec < h.specs[j].ClassSpec })
+	return h
+}
+
+// x is the binned value of v, and false when it has none.
+func (h *rollupHistogram) x(v float64) (float64, bool) {
+	if h.scale == "log" {
+		if v <= 0 {
+			return 0, false
+		}
+		return math.Log10(v), true
+	}
+	return v, true
+}
+
+func (h *rollupHistogram) bounds() (total int64, lo, hi float64, ok bool, err error) {
+	for i := range h.specs {
+		d := h.get(&h.specs[i])
+		total += d.Count()
+		d.Each(func(v float64) {
+			if x, has := h.x(v); has {
+				if !ok || x < lo {
+					lo = x
+				}
+				if !ok || x > hi {
+					hi = x
+				}
+				ok = true
+			}
+		})
+	}
+	return total, lo, hi, ok, nil
+}
+
+func (h *rollupHistogram) counts(lo, hi float64, bins int) ([]histogramCount, error) {
+	var rows []histogramCount
+	for i := range h.specs {
+		counts := make([]int64, bins)
+		h.get(&h.specs[i]).Each(func(v float64) {
+			x, has := h.x(v)
+			if !has || x < lo {
+				return
+			}
+			// width_bucket's arithmetic, with x == hi folded into the last bin
+			bin := bins - 1
+			if x < hi {
+				bin = min(int(float64(bins)*((x-lo)/(hi-lo))), bins-1)
+			}
+			counts[bin]++
+		})
+		for b, n := range counts {
+			if n > 0 {
+				rows = append(rows, histogramCount{ClassSpec: h.specs[i].ClassSpec, Bin: b + 1, Count: n})
+			}
+		}
+	}
+	return rows, nil
+}
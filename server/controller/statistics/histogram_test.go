@@ -0,0 +1,100 @@
+package statistics
+
+import (
+	"math"
+	"net/http"
+	"testing"
+
+	"server/lib"
+)
+
+func TestRollupHistogram_BinsLikeWidthBucket(t *testing.T) {
+	days := []specDay{
+		{ClassSpec: 2, Day: "2025-06-01", DPS: lib.NewDistribution(0, 1.99, 2, 4, 10)},
+		{ClassSpec: 2, Day: "2025-06-02", DPS: lib.NewDistribution(5.5)},
+		{ClassSpec: 7, Day: "2025-06-01", DPS: lib.NewDistribution(9.999)},
+	}
+	h := newRollupHistogram(days, "dps", "linear", nil)
+	total, lo, hi, ok, _ := h.bounds()
+	if !ok || total != 7 || lo != 0 || hi != 10 {
+		t.Fatalf("Expected 7 samples over [0, 10], got %d over [%v, %v]", total, lo, hi)
+	}
+	rows, _ := h.counts(lo, hi, 5)
+	// Lower edges belong to their bin and the maximum to the last one
+	want := []histogramCount{
+		{ClassSpec: 2, Bin: 1, Count: 2}, {ClassSpec: 2, Bin: 2, Count: 1}, {ClassSpec: 2, Bin: 3, Count: 2},
+		{ClassSpec: 2, Bin: 5, Count: 1}, {ClassSpec: 7, Bin: 5, Count: 1},
+	}
+	if len(rows) != len(want) {
+		t.Fatalf("Expected %v, got %v", want, rows)
+	}
+	for i := range want {
+		if rows[i] != want[i] {
+			t.Errorf("Row %d: expected %+v, got %+v", i, want[i], rows[i])
+		}
+	}
+
+	// Only positive values have a place on a log scale
+	total, lo, _, _, _ = newRollupHistogram(days, "dps", "log", nil).bounds()
+	if total != 7 || lo != math.Log10(1.99) {
+		t.Errorf("Expected zeros to be left out of the log range, got lower bound %v", lo)
+	}
+}
+
+func TestGetHistogram_RejectsInvalidParams(t *testing.T) {
+	for _, query := range []string{
+		"metric=dtps",
+		"scale=sqrt",
+		"bins=0",
+		"bins=201",
+		"bins=ten",
+		"bin_width=0",
+		"bin_width=-5",
+		"bin_width=Inf",
+		"bin_width=abc",
+		"bin_width=100&scale=log",
+	} {
+		w, stmts := statsRequest(t, GetHistogram, "/api/v1/statistics/histogram?"+query)
+		if w.Code != http.StatusBadRequest {
+			t.Errorf("%s: expected 400, got %d", query, w.Code)
+		}
+		if got := stmts.All(); len(got) != 0 {
+			t.Errorf("%s: expected no queries, got %v", query, got)
+		}
+	}
+}
+
+func TestFixedWidthBins_AlignsEdgesAndKeepsTheMaximum(t *testing.T) {
+	cases := []struct {
+		lo, hi, width  float64
+		wantLo, wantHi float64
+		wantBins       int
+	}{
+		// Edges on multiples of the width
+		{3, 9, 5, 0, 10, 2},
+		// A maximum on the last edge gets its own bin rather than folding into [5, 10)
+		{3, 10, 5, 0, 15, 3},
+		// Every sample alike still makes one bin
+		{7, 7, 5, 5, 10, 1},
+		{10, 10, 5, 10, 15, 1},
+	}
+	for _, tc := range cases {
+		lo, hi, bins, ok := fixedWidthBins(tc.lo, tc.hi, tc.width)
+		if !ok || lo != tc.wantLo || hi != tc.wantHi || bins != tc.wantBins {
+			t.Errorf("fixedWidthBins(%v, %v, %v) = %v, %v, %d, %v; want %v, %v, %d",
+				tc.lo, tc.hi, tc.width, lo, hi, bins, ok, tc.wantLo, tc.wantHi, tc.wantBins)
+		}
+	}
+	if _, _, _, ok := fixedWidthBins(0, 1000, 1); ok {
+		t.Error("Expected more than the maximum number of bins to be refused")
+	}
+
+	// The rollup binning places the maximum in the last of those bins
+	lo, hi, bins, _ := fixedWidthBins(3, 10, 5)
+	h := newRollupHistogram([]specDay{{ClassSpec: 1, DPS: lib.NewDistribution(3, 5, 10)}}, "dps", "linear", nil)
+	rows, _ := h.counts(lo, hi, bins)
+	want := []histogramCount{{ClassSpec: 1, Bin: 1, Count: 1}, {ClassSpec: 1, Bin: 2, Count: 1}, {ClassSpec: 1, Bin: 3, Count: 1}}
+	if len(rows) != len(want) || rows[0] != want[0] || rows[1] != want[1] || rows[2] != want[2] {
+		t.Errorf("Expected one sample per bin, got %v", rows)
+	}
+}
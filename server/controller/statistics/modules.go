@@ -0,0 +1,207 @@
+package statistics
+
+import (
+	"encoding/json"
+	"math"
+	"net/http"
+	"sort"
+	"strconv"
+
+	apiErrors "server/controller"
+	"server/lib"
+	"server/services/module_optimizer"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+const (
+	moduleDefaultTopPercent = 10
+	// moduleMinCharacters is the smallest population reported at all, the smallest top
+	// group, and the smallest group whose mean DPS, usage or level count is shown, so no
+	// figure describes a single character.
+	moduleMinCharacters = 5
+	moduleMaxLevel      = 6
+)
+
+// ModuleLevelStat is the characters at one attribute level and their mean recent DPS.
+type ModuleLevelStat struct {
+	Level      int      `json:"level"`
+	Characters int64    `json:"characters"`
+	MeanDPS    *float64 `json:"mean_dps,omitempty"` // omitted for groups smaller than 5
+}
+
+// ModuleAttributeStat describes one module attribute across a spec's characters.
+type ModuleAttributeStat struct {
+	AttrID   int     `json:"attr_id"`
+	Name     string  `json:"name"`
+	Type     string  `json:"type"`      // basic | special
+	UsageAll float64 `json:"usage_all"` // share of all characters with the attribute at level 1+
+	UsageTop float64 `json:"usage_top"` // same, among the top characters; 0 below 5 of them
+	// Mean level among top characters running the attribute; 0 below 5 of them
+	AvgLevelTop float64 `json:"avg_level_top"`
+	// Top characters per level 1..6; levels run by fewer than 5 of them read 0
+	LevelsTop []int64 `json:"levels_top"`
+	// Correlation of the attribute's level (0 when absent) with recent DPS, all characters
+	DPSCorrelation float64           `json:"dps_correlation"`
+	ByLevel        []ModuleLevelStat `json:"by_level"` // all characters, levels 0..6
+}
+
+type characterModules struct {
+	dps    float64
+	levels map[int]int // attribute ID -> level, levels >= 1 only
+}
+
+// GetModuleStats reports the module attributes and levels a spec's characters run, for
+// the top performers and overall, and how each attribute's level correlates with DPS.
+// Query params:
+//   - class_spec: int (required)
+//   - top_percent: int (share of characters, by recent DPS, counted as top; default 10)
+//   - the shared statistics filters (see statsFilters), which select the parses
+//
+// Modules are read from each character's latest uploaded CharSerialize equip slots, as
+// derived at ingest (models.CharacterBuild); characters whose data has no slot information
+// are skipped. Recent DPS is the mean of the character's last 10 matching parses. Only
+// aggregates over at least 5 characters are returned; the top group is never smaller.
+func GetModuleStats(c *gin.Context) {
+	dbAny, ok := c.Get("db")
+	if !ok {
+		c.JSON(http.StatusInternalServerError, apiErrors.NewErrorResponse(http.StatusInternalServerError, "Database not available in context"))
+		return
+	}
+	db := dbAny.(*gorm.DB)
+
+	classSpec, err := strconv.ParseInt(c.Query("class_spec"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, apiErrors.NewErrorResponse(http.StatusBadRequest, "Missing or invalid query param: class_spec"))
+		return
+	}
+	topPercent := moduleDefaultTopPercent
+	if v, err := strconv.Atoi(c.Query("top_percent")); err == nil && v > 0 && v <= 100 {
+		topPercent = v
+	}
+
+	where, args := parseStatsFilters(c, 0).playerWhere()
+	where += " AND a.class_spec = ?"
+	args = append(args, classSpec)
+
+	rows, err := db.Raw(`
+		SELECT p.dps, b.module_levels
+		FROM (`+recentDPSSQL(where)+`) p
+		JOIN character_builds b ON b.player_id = p.actor_id
+		WHERE b.module_levels IS NOT NULL`, args...).Rows()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, apiErrors.NewErrorResponse(http.StatusInternalServerError, "Failed to load character data", err.Error()))
+		return
+	}
+	defer rows.Close()
+
+	var chars []characterModules
+	for rows.Next() {
+		var dps float64
+		var raw []byte
+		if err := rows.Scan(&dps, &raw); err != nil {
+			continue
+		}
+		cm := characterModules{dps: dps}
+		if err := json.Unmarshal(raw, &cm.levels); err != nil {
+			continue
+		}
+		for id, level := range cm.levels {
+			if level < 1 || level > moduleMaxLevel {
+				delete(cm.levels, id)
+			}
+		}
+		chars = append(chars, cm)
+	}
+	if err := rows.Err(); err != nil {
+		c.JSON(http.StatusInternalServerError, apiErrors.NewErrorResponse(http.StatusInternalServerError, "Failed to load character data", err.Error()))
+		return
+	}
+
+	resp := gin.H{"class_spec": classSpec, "characters": len(chars), "top_characters": 0, "attributes": []ModuleAttributeStat{}}
+	if len(chars) < moduleMinCharacters {
+		c.JSON(http.StatusOK, resp)
+		return
+	}
+
+	resp["top_characters"], resp["attributes"] = summarizeModules(chars, topPercent)
+
+	c.JSON(http.StatusOK, resp)
+}
+
+// summarizeModules aggregates the characters' module attributes, counting the best
+// topPercent of them by DPS (at least moduleMinCharacters) as the top group. chars must
+// hold at least moduleMinCharacters characters; it is reordered by DPS.
+func summarizeModules(chars []characterModules, topPercent int) (topN int, attrs []ModuleAttributeStat) {
+	sort.Slice(chars, func(i, j int) bool { return chars[i].dps > chars[j].dps })
+	topN = int(math.Ceil(float64(len(chars)) * float64(topPercent) / 100))
+	if topN < moduleMinCharacters {
+		topN = moduleMinCharacters
+	}
+
+	attrIDs := map[int]bool{}
+	for _, ch := range chars {
+		for id := range ch.levels {
+			attrIDs[id] = true
+		}
+	}
+
+	dps := make([]float64, len(chars))
+	for i, ch := range chars {
+		dps[i] = ch.dps
+	}
+
+	attrs = make([]ModuleAttributeStat, 0, len(attrIDs))
+	for id := range attrIDs {
+		name := module_optimizer.GetAttributeName(id)
+		s := ModuleAttributeStat{AttrID: id, Name: name, Type: module_optimizer.GetAttributeType(name), LevelsTop: make([]int64, moduleMaxLevel)}
+
+		levels := make([]float64, len(chars))
+		counts := make([]int64, moduleMaxLevel+1)
+		sums := make([]float64, moduleMaxLevel+1)
+		usedAll, usedTop, levelSumTop := 0, 0, 0
+		for i, ch := range chars {
+			level := ch.levels[id]
+			levels[i] = float64(level)
+			counts[level]++
+			sums[level] += ch.dps
+			if level == 0 {
+				continue
+			}
+			usedAll++
+			if i < topN {
+				usedTop++
+				levelSumTop += level
+				s.LevelsTop[level-1]++
+			}
+		}
+		s.UsageAll = float64(usedAll) / float64(len(chars))
+		if usedTop >= moduleMinCharacters {
+			s.UsageTop = float64(usedTop) / float64(topN)
+			s.AvgLevelTop = float64(levelSumTop) / float64(usedTop)
+		}
+		for i, n := range s.LevelsTop {
+			if n < moduleMinCharacters {
+				s.LevelsTop[i] = 0
+			}
+		}
+		s.DPSCorrelation = lib.Pearson(levels, dps)
+		for level := 0; level <= moduleMaxLevel; level++ {
+			ls := ModuleLevelStat{Level: level, Characters: counts[level]}
+			if counts[level] >= moduleMinCharacters {
+				mean := sums[level] / float64(counts[level])
+				ls.MeanDPS = &mean
+			}
+			s.ByLevel = append(s.ByLevel, ls)
+		}
+		attrs = append(attrs, s)
+	}
+	sort.Slice(attrs, func(i, j int) bool {
+		if attrs[i].UsageTop != attrs[j].UsageTop {
+			return attrs[i].UsageTop > attrs[j].UsageTop
+		}
+		return attrs[i].AttrID < attrs[j].AttrID
+	})
+	return topN, attrs
+}
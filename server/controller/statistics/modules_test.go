@@ -0,0 +1,45 @@
+package statistics
+
+import "testing"
+
+func TestSummarizeModules_HidesSmallTopGroups(t *testing.T) {
+	// 20 characters by descending DPS: the best 3 run attribute 1 at level 6, the best 5
+	// attribute 2 at level 3, everyone attribute 3 at level 1
+	chars := make([]characterModules, 20)
+	for i := range chars {
+		levels := map[int]int{3: 1}
+		if i < 3 {
+			levels[1] = 6
+		}
+		if i < 5 {
+			levels[2] = 3
+		}
+		chars[i] = characterModules{dps: float64(1000 - i), levels: levels}
+	}
+
+	// 10% of 20 is 2, raised to the minimum group
+	topN, attrs := summarizeModules(chars, 10)
+	if topN != moduleMinCharacters {
+		t.Fatalf("Expected a top group of %d, got %d", moduleMinCharacters, topN)
+	}
+	byID := map[int]ModuleAttributeStat{}
+	for _, a := range attrs {
+		byID[a.AttrID] = a
+	}
+
+	rare := byID[1]
+	if rare.UsageTop != 0 || rare.AvgLevelTop != 0 || rare.LevelsTop[5] != 0 {
+		t.Errorf("Expected attribute 1's top figures to be hidden, got %+v", rare)
+	}
+	if rare.ByLevel[6].MeanDPS != nil {
+		t.Errorf("Expected no mean DPS for 3 characters, got %v", *rare.ByLevel[6].MeanDPS)
+	}
+
+	common := byID[2]
+	if common.UsageTop != 1 || common.AvgLevelTop != 3 || common.LevelsTop[2] != 5 {
+		t.Errorf("Expected attribute 2's top figures, got %+v", common)
+	}
+	if byID[3].UsageAll != 1 {
+		t.Errorf("Expected attribute 3 to be used by everyone, got %v", byID[3].UsageAll)
+	}
+}
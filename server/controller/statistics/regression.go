@@ -0,0 +1,239 @@
+package statistics
+
+import (
+	"fmt"
+	"math"
+	"net/http"
+	"strconv"
+
+	apiErrors "server/controller"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+const (
+	regressionDefaultMinSamples = 30
+	regressionBracketSize       = 1000
+)
+
+// RegressionCurvePoint is one ability-score bracket: the fitted DPS at its midpoint next to
+// the observed mean, so clients can see where the straight line stops fitting.
+type RegressionCurvePoint struct {
+	AbilityScore float64 `json:"ability_score"` // bracket midpoint
+	ExpectedDPS  float64 `json:"expected_dps"`
+	MeanDPS      float64 `json:"mean_dps"`
+	Samples      int64   `json:"samples"`
+}
+
+// ExpectedDPS is the fit evaluated at a requested ability score. Low and High span one
+// residual standard deviation, where roughly two thirds of players land.
+type ExpectedDPS struct {
+	AbilityScore int64   `json:"ability_score"`
+	DPS          float64 `json:"dps"`
+	Low          float64 `json:"low"`
+	High         float64 `json:"high"`
+}
+
+// ActualComparison compares a player's DPS with the expectation.
+type ActualComparison struct {
+	DPS      float64 `json:"dps"`
+	Residual float64 `json:"residual"` // actual - expected
+	ZScore   float64 `json:"z_score"`  // residual in residual standard deviations
+}
+
+// AbilityRegression is a least-squares fit of DPS on ability score for one spec.
+type AbilityRegression struct {
+	ClassSpec  int64                  `json:"class_spec"`
+	Samples    int64                  `json:"samples"`
+	Slope      float64                `json:"slope"` // DPS per ability-score point
+	Intercept  float64                `json:"intercept"`
+	R2         float64                `json:"r2"`
+	ResidualSD float64                `json:"residual_sd"`
+	AbilityMin int64                  `json:"ability_min"`
+	AbilityMax int64                  `json:"ability_max"`
+	Curve      []RegressionCurvePoint `json:"curve"`
+	Expected   *ExpectedDPS           `json:"expected,omitempty"`
+	Actual     *ActualComparison      `json:"actual,omitempty"`
+}
+
+func (r AbilityRegression) predict(abilityScore float64) float64 {
+	return r.Intercept + r.Slope*abilityScore
+}
+
+// regressionFit is one spec's row of regr_* aggregates.
+type regressionFit struct {
+	ClassSpec  int64    `gorm:"column:class_spec"`
+	N          int64    `gorm:"column:n"`
+	Slope      *float64 `gorm:"column:slope"`
+	Intercept  *float64 `gorm:"column:intercept"`
+	R2         *float64 `gorm:"column:r2"`
+	Syy        float64  `gorm:"column:syy"`
+	Sxy        float64  `gorm:"column:sxy"`
+	AbilityMin int64    `gorm:"column:ability_min"`
+	AbilityMax int64    `gorm:"column:ability_max"`
+}
+
+// newAbilityRegression builds a spec's regression from its aggregates, with the expected
+// DPS at abilityScore and the comparison with actualDPS when given. ok is false when every
+// sample has the same ability score, leaving no line to fit.
+func newAbilityRegression(fit regressionFit, abilityScore *int64, actualDPS *float64) (r AbilityRegression, ok bool) {
+	if fit.Slope == nil || fit.Intercept == nil {
+		return r, false
+	}
+	r = AbilityRegression{
+		ClassSpec:  fit.ClassSpec,
+		Samples:    fit.N,
+		Slope:      *fit.Slope,
+		Intercept:  *fit.Intercept,
+		AbilityMin: fit.AbilityMin,
+		AbilityMax: fit.AbilityMax,
+		Curve:      []RegressionCurvePoint{},
+	}
+	if fit.R2 != nil {
+		r.R2 = *fit.R2
+	}
+	// The residual variance is (Syy - slope*Sxy) / (n - 2)
+	if fit.N > 2 {
+		r.ResidualSD = math.Sqrt(math.Max(0, (fit.Syy-r.Slope*fit.Sxy)/float64(fit.N-2)))
+	}
+	if abilityScore != nil {
+		expected := r.predict(float64(*abilityScore))
+		r.Expected = &ExpectedDPS{AbilityScore: *abilityScore, DPS: expected, Low: expected - r.ResidualSD, High: expected + r.ResidualSD}
+		if actualDPS != nil {
+			cmp := &ActualComparison{DPS: *actualDPS, Residual: *actualDPS - expected}
+			if r.ResidualSD > 0 {
+				cmp.ZScore = cmp.Residual / r.ResidualSD
+			}
+			r.Actual = cmp
+		}
+	}
+	return r, true
+}
+
+// GetAbilityRegression fits DPS against ability score per class spec within one scene.
+// Query params:
+//   - scene_name or scene_id (one is required)
+//   - class_spec: int (only this spec)
+//   - ability_score: int (also return the expected DPS at this score)
+//   - dps: float (with ability_score, compare this actual DPS with the expectation)
+//   - min_samples: int (skip specs with fewer samples; default 30)
+//   - the other shared statistics filters (see statsFilters)
+//
+// Players without an ability score and encounters held for review are left out of the fit.
+func GetAbilityRegression(c *gin.Context) {
+	dbAny, ok := c.Get("db")
+	if !ok {
+		c.JSON(http.StatusInternalServerError, apiErrors.NewErrorResponse(http.StatusInternalServerError, "Database not available in context"))
+		return
+	}
+	db := dbAny.(*gorm.DB)
+
+	f := parseStatsFilters(c, 0)
+	if f.SceneName == "" && f.SceneID == nil {
+		c.JSON(http.StatusBadRequest, apiErrors.NewErrorResponse(http.StatusBadRequest, "Missing required query param: scene_name or scene_id"))
+		return
+	}
+	where, args := f.playerWhere()
+	where += " AND a.ability_score > 0"
+	if v := c.Query("class_spec"); v != "" {
+		n, err := strconv.ParseInt(v, 10, 64)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, apiErrors.NewErrorResponse(http.StatusBadRequest, "Invalid class_spec"))
+			return
+		}
+		where += " AND a.class_spec = ?"
+		args = append(args, n)
+	}
+	var abilityScore *int64
+	if v := c.Query("ability_score"); v != "" {
+		n, err := strconv.ParseInt(v, 10, 64)
+		if err != nil || n < 0 {
+			c.JSON(http.StatusBadRequest, apiErrors.NewErrorResponse(http.StatusBadRequest, "Invalid ability_score"))
+			return
+		}
+		abilityScore = &n
+	}
+	var actualDPS *float64
+	if v := c.Query("dps"); v != "" {
+		d, err := strconv.ParseFloat(v, 64)
+		if err != nil || d < 0 {
+			c.JSON(http.StatusBadRequest, apiErrors.NewErrorResponse(http.StatusBadRequest, "Invalid dps"))
+			return
+		}
+		actualDPS = &d
+	}
+	minSamples := int64(regressionDefaultMinSamples)
+	if v, err := strconv.ParseInt(c.Query("min_samples"), 10, 64); err == nil && v >= 3 {
+		minSamples = v
+	}
+
+	// regr_* aggregates give the ordinary least-squares fit
+	var fits []regressionFit
+	fitQ := fmt.Sprintf(`
+		SELECT COALESCE(a.class_spec, -1) AS class_spec,
+			   regr_count(a.dps, a.ability_score) AS n,
+			   regr_slope(a.dps, a.ability_score) AS slope,
+			   regr_intercept(a.dps, a.ability_score) AS intercept,
+			   regr_r2(a.dps, a.ability_score) AS r2,
+			   COALESCE(regr_syy(a.dps, a.ability_score), 0) AS syy,
+			   COALESCE(regr_sxy(a.dps, a.ability_score), 0) AS sxy,
+			   MIN(a.ability_score) AS ability_min,
+			   MAX(a.ability_score) AS ability_max
+		FROM actor_encounter_stats a
+		JOIN encounters e ON e.id = a.encounter_id
+		%s
+		GROUP BY 1
+		HAVING regr_count(a.dps, a.ability_score) >= ?
+		ORDER BY n DESC`, where)
+	if err := db.Raw(fitQ, append(args, minSamples)...).Scan(&fits).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, apiErrors.NewErrorResponse(http.StatusInternalServerError, "Failed to fit ability regression", err.Error()))
+		return
+	}
+
+	var brackets []struct {
+		ClassSpec int64   `gorm:"column:class_spec"`
+		Bracket   int64   `gorm:"column:bracket"`
+		Samples   int64   `gorm:"column:samples"`
+		MeanDPS   float64 `gorm:"column:mean_dps"`
+	}
+	bracketQ := fmt.Sprintf(`
+		SELECT COALESCE(a.class_spec, -1) AS class_spec,
+			   (FLOOR(a.ability_score::double precision / %[1]d) * %[1]d)::bigint AS bracket,
+			   COUNT(*) AS samples, AVG(a.dps) AS mean_dps
+		FROM actor_encounter_stats a
+		JOIN encounters e ON e.id = a.encounter_id
+		%[2]s
+		GROUP BY 1, 2
+		ORDER BY 1, 2`, regressionBracketSize, where)
+	if err := db.Raw(bracketQ, args...).Scan(&brackets).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, apiErrors.NewErrorResponse(http.StatusInternalServerError, "Failed to compute ability curve", err.Error()))
+		return
+	}
+
+	out := make([]AbilityRegression, 0, len(fits))
+	bySpec := make(map[int64]int, len(fits))
+	for _, fit := range fits {
+		r, ok := newAbilityRegression(fit, abilityScore, actualDPS)
+		if !ok {
+			continue
+		}
+		bySpec[r.ClassSpec] = len(out)
+		out = append(out, r)
+	}
+	for _, b := range brackets {
+		i, ok := bySpec[b.ClassSpec]
+		if !ok {
+			continue
+		}
+		mid := float64(b.Bracket) + regressionBracketSize/2
+		out[i].Curve = append(out[i].Curve, RegressionCurvePoint{
+			AbilityScore: mid,
+			ExpectedDPS:  out[i].predict(mid),
+			MeanDPS:      b.MeanDPS,
+			Samples:      b.Samples,
+		})
+	}
+
+	c.JSON(http.StatusOK, gin.H{"scene_name": f.SceneName, "scene_id": f.SceneID, "fits": out})
+}
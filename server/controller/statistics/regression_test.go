@@ -0,0 +1,86 @@
+package statistics
+
+import (
+	"math"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestGetAbilityRegression_RejectsInvalidParams(t *testing.T) {
+	for _, query := range []string{
+		"class_spec=2",
+		"scene_id=1&class_spec=healer",
+		"scene_id=1&ability_score=-1",
+		"scene_id=1&ability_score=high",
+		"scene_id=1&ability_score=2000&dps=-5",
+		"scene_id=1&ability_score=2000&dps=fast",
+	} {
+		w, stmts := statsRequest(t, GetAbilityRegression, "/api/v1/statistics/regression?"+query)
+		if w.Code != http.StatusBadRequest {
+			t.Errorf("%s: expected 400, got %d", query, w.Code)
+		}
+		if got := stmts.All(); len(got) != 0 {
+			t.Errorf("%s: expected no queries, got %v", query, got)
+		}
+	}
+}
+
+func TestGetAbilityRegression_FitsListedPlayersWithScores(t *testing.T) {
+	cases := map[string]string{
+		"scene_id=1&class_spec=2":                 ">= 30",
+		"scene_id=1&class_spec=2&min_samples=100": ">= 100",
+		// Too few samples for a residual spread; the default applies
+		"scene_id=1&class_spec=2&min_samples=2": ">= 30",
+	}
+	for query, having := range cases {
+		_, stmts := statsRequest(t, GetAbilityRegression, "/api/v1/statistics/regression?"+query)
+		fits := stmts.Matching("regr_slope")
+		if len(fits) != 1 {
+			t.Fatalf("%s: expected one fit query, got %v", query, stmts.All())
+		}
+		for _, want := range []string{
+			"e.held = false",
+			"e.scene_id = 1",
+			"a.ability_score > 0",
+			"a.class_spec = 2",
+			"HAVING regr_count(a.dps, a.ability_score) " + having,
+		} {
+			if !strings.Contains(fits[0], want) {
+				t.Errorf("%s: expected the fit to contain %q, got %s", query, want, fits[0])
+			}
+		}
+	}
+}
+
+func TestNewAbilityRegression_ComparesWithTheFit(t *testing.T) {
+	// DPS 100, 210 and 290 at ability scores 1000, 2000 and 3000
+	slope, intercept, r2 := 0.095, 10.0, 190000.0*190000.0/(2e6*18200)
+	fit := regressionFit{ClassSpec: 2, N: 3, Slope: &slope, Intercept: &intercept, R2: &r2, Syy: 18200, Sxy: 190000, AbilityMin: 1000, AbilityMax: 3000}
+	score, actual := int64(2500), 260.0
+	r, ok := newAbilityRegression(fit, &score, &actual)
+	if !ok {
+		t.Fatal("Expected a fit")
+	}
+	near := func(got, want float64) bool { return math.Abs(got-want) < 1e-9 }
+	sd := math.Sqrt(150)
+	if !near(r.ResidualSD, sd) {
+		t.Errorf("Expected a residual spread of %v, got %v", sd, r.ResidualSD)
+	}
+	if r.Expected == nil || !near(r.Expected.DPS, 247.5) || !near(r.Expected.Low, 247.5-sd) || !near(r.Expected.High, 247.5+sd) {
+		t.Errorf("Expected 247.5 DPS ± %v at 2500, got %+v", sd, r.Expected)
+	}
+	if r.Actual == nil || !near(r.Actual.Residual, 12.5) || !near(r.Actual.ZScore, 12.5/sd) {
+		t.Errorf("Expected 12.5 DPS above the fit, got %+v", r.Actual)
+	}
+
+	// Two samples fit a line exactly, with no spread to compare against
+	fit.N = 2
+	if r, _ := newAbilityRegression(fit, &score, &actual); r.ResidualSD != 0 || r.Actual.ZScore != 0 {
+		t.Errorf("Expected no spread or z-score, got %+v", r)
+	}
+	// Every sample at one ability score
+	if _, ok := newAbilityRegression(regressionFit{N: 5}, nil, nil); ok {
+		t.Error("Expected no fit without a slope")
+	}
+}
@@ -0,0 +1,150 @@
+package statistics
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"server/lib"
+	"server/services/rollups"
+
+	"gorm.io/datatypes"
+	"gorm.io/gorm"
+)
+
+// specDay holds one class spec's metric distributions for one UTC day.
+type specDay struct {
+	ClassSpec int64
+	Day       string // YYYY-MM-DD
+	DPS       lib.Distribution
+	HPS       lib.Distribution
+	BossDPS   lib.Distribution
+}
+
+// metric returns the distribution of dps, hps or boss_dps.
+func (d *specDay) metric(name string) *lib.Distribution {
+	switch name {
+	case "hps":
+		return &d.HPS
+	case "boss_dps":
+		return &d.BossDPS
+	}
+	return &d.DPS
+}
+
+// merge adds another spec-day's samples.
+func (d *specDay) merge(o specDay) {
+	d.DPS.Merge(o.DPS)
+	d.HPS.Merge(o.HPS)
+	d.BossDPS.Merge(o.BossDPS)
+}
+
+// rollupRow is a stat_rollups row as read by rollupDistributions.
+type rollupRow struct {
+	ClassSpec     int64          `gorm:"column:class_spec"`
+	Day           string         `gorm:"column:day"`
+	DPSValues     datatypes.JSON `gorm:"column:dps_values"`
+	HPSValues     datatypes.JSON `gorm:"column:hps_values"`
+	BossDPSValues datatypes.JSON `gorm:"column:boss_dps_values"`
+}
+
+func (r rollupRow) specDay() (specDay, error) {
+	d := specDay{ClassSpec: r.ClassSpec, Day: r.Day}
+	if err := json.Unmarshal(r.DPSValues, &d.DPS); err != nil {
+		return d, err
+	}
+	if err := json.Unmarshal(r.HPSValues, &d.HPS); err != nil {
+		return d, err
+	}
+	if err := json.Unmarshal(r.BossDPSValues, &d.BossDPS); err != nil {
+		return d, err
+	}
+	return d, nil
+}
+
+// rollupDistributions reads the per-spec, per-day distributions matching the filters from
+// the rollups. ok is false when the rollups can't answer the filters or aren't ready, in
+// which case callers use the live samples from classSamples. The distributions hold the
+// same values as the live samples, so every statistic read from them matches the live
+// query.
+func rollupDistributions(db *gorm.DB, f statsFilters) (days []specDay, ok bool, err error) {
+	rollupWhere, rollupArgs, ok := f.rollupWhere()
+	if !ok || !rollups.Ready() {
+		return nil, false, nil
+	}
+
+	cutoffDay := ""
+	if f.SinceDays > 0 {
+		// Whole days after the cutoff come from rollups; the cutoff day itself is partially
+		// included, so read it live.
+		cutoffDay = fmt.Sprintf("((NOW() - INTERVAL '%d days') AT TIME ZONE 'UTC')::date", f.SinceDays)
+		rollupWhere += " AND r.day > " + cutoffDay
+	}
+
+	var rows []rollupRow
+	if err := db.Raw(`
+		SELECT r.class_spec, to_char(r.day, 'YYYY-MM-DD') AS day, r.dps_values, r.hps_values, r.boss_dps_values
+		FROM stat_rollups r `+rollupWhere, rollupArgs...).Scan(&rows).Error; err != nil {
+		return nil, true, fmt.Errorf("failed to read rollups: %w", err)
+	}
+
+	// Rows of the same spec and day (other scenes, patches, brackets) merge into one
+	index := make(map[string]int)
+	add := func(d specDay) {
+		key := fmt.Sprintf("%d|%s", d.ClassSpec, d.Day)
+		if i, seen := index[key]; seen {
+			days[i].merge(d)
+			return
+		}
+		index[key] = len(days)
+		days = append(days, d)
+	}
+	for _, r := range rows {
+		d, err := r.specDay()
+		if err != nil {
+			return nil, true, err
+		}
+		add(d)
+	}
+
+	if cutoffDay != "" {
+		samplesQuery, samplesArgs := classSamples(f)
+		var samples []struct {
+			ClassSpec int64   `gorm:"column:class_spec"`
+			Day       string  `gorm:"column:day"`
+			DPS       float64 `gorm:"column:dps"`
+			HPS       float64 `gorm:"column:hps"`
+			BossDPS   float64 `gorm:"column:boss_dps"`
+		}
+		if err := db.Raw(`
+			SELECT s.class_spec, to_char(s.day, 'YYYY-MM-DD') AS day, s.dps, s.hps, s.boss_dps
+			FROM (`+samplesQuery+`) s
+			WHERE s.day = `+cutoffDay, samplesArgs...).Scan(&samples).Error; err != nil {
+			return nil, true, fmt.Errorf("failed to read cutoff day: %w", err)
+		}
+		// Collected per spec-day first, so each distribution is sorted once
+		type values struct {
+			spec              int64
+			day               string
+			dps, hps, bossDPS []float64
+		}
+		var live []*values
+		byKey := make(map[string]*values)
+		for _, s := range samples {
+			key := fmt.Sprintf("%d|%s", s.ClassSpec, s.Day)
+			v, seen := byKey[key]
+			if !seen {
+				v = &values{spec: s.ClassSpec, day: s.Day}
+				byKey[key] = v
+				live = append(live, v)
+			}
+			v.dps = append(v.dps, s.DPS)
+			v.hps = append(v.hps, s.HPS)
+			v.bossDPS = append(v.bossDPS, s.BossDPS)
+		}
+		for _, v := range live {
+			add(specDay{ClassSpec: v.spec, Day: v.day,
+				DPS: lib.NewDistribution(v.dps...), HPS: lib.NewDistribution(v.hps...), BossDPS: lib.NewDistribution(v.bossDPS...)})
+		}
+	}
+	return days, true, nil
+}
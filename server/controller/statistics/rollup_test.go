@@ -0,0 +1,131 @@
+package statistics
+
+import (
+	"fmt"
+	"math"
+	"math/rand"
+	"sort"
+	"testing"
+)
+
+// liveSpec mirrors the live GetClassStats query over one spec's samples: percentile_cont
+// quantiles and the median CI read at row_number ranks.
+type liveSpec struct {
+	count          int64
+	mean, min, max float64
+	q1, median, q3 float64
+	ciLow, ciHigh  float64
+}
+
+func liveStats(samples []float64) liveSpec {
+	sorted := append([]float64(nil), samples...)
+	sort.Float64s(sorted)
+	quantile := func(q float64) float64 {
+		pos := q * float64(len(sorted)-1)
+		lo := math.Floor(pos)
+		v := sorted[int(lo)]
+		if frac := pos - lo; frac > 0 {
+			v += frac * (sorted[int(lo)+1] - v)
+		}
+		return v
+	}
+	sum := 0.0
+	for _, v := range sorted {
+		sum += v
+	}
+	n := int64(len(sorted))
+	low, high := medianCIRanks(n)
+	return liveSpec{
+		count: n, mean: sum / float64(n), min: sorted[0], max: sorted[n-1],
+		q1: quantile(0.25), median: quantile(0.5), q3: quantile(0.75),
+		ciLow: sorted[low-1], ciHigh: sorted[high-1],
+	}
+}
+
+func TestClassStatsFromRollups_MatchesLiveQuery(t *testing.T) {
+	r := rand.New(rand.NewSource(7))
+	specs := map[int64]float64{1: 9, 2: 10, 3: 8.5}
+	live := make(map[int64][]float64)
+	var days []specDay
+	for spec, mu := range specs {
+		for day := 0; day < 5; day++ {
+			// Rollup rows are per day (and scene, patch, bracket); several per spec-day
+			for part := 0; part < 3; part++ {
+				d := specDay{ClassSpec: spec, Day: fmt.Sprintf("2025-06-%02d", day+1)}
+				for i := 0; i < 50+r.Intn(100); i++ {
+					v := math.Exp(r.NormFloat64()*0.6 + mu)
+					d.DPS.Add(v)
+					d.HPS.Add(v / 10)
+					d.BossDPS.Add(v * 0.9)
+					live[spec] = append(live[spec], v)
+				}
+				days = append(days, d)
+			}
+		}
+	}
+
+	out := classStatsFromRollups(days, 1)
+	if len(out) != len(specs) {
+		t.Fatalf("Expected %d specs, got %d", len(specs), len(out))
+	}
+	for i := 1; i < len(out); i++ {
+		if out[i-1].Count < out[i].Count {
+			t.Errorf("Expected specs ordered by count, got %d before %d", out[i-1].Count, out[i].Count)
+		}
+	}
+	for _, got := range out {
+		want := liveStats(live[got.ClassSpec])
+		if got.Count != want.count || got.DpsMin != want.min || got.DpsMax != want.max {
+			t.Errorf("Spec %d: count/min/max %d/%v/%v, want %d/%v/%v", got.ClassSpec, got.Count, got.DpsMin, got.DpsMax, want.count, want.min, want.max)
+		}
+		if math.Abs(got.AvgDPS-want.mean) > 1e-9*want.mean {
+			t.Errorf("Spec %d: mean %v, want %v", got.ClassSpec, got.AvgDPS, want.mean)
+		}
+		checks := []struct {
+			name      string
+			got, want float64
+		}{
+			{"q1", got.DpsQ1, want.q1},
+			{"median", got.DpsMedian, want.median},
+			{"q3", got.DpsQ3, want.q3},
+			{"median CI low", got.DpsMedianCI[0], want.ciLow},
+			{"median CI high", got.DpsMedianCI[1], want.ciHigh},
+		}
+		for _, c := range checks {
+			if c.got != c.want {
+				t.Errorf("Spec %d: %s %v, want %v", got.ClassSpec, c.name, c.got, c.want)
+			}
+		}
+	}
+
+	if out := classStatsFromRollups(days, 1e6); len(out) != 0 {
+		t.Errorf("Expected min_samples to drop every spec, got %d", len(out))
+	}
+}
+
+func TestTrendFromRollups_BucketsByISOWeek(t *testing.T) {
+	var days []specDay
+	for _, day := range []string{"2025-06-08", "2025-06-09", "2025-06-11", "2025-06-15"} {
+		d := specDay{ClassSpec: 4, Day: day}
+		d.DPS.Add(100)
+		d.HPS.Add(0)
+		days = append(days, d)
+	}
+	other := specDay{ClassSpec: 5, Day: "2025-06-10"}
+	other.DPS.Add(1)
+	days = append(days, other)
+
+	spec := int64(4)
+	series := trendFromRollups(days, "week", &spec)
+	if len(series) != 1 || series[0].ClassSpec != 4 {
+		t.Fatalf("Expected only spec 4, got %+v", series)
+	}
+	points := series[0].Points
+	if len(points) != 2 || points[0].Start != "2025-06-02" || points[0].Count != 1 || points[1].Start != "2025-06-09" || points[1].Count != 3 {
+		t.Errorf("Expected weeks of 2025-06-02 (1) and 2025-06-09 (3), got %+v", points)
+	}
+
+	if series := trendFromRollups(days, "day", nil); len(series) != 2 || len(series[0].Points) != 4 {
+		t.Errorf("Expected daily points for both specs, got %+v", series)
+	}
+}
@@ -1,17 +1,41 @@
 package statistics
 
 import (
+	"math"
 	"net/http"
+	"sort"
+	"strings"
 
 	"github.com/gin-gonic/gin"
 	"gorm.io/gorm"
 
 	"fmt"
-	"server/models"
+	apiErrors "server/controller"
+	"server/services/rollups"
+	"server/services/scenes"
 	"strconv"
-	"strings"
 )
 
+// latestActorStatsSQL selects the newest player row per actor (the live equivalent of
+// stat_actor_latest). %s is the WHERE clause over a and e.
+const latestActorStatsSQL = `
+	SELECT DISTINCT ON (a.actor_id) a.actor_id, a.class_id, a.class_spec, a.ability_score
+	FROM actor_encounter_stats a
+	JOIN encounters e ON e.id = a.encounter_id
+	%s
+	ORDER BY a.actor_id, a.id DESC`
+
+// latestActors returns a source (aliased l) holding each actor's newest player row among
+// the rows matching the filters. Unfiltered, it reads stat_actor_latest once rollups are
+// built instead of a DISTINCT ON scan.
+func latestActors(f statsFilters) (string, []interface{}) {
+	if f.IsZero() && rollups.Ready() {
+		return "stat_actor_latest l", nil
+	}
+	where, args := f.playerWhere()
+	return "(" + fmt.Sprintf(latestActorStatsSQL, where) + ") l", args
+}
+
 // OverviewResponse represents aggregate statistics over all encounters.
 type OverviewResponse struct {
 	TotalDamage   int64   `json:"total_damage"`
@@ -22,6 +46,8 @@ type OverviewResponse struct {
 }
 
 // GetOverview computes totals across encounters: damage, duration, healing, row count, and total players.
+// Query params: the shared statistics filters (see statsFilters). Ability-score filters keep
+// encounters with at least one player in range, and count only those players.
 func GetOverview(c *gin.Context) {
 	dbAny, ok := c.Get("db")
 	if !ok {
@@ -42,9 +68,18 @@ func GetOverview(c *gin.Context) {
 		Count         int64   `gorm:"column:encounter_count"`
 	}
 
-	if err := db.Model(&models.Encounter{}).
-		Select("COALESCE(SUM(total_dmg),0) AS total_dmg, COALESCE(SUM(duration),0) AS total_duration, COALESCE(SUM(total_heal),0) AS total_heal, COUNT(*) AS encounter_count").
-		Scan(&totals).Error; err != nil {
+	f := parseStatsFilters(c, 0)
+	encConds, encArgs := f.encounterConds()
+	if playerConds, playerArgs := f.playerConds(); playerConds != "" {
+		encConds += " AND EXISTS (SELECT 1 FROM actor_encounter_stats a WHERE a.encounter_id = e.id AND a.is_player = true" + playerConds + ")"
+		encArgs = append(encArgs, playerArgs...)
+	}
+	totalsQ := `
+		SELECT COALESCE(SUM(e.total_dmg),0) AS total_dmg, COALESCE(SUM(e.duration),0) AS total_duration,
+			   COALESCE(SUM(e.total_heal),0) AS total_heal, COUNT(*) AS encounter_count
+		FROM encounters e
+		WHERE TRUE` + encConds
+	if err := db.Raw(totalsQ, encArgs...).Scan(&totals).Error; err != nil {
 		// On query failure, respond with zeros to avoid breaking landing page
 		c.JSON(http.StatusOK, OverviewResponse{TotalDamage: 0, TotalDuration: 0, TotalHealing: 0, Encounters: 0, TotalPlayers: 0})
 		return
@@ -54,16 +89,8 @@ func GetOverview(c *gin.Context) {
 	var playerCount struct {
 		Total int64 `gorm:"column:total"`
 	}
-	playerCountQ := `
-		SELECT COUNT(*) AS total FROM (
-			SELECT DISTINCT ON (a.actor_id) a.actor_id
-			FROM actor_encounter_stats a
-			JOIN encounters e ON e.id = a.encounter_id
-			WHERE a.is_player = true
-			ORDER BY a.actor_id, a.id DESC
-		) t
-	`
-	if err := db.Raw(playerCountQ).Scan(&playerCount).Error; err != nil {
+	latest, latestArgs := latestActors(f)
+	if err := db.Raw("SELECT COUNT(*) AS total FROM "+latest, latestArgs...).Scan(&playerCount).Error; err != nil {
 		// If player count fails, set to 0 but still return other stats
 		playerCount.Total = 0
 	}
@@ -78,9 +105,13 @@ func GetOverview(c *gin.Context) {
 	c.JSON(http.StatusOK, resp)
 }
 
-// ClassStatsResponse contains aggregated statistics per class spec
+// ClassStatsResponse contains aggregated statistics per class spec, or per boss and class
+// spec with group_by=boss.
 type ClassStatsResponse struct {
 	ClassSpec int64   `json:"class_spec"`
+	BossKey   string  `json:"boss_key,omitempty"`   // group_by=boss: monster ID, or "name:<lowercased name>" without one
+	MonsterID *int64  `json:"monster_id,omitempty"` // group_by=boss
+	Boss      *string `json:"boss,omitempty"`       // group_by=boss: a name the boss was uploaded with
 	Count     int64   `json:"count"`
 	AvgDPS    float64 `json:"avg_dps"`
 	DpsQ1     float64 `json:"dps_q1"`
@@ -89,13 +120,27 @@ type ClassStatsResponse struct {
 	DpsMin    float64 `json:"dps_min"`
 	DpsMax    float64 `json:"dps_max"`
 
-	AvgHPS    float64   `json:"avg_hps"`
-	HpsQ1     float64   `json:"hps_q1"`
-	HpsMedian float64   `json:"hps_median"`
-	HpsQ3     float64   `json:"hps_q3"`
-	HpsMin    float64   `json:"hps_min"`
-	HpsMax    float64   `json:"hps_max"`
-	Outliers  []Outlier `json:"outliers"`
+	AvgHPS    float64 `json:"avg_hps"`
+	HpsQ1     float64 `json:"hps_q1"`
+	HpsMedian float64 `json:"hps_median"`
+	HpsQ3     float64 `json:"hps_q3"`
+	HpsMin    float64 `json:"hps_min"`
+	HpsMax    float64 `json:"hps_max"`
+
+	// Boss DPS uses boss-active time (boss phases only) rather than the full encounter duration
+	AvgBossDPS    float64 `json:"avg_boss_dps"`
+	BossDpsQ1     float64 `json:"boss_dps_q1"`
+	BossDpsMedian float64 `json:"boss_dps_median"`
+	BossDpsQ3     float64 `json:"boss_dps_q3"`
+	BossDpsMin    float64 `json:"boss_dps_min"`
+	BossDpsMax    float64 `json:"boss_dps_max"`
+
+	// 95% confidence intervals [low, high] on the medians
+	DpsMedianCI     [2]float64 `json:"dps_median_ci"`
+	HpsMedianCI     [2]float64 `json:"hps_median_ci"`
+	BossDpsMedianCI [2]float64 `json:"boss_dps_median_ci"`
+
+	Outliers []Outlier `json:"outliers"`
 }
 
 // Outlier represents a single outlier point for a class
@@ -105,97 +150,167 @@ type Outlier struct {
 	Value       float64 `json:"value"`
 }
 
-// GetClassStats aggregates DPS/HPS distributions per class_spec with optional filters.
-// Query params:
-//   - since_days: int (include encounters started within last N days)
-//   - min_duration: float seconds (minimum encounter duration)
-//   - max_duration: float seconds (maximum encounter duration)
-//   - scene_name: string (filter encounters by scene_name)
-//   - min_ability_score: int (minimum ability score)
-//   - max_ability_score: int (maximum ability score)
-func GetClassStats(c *gin.Context) {
-	dbAny, ok := c.Get("db")
-	if !ok {
-		c.JSON(http.StatusOK, gin.H{"classes": []ClassStatsResponse{}})
-		return
-	}
-	db, ok := dbAny.(*gorm.DB)
-	if !ok {
-		c.JSON(http.StatusOK, gin.H{"classes": []ClassStatsResponse{}})
-		return
-	}
+// Ranks (1-based, within a spec ordered by value) bounding a distribution-free 95%
+// confidence interval for the median of n samples: the binomial order-statistic interval
+// n/2 ± 1.96·√n/2. Small samples get wide intervals, up to the full min..max range.
+const (
+	medianCILowRankSQL  = "GREATEST(1, FLOOR(s.n / 2.0 - 0.98 * SQRT(s.n)))"
+	medianCIHighRankSQL = "LEAST(s.n, CEIL(1 + s.n / 2.0 + 0.98 * SQRT(s.n)))"
+)
 
-	// Parse filters
-	sinceDaysStr := c.Query("since_days")
-	minDurStr := c.Query("min_duration")
-	maxDurStr := c.Query("max_duration")
-	sceneName := c.Query("scene_name")
-	minAbilityScoreStr := c.Query("min_ability_score")
-	maxAbilityScoreStr := c.Query("max_ability_score")
+// classSamples builds the live per-player samples query for the given filters, returning
+// one row per player of a listed encounter with class_spec, encounter_id, day (UTC), dps,
+// hps and boss_dps. Endpoints read rollupDistributions instead when it can answer.
+func classSamples(f statsFilters) (string, []interface{}) {
+	where, args := f.playerWhere()
+	return fmt.Sprintf(`
+		SELECT COALESCE(a.class_spec, -1) AS class_spec, a.encounter_id, %s AS day,
+			   %s AS dps, %s AS hps, %s AS boss_dps
+		FROM actor_encounter_stats a
+		JOIN encounters e ON e.id = a.encounter_id
+		%s`, rollups.DaySQL, rollups.SampleDPSSQL, rollups.SampleHPSSQL, rollups.SampleBossDPSSQL, where), args
+}
 
-	where := "WHERE a.is_player = true"
-	var args []interface{}
+// medianCIRanks returns the ranks bounding the median confidence interval of n samples,
+// as medianCILowRankSQL and medianCIHighRankSQL do.
+func medianCIRanks(n int64) (low, high int64) {
+	root := math.Sqrt(float64(n))
+	low = int64(math.Max(1, math.Floor(float64(n)/2-0.98*root)))
+	high = int64(math.Min(float64(n), math.Ceil(1+float64(n)/2+0.98*root)))
+	return low, high
+}
 
-	if sinceDaysStr != "" {
-		if days, err := strconv.Atoi(sinceDaysStr); err == nil && days > 0 {
-			// embed literal days into interval; safe due to Atoi and positive check
-			where += fmt.Sprintf(" AND e.started_at >= NOW() - INTERVAL '%d days'", days)
+// classStatsFromRollups summarises per-spec distributions read from the rollups the way
+// the live GetClassStats query summarises samples, omitting specs with fewer than
+// minSamples samples. Specs are ordered by sample count, largest first.
+func classStatsFromRollups(days []specDay, minSamples int64) []ClassStatsResponse {
+	var specs []specDay
+	index := make(map[int64]int)
+	for _, d := range days {
+		if i, ok := index[d.ClassSpec]; ok {
+			specs[i].merge(d)
+			continue
 		}
+		index[d.ClassSpec] = len(specs)
+		specs = append(specs, specDay{ClassSpec: d.ClassSpec})
+		specs[len(specs)-1].merge(d)
 	}
-	if minDurStr != "" {
-		if v, err := strconv.ParseFloat(minDurStr, 64); err == nil {
-			where += " AND e.duration >= ?"
-			args = append(args, v)
-		}
-	}
-	if maxDurStr != "" {
-		if v, err := strconv.ParseFloat(maxDurStr, 64); err == nil {
-			where += " AND e.duration <= ?"
-			args = append(args, v)
-		}
-	}
-	if sceneName != "" {
-		where += " AND e.scene_name = ?"
-		args = append(args, sceneName)
-	}
-	if minAbilityScoreStr != "" {
-		if v, err := strconv.ParseInt(minAbilityScoreStr, 10, 64); err == nil {
-			where += " AND a.ability_score >= ?"
-			args = append(args, v)
+
+	out := make([]ClassStatsResponse, 0, len(specs))
+	for _, s := range specs {
+		n := s.DPS.Count()
+		if n < minSamples {
+			continue
 		}
+		low, high := medianCIRanks(n)
+		out = append(out, ClassStatsResponse{
+			ClassSpec: s.ClassSpec,
+			Count:     n,
+			AvgDPS:    s.DPS.Mean(),
+			DpsQ1:     s.DPS.Quantile(0.25),
+			DpsMedian: s.DPS.Quantile(0.5),
+			DpsQ3:     s.DPS.Quantile(0.75),
+			DpsMin:    s.DPS.Min(),
+			DpsMax:    s.DPS.Max(),
+			AvgHPS:    s.HPS.Mean(),
+			HpsQ1:     s.HPS.Quantile(0.25),
+			HpsMedian: s.HPS.Quantile(0.5),
+			HpsQ3:     s.HPS.Quantile(0.75),
+			HpsMin:    s.HPS.Min(),
+			HpsMax:    s.HPS.Max(),
+
+			AvgBossDPS:    s.BossDPS.Mean(),
+			BossDpsQ1:     s.BossDPS.Quantile(0.25),
+			BossDpsMedian: s.BossDPS.Quantile(0.5),
+			BossDpsQ3:     s.BossDPS.Quantile(0.75),
+			BossDpsMin:    s.BossDPS.Min(),
+			BossDpsMax:    s.BossDPS.Max(),
+
+			DpsMedianCI:     [2]float64{s.DPS.ValueAtRank(low), s.DPS.ValueAtRank(high)},
+			HpsMedianCI:     [2]float64{s.HPS.ValueAtRank(low), s.HPS.ValueAtRank(high)},
+			BossDpsMedianCI: [2]float64{s.BossDPS.ValueAtRank(low), s.BossDPS.ValueAtRank(high)},
+		})
 	}
-	if maxAbilityScoreStr != "" {
-		if v, err := strconv.ParseInt(maxAbilityScoreStr, 10, 64); err == nil {
-			where += " AND a.ability_score <= ?"
-			args = append(args, v)
-		}
+	sort.SliceStable(out, func(i, j int) bool { return out[i].Count > out[j].Count })
+	return out
+}
+
+// bossSamples is classSamples with one row per player and boss of their encounter, adding
+// boss_key, monster_id and boss (a name the boss was uploaded with).
+func bossSamples(f statsFilters) (string, []interface{}) {
+	samplesQuery, args := classSamples(f)
+	bossWhere := ""
+	if f.Boss != "" {
+		bossSQL, bossArgs := scenes.BossFilter("b", f.Boss)
+		bossWhere = " AND " + bossSQL
+		args = append(args, bossArgs...)
+	}
+	return `
+		SELECT s.*, bk.boss_key, bk.monster_id, bk.boss
+		FROM (` + samplesQuery + `) s
+		JOIN LATERAL (
+			SELECT COALESCE(b.monster_id::text, 'name:' || LOWER(b.monster_name)) AS boss_key,
+				   MAX(b.monster_id) AS monster_id, MAX(b.monster_name) AS boss
+			FROM encounter_bosses b
+			WHERE b.encounter_id = s.encounter_id` + bossWhere + `
+			GROUP BY 1
+		) bk ON true`, args
+}
+
+// liveClassStats is GetClassStats over the live samples, for filters the rollups can't
+// answer.
+func liveClassStats(db *gorm.DB, samplesQuery string, samplesArgs []interface{}, minSamples int64, byBoss bool) ([]ClassStatsResponse, error) {
+	group, bossCols := "s.class_spec", "NULL::text AS boss_key, NULL::bigint AS monster_id, NULL::text AS boss"
+	if byBoss {
+		group, bossCols = "s.boss_key, s.class_spec", "s.boss_key, MAX(s.monster_id) AS monster_id, MAX(s.boss) AS boss"
 	}
 
 	// Build query. COALESCE used to avoid nulls in results.
 	query := fmt.Sprintf(`
-		SELECT COALESCE(a.class_spec, -1) AS class_spec,
+		SELECT s.class_spec AS class_spec, %[4]s,
 			   COUNT(*) AS cnt,
-			   COALESCE(AVG(a.dps), 0) AS avg_dps,
-			   COALESCE(percentile_cont(0.25) WITHIN GROUP (ORDER BY a.dps), 0) AS dps_q1,
-			   COALESCE(percentile_cont(0.5)  WITHIN GROUP (ORDER BY a.dps), 0) AS dps_median,
-			   COALESCE(percentile_cont(0.75) WITHIN GROUP (ORDER BY a.dps), 0) AS dps_q3,
-			   COALESCE(MIN(a.dps), 0) AS dps_min,
-			   COALESCE(MAX(a.dps), 0) AS dps_max,
-			   COALESCE(AVG(CASE WHEN a.duration > 0 THEN a.heal_dealt::double precision / NULLIF(a.duration,0) ELSE 0 END), 0) AS avg_hps,
-			   COALESCE(percentile_cont(0.25) WITHIN GROUP (ORDER BY (CASE WHEN a.duration > 0 THEN a.heal_dealt::double precision / NULLIF(a.duration,0) ELSE 0 END)), 0) AS hps_q1,
-			   COALESCE(percentile_cont(0.5)  WITHIN GROUP (ORDER BY (CASE WHEN a.duration > 0 THEN a.heal_dealt::double precision / NULLIF(a.duration,0) ELSE 0 END)), 0) AS hps_median,
-			   COALESCE(percentile_cont(0.75) WITHIN GROUP (ORDER BY (CASE WHEN a.duration > 0 THEN a.heal_dealt::double precision / NULLIF(a.duration,0) ELSE 0 END)), 0) AS hps_q3,
-			   COALESCE(MIN(CASE WHEN a.duration > 0 THEN a.heal_dealt::double precision / NULLIF(a.duration,0) ELSE 0 END), 0) AS hps_min,
-			   COALESCE(MAX(CASE WHEN a.duration > 0 THEN a.heal_dealt::double precision / NULLIF(a.duration,0) ELSE 0 END), 0) AS hps_max
-		FROM actor_encounter_stats a
-		JOIN encounters e ON e.id = a.encounter_id
-		%s
-		GROUP BY COALESCE(a.class_spec, -1)
+			   COALESCE(AVG(s.dps), 0) AS avg_dps,
+			   COALESCE(percentile_cont(0.25) WITHIN GROUP (ORDER BY s.dps), 0) AS dps_q1,
+			   COALESCE(percentile_cont(0.5)  WITHIN GROUP (ORDER BY s.dps), 0) AS dps_median,
+			   COALESCE(percentile_cont(0.75) WITHIN GROUP (ORDER BY s.dps), 0) AS dps_q3,
+			   COALESCE(MIN(s.dps), 0) AS dps_min,
+			   COALESCE(MAX(s.dps), 0) AS dps_max,
+			   COALESCE(AVG(s.hps), 0) AS avg_hps,
+			   COALESCE(percentile_cont(0.25) WITHIN GROUP (ORDER BY s.hps), 0) AS hps_q1,
+			   COALESCE(percentile_cont(0.5)  WITHIN GROUP (ORDER BY s.hps), 0) AS hps_median,
+			   COALESCE(percentile_cont(0.75) WITHIN GROUP (ORDER BY s.hps), 0) AS hps_q3,
+			   COALESCE(MIN(s.hps), 0) AS hps_min,
+			   COALESCE(MAX(s.hps), 0) AS hps_max,
+			   COALESCE(AVG(s.boss_dps), 0) AS avg_boss_dps,
+			   COALESCE(percentile_cont(0.25) WITHIN GROUP (ORDER BY s.boss_dps), 0) AS boss_dps_q1,
+			   COALESCE(percentile_cont(0.5)  WITHIN GROUP (ORDER BY s.boss_dps), 0) AS boss_dps_median,
+			   COALESCE(percentile_cont(0.75) WITHIN GROUP (ORDER BY s.boss_dps), 0) AS boss_dps_q3,
+			   COALESCE(MIN(s.boss_dps), 0) AS boss_dps_min,
+			   COALESCE(MAX(s.boss_dps), 0) AS boss_dps_max,
+			   COALESCE(MAX(s.dps) FILTER (WHERE s.dps_rank = %[2]s), 0) AS dps_median_ci_low,
+			   COALESCE(MAX(s.dps) FILTER (WHERE s.dps_rank = %[3]s), 0) AS dps_median_ci_high,
+			   COALESCE(MAX(s.hps) FILTER (WHERE s.hps_rank = %[2]s), 0) AS hps_median_ci_low,
+			   COALESCE(MAX(s.hps) FILTER (WHERE s.hps_rank = %[3]s), 0) AS hps_median_ci_high,
+			   COALESCE(MAX(s.boss_dps) FILTER (WHERE s.boss_dps_rank = %[2]s), 0) AS boss_dps_median_ci_low,
+			   COALESCE(MAX(s.boss_dps) FILTER (WHERE s.boss_dps_rank = %[3]s), 0) AS boss_dps_median_ci_high
+		FROM (
+			SELECT s.*,
+				   COUNT(*) OVER (PARTITION BY %[5]s) AS n,
+				   row_number() OVER (PARTITION BY %[5]s ORDER BY s.dps) AS dps_rank,
+				   row_number() OVER (PARTITION BY %[5]s ORDER BY s.hps) AS hps_rank,
+				   row_number() OVER (PARTITION BY %[5]s ORDER BY s.boss_dps) AS boss_dps_rank
+			FROM (%[1]s) s
+		) s
+		GROUP BY %[5]s
+		HAVING COUNT(*) >= ?
 		ORDER BY cnt DESC
-	`, where)
+	`, samplesQuery, medianCILowRankSQL, medianCIHighRankSQL, bossCols, group)
 
 	type row struct {
 		ClassSpec int64   `gorm:"column:class_spec" json:"class_spec"`
+		BossKey   *string `gorm:"column:boss_key"`
+		MonsterID *int64  `gorm:"column:monster_id"`
+		Boss      *string `gorm:"column:boss"`
 		Count     int64   `gorm:"column:cnt" json:"count"`
 		AvgDPS    float64 `gorm:"column:avg_dps" json:"avg_dps"`
 		DpsQ1     float64 `gorm:"column:dps_q1" json:"dps_q1"`
@@ -209,20 +324,39 @@ func GetClassStats(c *gin.Context) {
 		HpsQ3     float64 `gorm:"column:hps_q3" json:"hps_q3"`
 		HpsMin    float64 `gorm:"column:hps_min" json:"hps_min"`
 		HpsMax    float64 `gorm:"column:hps_max" json:"hps_max"`
+
+		AvgBossDPS    float64 `gorm:"column:avg_boss_dps" json:"avg_boss_dps"`
+		BossDpsQ1     float64 `gorm:"column:boss_dps_q1" json:"boss_dps_q1"`
+		BossDpsMedian float64 `gorm:"column:boss_dps_median" json:"boss_dps_median"`
+		BossDpsQ3     float64 `gorm:"column:boss_dps_q3" json:"boss_dps_q3"`
+		BossDpsMin    float64 `gorm:"column:boss_dps_min" json:"boss_dps_min"`
+		BossDpsMax    float64 `gorm:"column:boss_dps_max" json:"boss_dps_max"`
+
+		DpsMedianCILow      float64 `gorm:"column:dps_median_ci_low"`
+		DpsMedianCIHigh     float64 `gorm:"column:dps_median_ci_high"`
+		HpsMedianCILow      float64 `gorm:"column:hps_median_ci_low"`
+		HpsMedianCIHigh     float64 `gorm:"column:hps_median_ci_high"`
+		BossDpsMedianCILow  float64 `gorm:"column:boss_dps_median_ci_low"`
+		BossDpsMedianCIHigh float64 `gorm:"column:boss_dps_median_ci_high"`
 	}
 
 	var rows []row
-	if err := db.Raw(query, args...).Scan(&rows).Error; err != nil {
-		// Return an empty set on failure so UI can handle gracefully
-		c.JSON(http.StatusOK, gin.H{"classes": []ClassStatsResponse{}})
-		return
+	if err := db.Raw(query, append(samplesArgs, minSamples)...).Scan(&rows).Error; err != nil {
+		return nil, err
 	}
 
 	// Map to response type
 	out := make([]ClassStatsResponse, 0, len(rows))
 	for _, r := range rows {
+		bossKey := ""
+		if r.BossKey != nil {
+			bossKey = *r.BossKey
+		}
 		out = append(out, ClassStatsResponse{
 			ClassSpec: r.ClassSpec,
+			BossKey:   bossKey,
+			MonsterID: r.MonsterID,
+			Boss:      r.Boss,
 			Count:     r.Count,
 			AvgDPS:    r.AvgDPS,
 			DpsQ1:     r.DpsQ1,
@@ -236,20 +370,90 @@ func GetClassStats(c *gin.Context) {
 			HpsQ3:     r.HpsQ3,
 			HpsMin:    r.HpsMin,
 			HpsMax:    r.HpsMax,
+
+			AvgBossDPS:    r.AvgBossDPS,
+			BossDpsQ1:     r.BossDpsQ1,
+			BossDpsMedian: r.BossDpsMedian,
+			BossDpsQ3:     r.BossDpsQ3,
+			BossDpsMin:    r.BossDpsMin,
+			BossDpsMax:    r.BossDpsMax,
+
+			DpsMedianCI:     [2]float64{r.DpsMedianCILow, r.DpsMedianCIHigh},
+			HpsMedianCI:     [2]float64{r.HpsMedianCILow, r.HpsMedianCIHigh},
+			BossDpsMedianCI: [2]float64{r.BossDpsMedianCILow, r.BossDpsMedianCIHigh},
 		})
 	}
+	return out, nil
+}
 
-	// Build per-class thresholds and collect actor rows in a single query.
-	// Collect class specs
-	classSpecs := make([]int64, 0, len(rows))
-	thresholds := make(map[int64]struct {
+// GetClassStats aggregates DPS/HPS distributions per class_spec with optional filters.
+// Query params:
+//   - the shared statistics filters (see statsFilters)
+//   - min_samples: int (omit groups with fewer samples; default 1)
+//   - group_by: class_spec | boss (default class_spec). With boss, each row is one boss
+//     (by monster ID, or by name for bosses the catalog doesn't know) and spec, and a
+//     player counts once for every boss of their encounter. The boss filter still narrows
+//     the encounters, and with group_by=boss also the bosses reported.
+//
+// Each group also gets a 95% confidence interval on its DPS, HPS and boss DPS medians.
+// Distributions come from the rollups when they can answer the filters, and from the live
+// rows otherwise, with the same results; outliers always come from the live rows.
+func GetClassStats(c *gin.Context) {
+	dbAny, ok := c.Get("db")
+	if !ok {
+		c.JSON(http.StatusOK, gin.H{"classes": []ClassStatsResponse{}})
+		return
+	}
+	db, ok := dbAny.(*gorm.DB)
+	if !ok {
+		c.JSON(http.StatusOK, gin.H{"classes": []ClassStatsResponse{}})
+		return
+	}
+
+	f := parseStatsFilters(c, 0)
+	byBoss := false
+	switch strings.ToLower(c.Query("group_by")) {
+	case "", "class_spec":
+	case "boss":
+		byBoss = true
+	default:
+		c.JSON(http.StatusBadRequest, apiErrors.NewErrorResponse(http.StatusBadRequest, "Invalid group_by (expected class_spec or boss)"))
+		return
+	}
+	samplesQuery, samplesArgs := classSamples(f)
+	if byBoss {
+		samplesQuery, samplesArgs = bossSamples(f)
+	}
+	minSamples := int64(1)
+	if v, err := strconv.ParseInt(c.Query("min_samples"), 10, 64); err == nil && v > 1 {
+		minSamples = v
+	}
+
+	var out []ClassStatsResponse
+	days, fromRollups, err := rollupDistributions(db, f)
+	if fromRollups && !byBoss {
+		out = classStatsFromRollups(days, minSamples)
+	} else {
+		out, err = liveClassStats(db, samplesQuery, samplesArgs, minSamples, byBoss)
+	}
+	if err != nil {
+		// Return an empty set on failure so UI can handle gracefully
+		c.JSON(http.StatusOK, gin.H{"classes": []ClassStatsResponse{}})
+		return
+	}
+
+	// Build per-group thresholds and collect the rows beyond them in a single query.
+	// Groups are specs, or boss and spec with group_by=boss.
+	groupKey := func(bossKey string, spec int64) string { return fmt.Sprintf("%s|%d", bossKey, spec) }
+	thresholds := make(map[string]struct {
 		dpsLow, dpsHigh, hpsLow, hpsHigh float64
 	})
-	for _, r := range rows {
-		classSpecs = append(classSpecs, r.ClassSpec)
+	fences := make([]string, 0, len(out))
+	fenceArgs := append([]interface{}{}, samplesArgs...)
+	for _, r := range out {
 		dpsIQR := r.DpsQ3 - r.DpsQ1
 		hpsIQR := r.HpsQ3 - r.HpsQ1
-		thresholds[r.ClassSpec] = struct {
+		thr := struct {
 			dpsLow, dpsHigh, hpsLow, hpsHigh float64
 		}{
 			dpsLow:  r.DpsQ1 - 1.5*dpsIQR,
@@ -257,62 +461,54 @@ func GetClassStats(c *gin.Context) {
 			hpsLow:  r.HpsQ1 - 1.5*hpsIQR,
 			hpsHigh: r.HpsQ3 + 1.5*hpsIQR,
 		}
+		thresholds[groupKey(r.BossKey, r.ClassSpec)] = thr
+		fences = append(fences, "(?::text, ?::bigint, ?::double precision, ?::double precision, ?::double precision, ?::double precision)")
+		fenceArgs = append(fenceArgs, r.BossKey, r.ClassSpec, thr.dpsLow, thr.dpsHigh, thr.hpsLow, thr.hpsHigh)
 	}
 
 	// initialize Outliers slice on each class response
-	classMap := make(map[int64]*ClassStatsResponse, len(out))
+	classMap := make(map[string]*ClassStatsResponse, len(out))
 	for i := range out {
 		out[i].Outliers = []Outlier{}
-		classMap[out[i].ClassSpec] = &out[i]
+		classMap[groupKey(out[i].BossKey, out[i].ClassSpec)] = &out[i]
 	}
 
-	if len(classSpecs) > 0 {
-		// build IN clause placeholders
-		placeholders := make([]string, len(classSpecs))
-		for i := range classSpecs {
-			placeholders[i] = "?"
-		}
-		inClause := strings.Join(placeholders, ",")
-
-		actorQuery := fmt.Sprintf(`
-			SELECT a.encounter_id, COALESCE(a.class_spec, -1) AS class_spec,
-				   a.dps AS dps,
-				   (CASE WHEN a.duration > 0 THEN a.heal_dealt::double precision / NULLIF(a.duration,0) ELSE 0 END) AS hps
-			FROM actor_encounter_stats a
-			JOIN encounters e ON e.id = a.encounter_id
-			%s AND COALESCE(a.class_spec, -1) IN (%s)
-		`, where, inClause)
-
-		// combine args + classSpecs values
-		actorArgs := make([]interface{}, 0, len(args)+len(classSpecs))
-		actorArgs = append(actorArgs, args...)
-		for _, cs := range classSpecs {
-			actorArgs = append(actorArgs, cs)
-		}
-
+	if len(out) > 0 {
 		type actorRow struct {
 			EncounterID int64   `gorm:"column:encounter_id"`
+			BossKey     string  `gorm:"column:boss_key"`
 			ClassSpec   int64   `gorm:"column:class_spec"`
 			Dps         float64 `gorm:"column:dps"`
 			Hps         float64 `gorm:"column:hps"`
 		}
 
+		bossKey := "''"
+		if byBoss {
+			bossKey = "s.boss_key"
+		}
 		var actorRows []actorRow
-		if err := db.Raw(actorQuery, actorArgs...).Scan(&actorRows).Error; err == nil {
+		outliersQ := `
+			SELECT s.encounter_id, ` + bossKey + ` AS boss_key, s.class_spec, s.dps, s.hps
+			FROM (` + samplesQuery + `) s
+			JOIN (VALUES ` + strings.Join(fences, ", ") + `) t(boss_key, class_spec, dps_low, dps_high, hps_low, hps_high)
+				ON t.class_spec = s.class_spec AND t.boss_key = ` + bossKey + `
+			WHERE s.dps < t.dps_low OR s.dps > t.dps_high OR s.hps < t.hps_low OR s.hps > t.hps_high`
+		if err := db.Raw(outliersQ, fenceArgs...).Scan(&actorRows).Error; err == nil {
 			for _, ar := range actorRows {
-				thr, ok := thresholds[ar.ClassSpec]
+				key := groupKey(ar.BossKey, ar.ClassSpec)
+				thr, ok := thresholds[key]
 				if !ok {
 					continue
 				}
 				// check dps
 				if ar.Dps < thr.dpsLow || ar.Dps > thr.dpsHigh {
-					if cls, ok := classMap[ar.ClassSpec]; ok {
+					if cls, ok := classMap[key]; ok {
 						cls.Outliers = append(cls.Outliers, Outlier{Type: "dps", EncounterID: ar.EncounterID, Value: ar.Dps})
 					}
 				}
 				// check hps
 				if ar.Hps < thr.hpsLow || ar.Hps > thr.hpsHigh {
-					if cls, ok := classMap[ar.ClassSpec]; ok {
+					if cls, ok := classMap[key]; ok {
 						cls.Outliers = append(cls.Outliers, Outlier{Type: "hps", EncounterID: ar.EncounterID, Value: ar.Hps})
 					}
 				}
@@ -324,6 +520,9 @@ func GetClassStats(c *gin.Context) {
 }
 
 // GetTotals returns overall player totals and simple breakdowns.
+// Query params: the shared statistics filters (see statsFilters). Each player is counted
+// once, by their latest row among the matching encounters.
+//
 // Response JSON shape:
 //
 //	{
@@ -354,9 +553,8 @@ func GetTotals(c *gin.Context) {
 		return
 	}
 
-	// We pick the most recent row per actor_id by created_at using DISTINCT ON.
-	// Only consider players (is_player = true) and ensure we join with encounters
-	// (joining here keeps the option to filter by encounter fields later).
+	// We pick the most recent matching row per actor_id (see latestActors)
+	latest, args := latestActors(parseStatsFilters(c, 0))
 
 	// Total players
 	var total struct {
@@ -364,14 +562,10 @@ func GetTotals(c *gin.Context) {
 	}
 	totQ := `
 		SELECT COUNT(*) AS total FROM (
-			SELECT DISTINCT ON (a.actor_id) a.actor_id
-			FROM actor_encounter_stats a
-			JOIN encounters e ON e.id = a.encounter_id
-			WHERE a.is_player = true
-			ORDER BY a.actor_id, a.id DESC
+			SELECT actor_id FROM ` + latest + `
 		) t
 	`
-	if err := db.Raw(totQ).Scan(&total).Error; err != nil {
+	if err := db.Raw(totQ, args...).Scan(&total).Error; err != nil {
 		c.JSON(http.StatusOK, gin.H{"error": "failed to compute totals"})
 		return
 	}
@@ -384,15 +578,11 @@ func GetTotals(c *gin.Context) {
 	var specRows []kv
 	specQ := `
 		SELECT class_spec AS key, COUNT(*) AS val FROM (
-			SELECT DISTINCT ON (a.actor_id) COALESCE(a.class_spec, -1) AS class_spec
-			FROM actor_encounter_stats a
-			JOIN encounters e ON e.id = a.encounter_id
-			WHERE a.is_player = true
-			ORDER BY a.actor_id, a.id DESC
+			SELECT COALESCE(l.class_spec, -1) AS class_spec FROM ` + latest + `
 		) t
 		GROUP BY class_spec
 	`
-	if err := db.Raw(specQ).Scan(&specRows).Error; err != nil {
+	if err := db.Raw(specQ, args...).Scan(&specRows).Error; err != nil {
 		c.JSON(http.StatusOK, gin.H{"error": "failed to compute class_spec breakdown"})
 		return
 	}
@@ -401,15 +591,11 @@ func GetTotals(c *gin.Context) {
 	var classRows []kv
 	classQ := `
 		SELECT class_id AS key, COUNT(*) AS val FROM (
-			SELECT DISTINCT ON (a.actor_id) COALESCE(a.class_id, -1) AS class_id
-			FROM actor_encounter_stats a
-			JOIN encounters e ON e.id = a.encounter_id
-			WHERE a.is_player = true
-			ORDER BY a.actor_id, a.id DESC
+			SELECT COALESCE(l.class_id, -1) AS class_id FROM ` + latest + `
 		) t
 		GROUP BY class_id
 	`
-	if err := db.Raw(classQ).Scan(&classRows).Error; err != nil {
+	if err := db.Raw(classQ, args...).Scan(&classRows).Error; err != nil {
 		c.JSON(http.StatusOK, gin.H{"error": "failed to compute class_id breakdown"})
 		return
 	}
@@ -418,16 +604,12 @@ func GetTotals(c *gin.Context) {
 	var abilityRows []kv
 	abilityQ := `
 		SELECT (FLOOR(COALESCE(ability_score,0)::double precision / 1000) * 1000)::bigint AS key, COUNT(*) AS val FROM (
-			SELECT DISTINCT ON (a.actor_id) COALESCE(a.ability_score, 0) AS ability_score
-			FROM actor_encounter_stats a
-			JOIN encounters e ON e.id = a.encounter_id
-			WHERE a.is_player = true
-			ORDER BY a.actor_id, a.id DESC
+			SELECT COALESCE(l.ability_score, 0) AS ability_score FROM ` + latest + `
 		) t
 		GROUP BY key
 		ORDER BY key
 	`
-	if err := db.Raw(abilityQ).Scan(&abilityRows).Error; err != nil {
+	if err := db.Raw(abilityQ, args...).Scan(&abilityRows).Error; err != nil {
 		c.JSON(http.StatusOK, gin.H{"error": "failed to compute ability_score breakdown"})
 		return
 	}
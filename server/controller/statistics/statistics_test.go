@@ -0,0 +1,70 @@
+package statistics
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	dbpkg "server/db"
+
+	"github.com/gin-gonic/gin"
+)
+
+func getClassStats(t *testing.T, query string) (int, *dbpkg.Statements) {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+	db, stmts, err := dbpkg.DryRun()
+	if err != nil {
+		t.Fatal(err)
+	}
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request = httptest.NewRequest(http.MethodGet, "/api/v1/statistics/classes"+query, nil)
+	c.Set("db", db)
+	GetClassStats(c)
+	return w.Code, stmts
+}
+
+func TestGetClassStats_GroupsByBoss(t *testing.T) {
+	code, stmts := getClassStats(t, "?group_by=boss&boss=Tina")
+	if code != http.StatusOK {
+		t.Fatalf("Expected 200, got %d", code)
+	}
+	all := stmts.All()
+	if len(all) != 1 {
+		t.Fatalf("Expected one statistics query, got %v", all)
+	}
+	q := all[0]
+	for _, want := range []string{
+		"PARTITION BY s.boss_key, s.class_spec",
+		"GROUP BY s.boss_key, s.class_spec",
+		"FROM encounter_bosses b",
+		"e.held = false",
+	} {
+		if !strings.Contains(q, want) {
+			t.Errorf("Expected the query to contain %q, got %s", want, q)
+		}
+	}
+	// The boss filter narrows both the encounters and the bosses reported
+	if n := strings.Count(q, "LOWER(b.monster_name) = LOWER('Tina')"); n != 2 {
+		t.Errorf("Expected the boss filter twice, got %d in %s", n, q)
+	}
+}
+
+func TestGetClassStats_DefaultsToSpecs(t *testing.T) {
+	code, stmts := getClassStats(t, "")
+	if code != http.StatusOK {
+		t.Fatalf("Expected 200, got %d", code)
+	}
+	all := stmts.All()
+	if len(all) != 1 || !strings.Contains(all[0], "GROUP BY s.class_spec\n") || strings.Contains(all[0], "encounter_bosses") {
+		t.Errorf("Expected a per-spec query, got %v", all)
+	}
+}
+
+func TestGetClassStats_RejectsUnknownGrouping(t *testing.T) {
+	if code, _ := getClassStats(t, "?group_by=scene"); code != http.StatusBadRequest {
+		t.Errorf("Expected 400, got %d", code)
+	}
+}
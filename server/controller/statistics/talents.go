@@ -0,0 +1,196 @@
+package statistics
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	apiErrors "server/controller"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+const (
+	// recentParses is how many of a character's latest matching parses make up their
+	// recent DPS.
+	recentParses        = 10
+	talentDefaultBuilds = 10
+	talentMaxBuilds     = 50
+	// talentMinCharacters is the smallest group of characters a build or a node's mean DPS
+	// is reported for, so no figure describes a single character.
+	talentMinCharacters = 5
+)
+
+// recentDPSSQL selects actor_id, class_id and dps, each actor's mean DPS over their latest
+// recentParses player rows matching where (over a and e).
+func recentDPSSQL(where string) string {
+	return fmt.Sprintf(`
+		SELECT r.actor_id, MAX(r.class_id) AS class_id, AVG(r.dps) AS dps
+		FROM (
+			SELECT a.actor_id, a.class_id, a.dps,
+				   row_number() OVER (PARTITION BY a.actor_id ORDER BY e.started_at DESC, a.id DESC) AS rn
+			FROM actor_encounter_stats a
+			JOIN encounters e ON e.id = a.encounter_id
+			%s
+		) r
+		WHERE r.rn <= %d
+		GROUP BY r.actor_id`, where, recentParses)
+}
+
+// talentIDsPattern accepts a flat JSON array of non-negative integers; anything else in
+// talent_node_ids_json is skipped rather than failing the jsonb cast.
+const talentIDsPattern = `^\s*\[\s*[0-9]+(\s*,\s*[0-9]+)*\s*\]\s*$`
+
+// TalentNodeStat is how often a talent node is picked and how its pickers perform.
+type TalentNodeStat struct {
+	NodeID   int64    `gorm:"column:node_id" json:"node_id"`
+	Picks    int64    `gorm:"column:picks" json:"picks"`
+	PickRate float64  `gorm:"-" json:"pick_rate"`                      // share of characters picking it, 0-1
+	AvgDPS   *float64 `gorm:"column:avg_dps" json:"avg_dps,omitempty"` // omitted below the minimum characters
+}
+
+// TalentBuildStat is one full talent build (its sorted node IDs).
+type TalentBuildStat struct {
+	Nodes   []int64 `gorm:"-" json:"nodes"`
+	Players int64   `gorm:"column:players" json:"players"`
+	Share   float64 `gorm:"-" json:"share"` // share of characters using it, 0-1
+	AvgDPS  float64 `gorm:"column:avg_dps" json:"avg_dps"`
+	MinDPS  float64 `gorm:"column:min_dps" json:"min_dps"`
+	MaxDPS  float64 `gorm:"column:max_dps" json:"max_dps"`
+}
+
+// GetTalentStats aggregates talent selections for one class spec: per-node pick rates and
+// the most common full builds, each joined to their characters' recent DPS.
+// Query params:
+//   - class_spec: int (required)
+//   - sort: popularity | dps (order of builds; default popularity)
+//   - builds: int (number of builds; default 10, max 50)
+//   - min_samples: int (skip builds used by fewer characters; default and minimum 5)
+//   - the shared statistics filters (see statsFilters), which select the parses
+//
+// A character counts when they have at least one matching parse as the spec and their
+// latest uploaded snapshot was taken on the spec's class; their recent DPS is the mean of
+// their last 10 such parses. Talents come from that snapshot. Builds used by fewer than
+// min_samples characters are left out and node mean DPS is omitted below 5 pickers, so
+// individual characters are never identifiable in the output.
+func GetTalentStats(c *gin.Context) {
+	dbAny, ok := c.Get("db")
+	if !ok {
+		c.JSON(http.StatusInternalServerError, apiErrors.NewErrorResponse(http.StatusInternalServerError, "Database not available in context"))
+		return
+	}
+	db := dbAny.(*gorm.DB)
+
+	classSpec, err := strconv.ParseInt(c.Query("class_spec"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, apiErrors.NewErrorResponse(http.StatusBadRequest, "Missing or invalid query param: class_spec"))
+		return
+	}
+	sortBy := strings.ToLower(c.DefaultQuery("sort", "popularity"))
+	if sortBy != "popularity" && sortBy != "dps" {
+		c.JSON(http.StatusBadRequest, apiErrors.NewErrorResponse(http.StatusBadRequest, "Invalid sort (expected popularity or dps)"))
+		return
+	}
+	buildLimit := talentDefaultBuilds
+	if v, err := strconv.Atoi(c.Query("builds")); err == nil && v > 0 && v <= talentMaxBuilds {
+		buildLimit = v
+	}
+	minSamples := int64(talentMinCharacters)
+	if v, err := strconv.ParseInt(c.Query("min_samples"), 10, 64); err == nil && v > minSamples {
+		minSamples = v
+	}
+
+	where, args := parseStatsFilters(c, 0).playerWhere()
+	where += " AND a.class_spec = ?"
+	args = append(args, classSpec)
+
+	cte := talentsCTE(where)
+
+	var total int64
+	if err := db.Raw(cte+" SELECT COUNT(*) FROM talents", args...).Scan(&total).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, apiErrors.NewErrorResponse(http.StatusInternalServerError, "Failed to load talent builds", err.Error()))
+		return
+	}
+
+	nodes := []TalentNodeStat{}
+	if err := db.Raw(cte+fmt.Sprintf(`
+		SELECT n.node_id, COUNT(*) AS picks, CASE WHEN COUNT(*) >= %d THEN AVG(t.dps) END AS avg_dps
+		FROM talents t
+		CROSS JOIN LATERAL unnest(t.nodes) AS n(node_id)
+		GROUP BY n.node_id
+		ORDER BY picks DESC, n.node_id`, talentMinCharacters), args...).Scan(&nodes).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, apiErrors.NewErrorResponse(http.StatusInternalServerError, "Failed to aggregate talent picks", err.Error()))
+		return
+	}
+	for i := range nodes {
+		if total > 0 {
+			nodes[i].PickRate = float64(nodes[i].Picks) / float64(total)
+		}
+	}
+
+	buildArgs := append(append([]interface{}{}, args...), minSamples)
+	var buildRows []struct {
+		TalentBuildStat
+		Nodes string `gorm:"column:nodes"`
+	}
+	if err := db.Raw(cte+talentBuildsSQL(sortBy, buildLimit), buildArgs...).Scan(&buildRows).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, apiErrors.NewErrorResponse(http.StatusInternalServerError, "Failed to aggregate talent builds", err.Error()))
+		return
+	}
+	builds := make([]TalentBuildStat, 0, len(buildRows))
+	for _, r := range buildRows {
+		b := r.TalentBuildStat
+		b.Nodes = []int64{}
+		for _, s := range strings.Split(r.Nodes, ",") {
+			if id, err := strconv.ParseInt(s, 10, 64); err == nil {
+				b.Nodes = append(b.Nodes, id)
+			}
+		}
+		if total > 0 {
+			b.Share = float64(b.Players) / float64(total)
+		}
+		builds = append(builds, b)
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"class_spec": classSpec,
+		"characters": total,
+		"nodes":      nodes,
+		"builds":     builds,
+	})
+}
+
+// talentsCTE defines talents, each counted character's distinct node IDs (sorted) with their
+// recent DPS, for the player rows matching where (over a and e). Snapshots taken on another
+// class hold that class's talents, so they are skipped.
+func talentsCTE(where string) string {
+	return fmt.Sprintf(`
+		WITH perf AS (%s
+		), talents AS (
+			SELECT d.player_id, p.dps,
+				   ARRAY(SELECT DISTINCT x::bigint FROM jsonb_array_elements_text(d.talent_node_ids_json::jsonb) x ORDER BY 1) AS nodes
+			FROM detailed_playerdata d
+			JOIN perf p ON p.actor_id = d.player_id
+			JOIN character_builds cb ON cb.player_id = d.player_id AND cb.profession_id = p.class_id
+			WHERE d.talent_node_ids_json ~ '%s'
+		)`, recentDPSSQL(where), talentIDsPattern)
+}
+
+// talentBuildsSQL selects the top limit builds of talents in sortBy order. Its one
+// argument is the minimum characters per build, applied whatever the order.
+func talentBuildsSQL(sortBy string, limit int) string {
+	order := "players DESC, avg_dps DESC"
+	if sortBy == "dps" {
+		order = "avg_dps DESC, players DESC"
+	}
+	return fmt.Sprintf(`
+		SELECT array_to_string(t.nodes, ',') AS nodes, COUNT(*) AS players,
+			   AVG(t.dps) AS avg_dps, MIN(t.dps) AS min_dps, MAX(t.dps) AS max_dps
+		FROM talents t
+		GROUP BY t.nodes
+		HAVING COUNT(*) >= ?
+		ORDER BY %s
+		LIMIT %d`, order, limit)
+}
@@ -0,0 +1,28 @@
+package statistics
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestTalentBuildsSQL_ThresholdForEverySort(t *testing.T) {
+	for _, sortBy := range []string{"popularity", "dps"} {
+		q := talentBuildsSQL(sortBy, 10)
+		if !strings.Contains(q, "HAVING COUNT(*) >= ?") {
+			t.Errorf("%s: expected small builds to be left out, got %s", sortBy, q)
+		}
+	}
+	if q := talentBuildsSQL("dps", 10); !strings.Contains(q, "ORDER BY avg_dps DESC") {
+		t.Errorf("Expected sort=dps to order by DPS, got %s", q)
+	}
+}
+
+func TestTalentsCTE_SkipsSnapshotsOfOtherClasses(t *testing.T) {
+	q := talentsCTE("WHERE a.class_spec = ?")
+	if !strings.Contains(q, "cb.profession_id = p.class_id") {
+		t.Errorf("Expected talents to require a snapshot of the spec's class, got %s", q)
+	}
+	if !strings.Contains(q, "MAX(r.class_id) AS class_id") {
+		t.Errorf("Expected recent DPS to carry the spec's class, got %s", q)
+	}
+}
@@ -0,0 +1,185 @@
+package statistics
+
+import (
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	apiErrors "server/controller"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// trendDefaultSinceDays bounds the trend window when since_days is not given.
+const trendDefaultSinceDays = 90
+
+// TrendPoint is one time bucket of a spec's DPS/HPS distribution. Count is the number of
+// samples in the bucket, so clients can hide noisy buckets.
+type TrendPoint struct {
+	Start     string  `gorm:"column:bucket_start" json:"start"` // first day of the bucket (UTC, YYYY-MM-DD)
+	Count     int64   `gorm:"column:cnt" json:"count"`
+	DpsMedian float64 `gorm:"column:dps_median" json:"dps_median"`
+	DpsP75    float64 `gorm:"column:dps_p75" json:"dps_p75"`
+	DpsP95    float64 `gorm:"column:dps_p95" json:"dps_p95"`
+	HpsMedian float64 `gorm:"column:hps_median" json:"hps_median"`
+	HpsP75    float64 `gorm:"column:hps_p75" json:"hps_p75"`
+	HpsP95    float64 `gorm:"column:hps_p95" json:"hps_p95"`
+}
+
+// ClassTrendSeries is the time series for one class spec.
+type ClassTrendSeries struct {
+	ClassSpec int64        `json:"class_spec"`
+	Points    []TrendPoint `json:"points"`
+}
+
+// GetClassTrend returns per-spec DPS/HPS medians, p75 and p95 bucketed over time.
+// Query params:
+//   - bucket: day | week (ISO weeks starting Monday; default week; others are rejected)
+//   - class_spec: int (only this spec)
+//   - the shared statistics filters (see statsFilters); since_days defaults to 90
+func GetClassTrend(c *gin.Context) {
+	bucket := strings.ToLower(c.Query("bucket"))
+	if bucket == "" {
+		bucket = "week"
+	}
+	var bucketExpr string
+	switch bucket {
+	case "day":
+		bucketExpr = "s.day"
+	case "week":
+		bucketExpr = "date_trunc('week', s.day)::date"
+	default:
+		c.JSON(http.StatusBadRequest, apiErrors.NewErrorResponse(http.StatusBadRequest, "Invalid bucket (expected day or week)"))
+		return
+	}
+
+	empty := gin.H{"bucket": bucket, "series": []ClassTrendSeries{}}
+	dbAny, ok := c.Get("db")
+	if !ok {
+		c.JSON(http.StatusOK, empty)
+		return
+	}
+	db, ok := dbAny.(*gorm.DB)
+	if !ok {
+		c.JSON(http.StatusOK, empty)
+		return
+	}
+
+	f := parseStatsFilters(c, trendDefaultSinceDays)
+	var classSpec *int64
+	if v := c.Query("class_spec"); v != "" {
+		if n, err := strconv.ParseInt(v, 10, 64); err == nil {
+			classSpec = &n
+		}
+	}
+
+	days, fromRollups, err := rollupDistributions(db, f)
+	if fromRollups {
+		if err != nil {
+			c.JSON(http.StatusOK, empty)
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{"bucket": bucket, "series": trendFromRollups(days, bucket, classSpec)})
+		return
+	}
+
+	samplesQuery, samplesArgs := classSamples(f)
+	specWhere := ""
+	if classSpec != nil {
+		specWhere = "WHERE s.class_spec = ?"
+		samplesArgs = append(samplesArgs, *classSpec)
+	}
+
+	query := fmt.Sprintf(`
+		SELECT s.class_spec AS class_spec,
+			   to_char(%s, 'YYYY-MM-DD') AS bucket_start,
+			   COUNT(*) AS cnt,
+			   COALESCE(percentile_cont(0.5)  WITHIN GROUP (ORDER BY s.dps), 0) AS dps_median,
+			   COALESCE(percentile_cont(0.75) WITHIN GROUP (ORDER BY s.dps), 0) AS dps_p75,
+			   COALESCE(percentile_cont(0.95) WITHIN GROUP (ORDER BY s.dps), 0) AS dps_p95,
+			   COALESCE(percentile_cont(0.5)  WITHIN GROUP (ORDER BY s.hps), 0) AS hps_median,
+			   COALESCE(percentile_cont(0.75) WITHIN GROUP (ORDER BY s.hps), 0) AS hps_p75,
+			   COALESCE(percentile_cont(0.95) WITHIN GROUP (ORDER BY s.hps), 0) AS hps_p95
+		FROM (%s) s
+		%s
+		GROUP BY 1, 2
+		ORDER BY 1, 2
+	`, bucketExpr, samplesQuery, specWhere)
+
+	type row struct {
+		ClassSpec int64 `gorm:"column:class_spec"`
+		TrendPoint
+	}
+	var rows []row
+	if err := db.Raw(query, samplesArgs...).Scan(&rows).Error; err != nil {
+		// Return an empty set on failure so UI can handle gracefully
+		c.JSON(http.StatusOK, empty)
+		return
+	}
+
+	// Rows are ordered by spec, so each spec's points are contiguous
+	series := []ClassTrendSeries{}
+	for _, r := range rows {
+		if n := len(series); n == 0 || series[n-1].ClassSpec != r.ClassSpec {
+			series = append(series, ClassTrendSeries{ClassSpec: r.ClassSpec, Points: []TrendPoint{}})
+		}
+		series[len(series)-1].Points = append(series[len(series)-1].Points, r.TrendPoint)
+	}
+
+	c.JSON(http.StatusOK, gin.H{"bucket": bucket, "series": series})
+}
+
+// trendFromRollups buckets per-spec distributions read from the rollups the way the live
+// GetClassTrend query buckets samples.
+func trendFromRollups(days []specDay, bucket string, classSpec *int64) []ClassTrendSeries {
+	var points []specDay
+	index := make(map[string]int)
+	for _, d := range days {
+		if classSpec != nil && d.ClassSpec != *classSpec {
+			continue
+		}
+		start := d.Day
+		if bucket == "week" {
+			if t, err := time.Parse("2006-01-02", d.Day); err == nil {
+				// ISO weeks start on Monday
+				start = t.AddDate(0, 0, -((int(t.Weekday()) + 6) % 7)).Format("2006-01-02")
+			}
+		}
+		key := fmt.Sprintf("%d|%s", d.ClassSpec, start)
+		if i, ok := index[key]; ok {
+			points[i].merge(d)
+			continue
+		}
+		index[key] = len(points)
+		points = append(points, specDay{ClassSpec: d.ClassSpec, Day: start})
+		points[len(points)-1].merge(d)
+	}
+	sort.Slice(points, func(i, j int) bool {
+		if points[i].ClassSpec != points[j].ClassSpec {
+			return points[i].ClassSpec < points[j].ClassSpec
+		}
+		return points[i].Day < points[j].Day
+	})
+
+	series := []ClassTrendSeries{}
+	for _, p := range points {
+		if n := len(series); n == 0 || series[n-1].ClassSpec != p.ClassSpec {
+			series = append(series, ClassTrendSeries{ClassSpec: p.ClassSpec, Points: []TrendPoint{}})
+		}
+		series[len(series)-1].Points = append(series[len(series)-1].Points, TrendPoint{
+			Start:     p.Day,
+			Count:     p.DPS.Count(),
+			DpsMedian: p.DPS.Quantile(0.5),
+			DpsP75:    p.DPS.Quantile(0.75),
+			DpsP95:    p.DPS.Quantile(0.95),
+			HpsMedian: p.HPS.Quantile(0.5),
+			HpsP75:    p.HPS.Quantile(0.75),
+			HpsP95:    p.HPS.Quantile(0.95),
+		})
+	}
+	return series
+}
@@ -0,0 +1,28 @@
+package statistics
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestGetClassTrend_RejectsUnknownBucket(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	for query, want := range map[string]int{
+		"":              http.StatusOK,
+		"?bucket=DAY":   http.StatusOK,
+		"?bucket=week":  http.StatusOK,
+		"?bucket=month": http.StatusBadRequest,
+		"?bucket=":      http.StatusOK,
+	} {
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		c.Request = httptest.NewRequest(http.MethodGet, "/api/v1/statistics/trend"+query, nil)
+		GetClassTrend(c)
+		if w.Code != want {
+			t.Errorf("GET trend%s: expected %d, got %d", query, want, w.Code)
+		}
+	}
+}
@@ -1,6 +1,8 @@
 package upload
 
 import (
+	"encoding/json"
+	"log"
 	"net/http"
 	"strings"
 	"time"
@@ -8,16 +10,26 @@ import (
 	apiErrors "server/controller"
 	"server/lib"
 	"server/models"
+	"server/services/builds"
+	"server/services/gamedata"
+	"server/services/moderation"
+	"server/services/parses"
+	"server/services/patches"
+	"server/services/progress"
+	"server/services/rollups"
+	"server/services/scenes"
 
 	"github.com/gin-gonic/gin"
+	"gorm.io/datatypes"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 // ConvertToEncounterInput converts EncounterIn to lib.EncounterInput for deduplication
 func ConvertToEncounterInput(e EncounterIn) lib.EncounterInput {
 	bosses := make([]lib.BossInput, len(e.EncounterBosses))
 	for i, b := range e.EncounterBosses {
-		bosses[i] = lib.BossInput{MonsterName: b.MonsterName}
+		bosses[i] = lib.BossInput{MonsterName: b.MonsterName, MonsterID: b.MonsterID}
 	}
 
 	actors := make([]lib.ActorStatInput, len(e.ActorEncounterStats))
@@ -40,6 +52,91 @@ func ConvertToEncounterInput(e EncounterIn) lib.EncounterInput {
 	}
 }
 
+// canonicalizeNames fills in the scene ID the client didn't send and the boss IDs by
+// resolving their names, which arrive in the uploader's client language, against the game
+// data catalog. Dedupe and the scene/boss filters then match the same fight across
+// languages. Boss IDs only ever come from the catalog, so a client can't make a fight
+// look like another one.
+func canonicalizeNames(e *EncounterIn) {
+	catalog := gamedata.Get()
+	if e.SceneID == nil && e.SceneName != nil {
+		if id, ok := catalog.SceneID(*e.SceneName); ok {
+			e.SceneID = &id
+		}
+	}
+	for i := range e.EncounterBosses {
+		b := &e.EncounterBosses[i]
+		b.MonsterID = nil
+		if id, ok := catalog.MonsterID(b.MonsterName); ok {
+			b.MonsterID = &id
+		}
+	}
+}
+
+// attemptOutcome reports whether an attempt ended in a kill, from the boss HP it ended on
+// or its end reason. known is false when the client sent neither.
+func attemptOutcome(a AttemptIn) (kill, known bool) {
+	if a.BossHpEnd != nil {
+		return *a.BossHpEnd <= 0, true
+	}
+	if a.Reason != nil && *a.Reason != "" {
+		switch strings.ToLower(*a.Reason) {
+		case "kill", "killed", "success", "clear", "cleared", "boss_killed":
+			return true, true
+		}
+		return false, true
+	}
+	return false, false
+}
+
+// killAttemptIndex returns the attempt that killed the boss: the last attempt that ended
+// in a kill. Clients that report no attempt outcomes only get a kill attempt when a boss
+// was defeated in a single-attempt encounter.
+func killAttemptIndex(e EncounterIn) (int, bool) {
+	found, anyKnown := false, false
+	kill := 0
+	for _, a := range e.Attempts {
+		k, known := attemptOutcome(a)
+		anyKnown = anyKnown || known
+		if k && (!found || a.AttemptIndex > kill) {
+			kill, found = a.AttemptIndex, true
+		}
+	}
+	if found || anyKnown {
+		return kill, found
+	}
+
+	if len(e.Attempts) != 1 {
+		return 0, false
+	}
+	for _, b := range e.EncounterBosses {
+		if b.IsDefeated {
+			return e.Attempts[0].AttemptIndex, true
+		}
+	}
+	return 0, false
+}
+
+// segmentTotals fills in duration and DPS for an attempt/phase actor row when the client
+// omitted them, using the segment's own start and end (endMs = 0 when still open).
+func segmentTotals(s SegmentActorStatIn, startMs, endMs int64) (dmg, heal, taken, bossDmg int64, dps, dur float64) {
+	dmg, heal, taken = s.DamageDealt, s.HealDealt, s.DamageTaken
+	if s.BossDamageDealt != nil {
+		bossDmg = *s.BossDamageDealt
+	}
+	if s.Duration != nil {
+		dur = *s.Duration
+	} else if endMs > startMs {
+		dur = float64(endMs-startMs) / 1000
+	}
+	if s.DPS != nil {
+		dps = *s.DPS
+	} else if dur > 0 {
+		dps = float64(dmg) / dur
+	}
+	return
+}
+
 // Incoming payload structures (omit IDs; server assigns IDs)
 type EncounterIn struct {
 	StartedAtMs   int64   `json:"startedAtMs"`
@@ -70,6 +167,9 @@ type AttemptIn struct {
 	BossHpStart  *int64  `json:"bossHpStart"`
 	BossHpEnd    *int64  `json:"bossHpEnd"`
 	TotalDeaths  int     `json:"totalDeaths"`
+
+	// Optional per-actor stats for this attempt only
+	ActorStats []SegmentActorStatIn `json:"actorStats"`
 }
 
 type EncounterPhaseIn struct {
@@ -77,6 +177,24 @@ type EncounterPhaseIn struct {
 	StartTimeMs int64  `json:"startTimeMs"`
 	EndTimeMs   *int64 `json:"endTimeMs"`
 	Outcome     string `json:"outcome"`
+
+	// Optional per-actor stats for this phase only
+	ActorStats []SegmentActorStatIn `json:"actorStats"`
+}
+
+// SegmentActorStatIn carries per-actor totals for an attempt or phase. Identity fields
+// (name, class, ability score) are taken from the encounter-level actor stats.
+type SegmentActorStatIn struct {
+	ActorID         int64    `json:"actorId"`
+	DamageDealt     int64    `json:"damageDealt"`
+	HealDealt       int64    `json:"healDealt"`
+	DamageTaken     int64    `json:"damageTaken"`
+	HitsDealt       int64    `json:"hitsDealt"`
+	HitsHeal        int64    `json:"hitsHeal"`
+	HitsTaken       int64    `json:"hitsTaken"`
+	BossDamageDealt *int64   `json:"bossDamageDealt"`
+	DPS             *float64 `json:"dps"`
+	Duration        *float64 `json:"duration"`
 }
 
 type DeathEventIn struct {
@@ -148,8 +266,9 @@ type DamageSkillStatIn struct {
 	LuckyTotal      int64  `json:"luckyTotal"`
 	HpLossTotal     int64  `json:"hpLossTotal"`
 	ShieldLossTotal int64  `json:"shieldLossTotal"`
-	// HitDetails omitted for now
-	MonsterName *string `json:"monsterName"`
+	// HitDetails is an optional per-hit timeline (see lib.HitDetail)
+	HitDetails  json.RawMessage `json:"hitDetails"`
+	MonsterName *string         `json:"monsterName"`
 }
 
 type HealSkillStatIn struct {
@@ -176,6 +295,7 @@ type EntityIn struct {
 
 type EncounterBossIn struct {
 	MonsterName string `json:"monsterName"`
+	MonsterID   *int64 `json:"-"` // resolved from MonsterName by canonicalizeNames
 	Hits        int64  `json:"hits"`
 	TotalDamage int64  `json:"totalDamage"`
 	MaxHP       *int64 `json:"maxHp"`
@@ -318,12 +438,26 @@ func UploadEncounters(c *gin.Context) {
 		c.JSON(http.StatusBadRequest, apiErrors.NewErrorResponse(http.StatusBadRequest, "Too many encounters in one request (max 10)"))
 		return
 	}
+	// Hit timelines are stored as jsonb; keep only well-formed, bounded ones
+	for i := range req.Encounters {
+		for j := range req.Encounters[i].DamageSkillStats {
+			s := &req.Encounters[i].DamageSkillStats[j]
+			hits, err := lib.NormalizeHitDetails(s.HitDetails)
+			if err != nil {
+				c.JSON(http.StatusBadRequest, apiErrors.NewErrorResponse(http.StatusBadRequest, "Invalid request payload", err.Error()))
+				return
+			}
+			s.HitDetails = hits
+		}
+	}
 
 	createdIDs := make([]int64, 0, len(req.Encounters))
 	dedupeConfig := lib.DefaultDedupeConfig()
 
 	err := txdb.Transaction(func(tx *gorm.DB) error {
 		for _, e := range req.Encounters {
+			canonicalizeNames(&e)
+
 			// Compute server-side fingerprint and player set hash
 			encInput := ConvertToEncounterInput(e)
 			fingerprint := lib.ComputeEncounterFingerprint(encInput, dedupeConfig)
@@ -394,19 +528,31 @@ func UploadEncounters(c *gin.Context) {
 			if e.TotalHeal != nil {
 				th = *e.TotalHeal
 			}
+			// Boss-active time excludes trash/mob phases so boss DPS isn't diluted by slow clears
+			var endedAtMs int64
+			if e.EndedAtMs != nil {
+				endedAtMs = *e.EndedAtMs
+			}
+			phaseWindows := make([]lib.PhaseWindow, 0, len(e.Phases))
+			for _, p := range e.Phases {
+				phaseWindows = append(phaseWindows, lib.PhaseWindow{PhaseType: p.PhaseType, StartTimeMs: p.StartTimeMs, EndTimeMs: p.EndTimeMs})
+			}
+			bossDuration := lib.ComputeBossActiveSeconds(phaseWindows, e.StartedAtMs, endedAtMs)
 			encounter := models.Encounter{
-				StartedAt:     time.UnixMilli(e.StartedAtMs),
-				EndedAt:       endedAtPtr,
-				Duration:      duration,
-				LocalPlayerID: e.LocalPlayerID,
-				TotalDmg:      td,
-				TotalHeal:     th,
-				SceneID:       e.SceneID,
-				SceneName:     e.SceneName,
-				SourceHash:    e.SourceHash,
-				Fingerprint:   &fingerprint,
-				PlayerSetHash: &playerSetHash,
-				UserID:        user.ID,
+				StartedAt:          time.UnixMilli(e.StartedAtMs),
+				EndedAt:            endedAtPtr,
+				Duration:           duration,
+				BossDuration:       bossDuration,
+				LocalPlayerID:      e.LocalPlayerID,
+				TotalDmg:           td,
+				TotalHeal:          th,
+				SceneID:            e.SceneID,
+				SceneName:          e.SceneName,
+				SourceHash:         e.SourceHash,
+				Fingerprint:        &fingerprint,
+				PlayerSetHash:      &playerSetHash,
+				FingerprintVersion: lib.FingerprintVersion,
+				UserID:             user.ID,
 			}
 
 			// Create with unique constraint handling (in case of race condition on fingerprint unique index)
@@ -427,6 +573,12 @@ func UploadEncounters(c *gin.Context) {
 			}
 			createdIDs = append(createdIDs, encounter.ID)
 
+			// Identity fields copied onto attempt/phase actor rows
+			actorsByID := make(map[int64]ActorEncounterStatIn, len(e.ActorEncounterStats))
+			for _, s := range e.ActorEncounterStats {
+				actorsByID[s.ActorID] = s
+			}
+
 			// Attempts
 			if len(e.Attempts) > 0 {
 				attempts := make([]models.Attempt, 0, len(e.Attempts))
@@ -451,6 +603,44 @@ func UploadEncounters(c *gin.Context) {
 				if err := tx.Create(&attempts).Error; err != nil {
 					return err
 				}
+
+				killIndex, hasKill := killAttemptIndex(e)
+				var attemptStats []models.AttemptActorStat
+				for _, a := range e.Attempts {
+					var endedAtMs int64
+					if a.EndedAtMs != nil {
+						endedAtMs = *a.EndedAtMs
+					}
+					for _, s := range a.ActorStats {
+						dmg, heal, taken, bossDmg, dps, dur := segmentTotals(s, a.StartedAtMs, endedAtMs)
+						actor := actorsByID[s.ActorID]
+						attemptStats = append(attemptStats, models.AttemptActorStat{
+							EncounterID:     encounter.ID,
+							AttemptIndex:    a.AttemptIndex,
+							IsKill:          hasKill && a.AttemptIndex == killIndex,
+							ActorID:         s.ActorID,
+							DamageDealt:     dmg,
+							HealDealt:       heal,
+							DamageTaken:     taken,
+							HitsDealt:       s.HitsDealt,
+							HitsHeal:        s.HitsHeal,
+							HitsTaken:       s.HitsTaken,
+							BossDamageDealt: bossDmg,
+							DPS:             dps,
+							Duration:        dur,
+							Name:            actor.Name,
+							ClassID:         actor.ClassID,
+							ClassSpec:       actor.ClassSpec,
+							AbilityScore:    actor.AbilityScore,
+							IsPlayer:        actor.IsPlayer,
+						})
+					}
+				}
+				if len(attemptStats) > 0 {
+					if err := tx.Create(&attemptStats).Error; err != nil {
+						return err
+					}
+				}
 			}
 
 			// Encounter phases
@@ -474,6 +664,44 @@ func UploadEncounters(c *gin.Context) {
 				if err := tx.Create(&phases).Error; err != nil {
 					return err
 				}
+
+				// phases were created in input order, so IDs line up with e.Phases
+				var phaseStats []models.PhaseActorStat
+				for i, p := range e.Phases {
+					var endTimeMs int64
+					if p.EndTimeMs != nil {
+						endTimeMs = *p.EndTimeMs
+					}
+					for _, s := range p.ActorStats {
+						dmg, heal, taken, bossDmg, dps, dur := segmentTotals(s, p.StartTimeMs, endTimeMs)
+						actor := actorsByID[s.ActorID]
+						phaseStats = append(phaseStats, models.PhaseActorStat{
+							EncounterID:     encounter.ID,
+							PhaseID:         phases[i].ID,
+							PhaseType:       p.PhaseType,
+							ActorID:         s.ActorID,
+							DamageDealt:     dmg,
+							HealDealt:       heal,
+							DamageTaken:     taken,
+							HitsDealt:       s.HitsDealt,
+							HitsHeal:        s.HitsHeal,
+							HitsTaken:       s.HitsTaken,
+							BossDamageDealt: bossDmg,
+							DPS:             dps,
+							Duration:        dur,
+							Name:            actor.Name,
+							ClassID:         actor.ClassID,
+							ClassSpec:       actor.ClassSpec,
+							AbilityScore:    actor.AbilityScore,
+							IsPlayer:        actor.IsPlayer,
+						})
+					}
+				}
+				if len(phaseStats) > 0 {
+					if err := tx.Create(&phaseStats).Error; err != nil {
+						return err
+					}
+				}
 			}
 
 			// Death events
@@ -597,12 +825,42 @@ func UploadEncounters(c *gin.Context) {
 				if err := tx.Create(&stats).Error; err != nil {
 					return err
 				}
+
+				// Keep each character's latest identity; older uploads don't overwrite newer data
+				var chars []models.Character
+				seenChars := make(map[int64]bool)
+				for _, s := range stats {
+					if s.IsPlayer && !seenChars[s.ActorID] {
+						seenChars[s.ActorID] = true
+						chars = append(chars, models.Character{
+							ActorID:      s.ActorID,
+							Name:         s.Name,
+							ClassID:      s.ClassID,
+							ClassSpec:    s.ClassSpec,
+							AbilityScore: s.AbilityScore,
+							LastSeenAt:   encounter.StartedAt,
+						})
+					}
+				}
+				if len(chars) > 0 {
+					if err := tx.Clauses(clause.OnConflict{
+						Columns:   []clause.Column{{Name: "actor_id"}},
+						DoUpdates: clause.AssignmentColumns([]string{"name", "class_id", "class_spec", "ability_score", "last_seen_at", "updated_at"}),
+						Where:     clause.Where{Exprs: []clause.Expression{gorm.Expr("characters.last_seen_at <= excluded.last_seen_at")}},
+					}).Create(&chars).Error; err != nil {
+						return err
+					}
+				}
 			}
 
 			// Damage skill stats
 			if len(e.DamageSkillStats) > 0 {
 				dss := make([]models.DamageSkillStat, 0, len(e.DamageSkillStats))
 				for _, s := range e.DamageSkillStats {
+					var hitDetails datatypes.JSON
+					if len(s.HitDetails) > 0 {
+						hitDetails = datatypes.JSON(s.HitDetails)
+					}
 					dss = append(dss, models.DamageSkillStat{
 						EncounterID:     encounter.ID,
 						AttackerID:      s.AttackerID,
@@ -616,6 +874,7 @@ func UploadEncounters(c *gin.Context) {
 						LuckyTotal:      s.LuckyTotal,
 						HpLossTotal:     s.HpLossTotal,
 						ShieldLossTotal: s.ShieldLossTotal,
+						HitDetails:      hitDetails,
 						MonsterName:     s.MonsterName,
 					})
 				}
@@ -674,6 +933,7 @@ func UploadEncounters(c *gin.Context) {
 					bosses = append(bosses, models.EncounterBoss{
 						EncounterID: encounter.ID,
 						MonsterName: b.MonsterName,
+						MonsterID:   b.MonsterID,
 						Hits:        b.Hits,
 						TotalDamage: b.TotalDamage,
 						MaxHP:       b.MaxHP,
@@ -704,14 +964,35 @@ func UploadEncounters(c *gin.Context) {
 					playerData = append(playerData, data)
 				}
 				// Use upsert to handle updates to existing player data
+				progressService := progress.NewProgressService(tx)
+				buildService := builds.NewBuildService(tx)
 				for _, pd := range playerData {
 					if err := tx.Save(&pd).Error; err != nil {
 						return err
 					}
+					if err := progressService.RecordSnapshot(pd.PlayerID, pd.LastSeenMs, pd.CharSerializeJSON); err != nil {
+						return err
+					}
+					if err := buildService.RecordSnapshot(pd.PlayerID, pd.LastSeenMs, pd.CharSerializeJSON); err != nil {
+						return err
+					}
 				}
 			}
 		}
 
+		// Tag the encounters with the patch they were played on
+		if err := patches.NewPatchService(tx).TagEncounters(createdIDs); err != nil {
+			return err
+		}
+		// Link them to their scene, creating it on first sight
+		if err := scenes.NewSceneService(tx).LinkEncounters(createdIDs); err != nil {
+			return err
+		}
+		// Hold implausible encounters for review before they reach leaderboards
+		if err := moderation.NewModerationService(tx).CheckEncounters(createdIDs); err != nil {
+			return err
+		}
+
 		// Increment user's upload counter
 		if err := tx.Model(&models.User{}).Where("id = ?", user.ID).UpdateColumn("encounters_uploaded", gorm.Expr("encounters_uploaded + ?", len(createdIDs))).Error; err != nil {
 			return err
@@ -724,5 +1005,14 @@ func UploadEncounters(c *gin.Context) {
 		return
 	}
 
+	// Rank the new parses against their peers; the periodic recompute repairs any failure here
+	if err := parses.NewParseService(txdb).RankEncounters(createdIDs); err != nil {
+		log.Printf("upload: %v", err)
+	}
+	// Same for statistics rollups; the periodic catch-up repairs any failure here
+	if err := rollups.NewRollupService(txdb).AddEncounters(createdIDs); err != nil {
+		log.Printf("upload: %v", err)
+	}
+
 	c.JSON(http.StatusOK, UploadEncountersResponse{Ingested: len(createdIDs), IDs: createdIDs})
 }
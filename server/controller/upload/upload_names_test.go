@@ -0,0 +1,125 @@
+package upload
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"strings"
+	"testing"
+
+	dbpkg "server/db"
+	"server/lib"
+	"server/models"
+	"server/services/gamedata"
+
+	"github.com/gin-gonic/gin"
+)
+
+// useTestCatalog makes the fixture tables in services/gamedata/testdata, which carry
+// German and French names, the current catalog for the test.
+func useTestCatalog(t *testing.T) *gamedata.Catalog {
+	t.Helper()
+	// Registered first so it runs after t.Setenv has restored GAMEDATA_DIR
+	t.Cleanup(func() {
+		if err := gamedata.Init(); err != nil {
+			t.Error(err)
+		}
+	})
+	t.Setenv("GAMEDATA_DIR", "../../services/gamedata/testdata")
+	if err := gamedata.Init(); err != nil {
+		t.Fatal(err)
+	}
+	return gamedata.Get()
+}
+
+var fingerprintLookup = regexp.MustCompile(`fingerprint = '([0-9a-f]{64})'`)
+
+// uploadFingerprint uploads body against a dry-run DB and returns the fingerprint the
+// handler looked up for duplicates. Ingest can't complete without a database, so only
+// rejected payloads fail the test.
+func uploadFingerprint(t *testing.T, body string) string {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+	db, stmts, err := dbpkg.DryRun()
+	if err != nil {
+		t.Fatal(err)
+	}
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request = httptest.NewRequest(http.MethodPost, "/api/v1/upload", strings.NewReader(body))
+	c.Request.Header.Set("Content-Type", "application/json")
+	c.Set("db", db)
+	c.Set("user", &models.User{ID: 2})
+	UploadEncounters(c)
+	if w.Code == http.StatusBadRequest {
+		t.Fatalf("Expected the payload to be accepted, got %s", w.Body.String())
+	}
+	for _, stmt := range stmts.All() {
+		if m := fingerprintLookup.FindStringSubmatch(stmt); m != nil {
+			return m[1]
+		}
+	}
+	t.Fatalf("Expected a fingerprint lookup, got %v", stmts.All())
+	return ""
+}
+
+func encounterBody(sceneName, bossName, extraBossFields string) string {
+	return `{"encounters": [{
+		"startedAtMs": 1760000000000, "totalDmg": 1000, "sceneName": "` + sceneName + `",
+		"encounterBosses": [{"monsterName": "` + bossName + `"` + extraBossFields + `, "isDefeated": true}],
+		"actorEncounterStats": [{"actorId": 7, "isPlayer": true, "damageDealt": 600}, {"actorId": 8, "isPlayer": true, "damageDealt": 400}],
+		"attempts": [{"attemptIndex": 1, "startedAtMs": 1760000000000}]
+	}]}`
+}
+
+func TestUploadEncounters_LocalizedNamesDedupeAcrossLanguages(t *testing.T) {
+	catalog := useTestCatalog(t)
+
+	english := uploadFingerprint(t, encounterBody("Dragon's Lair", "Tina", ""))
+	german := uploadFingerprint(t, encounterBody("Drachenhort", "Tina die Drachenkönigin", ""))
+	french := uploadFingerprint(t, encounterBody("  antre du DRAGON ", "Tina la reine dragon", ""))
+	if german != english || french != english {
+		t.Errorf("Expected the same fight to fingerprint alike in every language, got en %s, de %s, fr %s", english, german, french)
+	}
+
+	sceneID, monsterID := int64(1001), int64(5001)
+	want := lib.ComputeEncounterFingerprint(lib.EncounterInput{
+		StartedAtMs:         1760000000000,
+		TotalDmg:            &[]int64{1000}[0],
+		SceneID:             &sceneID,
+		SceneName:           &[]string{"Drachenhort"}[0],
+		EncounterBosses:     []lib.BossInput{{MonsterName: "Tina die Drachenkönigin", MonsterID: &monsterID}},
+		ActorEncounterStats: []lib.ActorStatInput{{ActorID: 7, DamageDealt: 600, IsPlayer: true}, {ActorID: 8, DamageDealt: 400, IsPlayer: true}},
+		AttemptsCount:       1,
+	}, lib.DefaultDedupeConfig())
+	if german != want {
+		t.Errorf("Expected the fingerprint of scene 1001 with boss 5001, got %s", german)
+	}
+
+	// Localized back for readers in any language
+	locale := catalog.MatchLocale("de-DE,de;q=0.9,en;q=0.8")
+	if locale != "de" {
+		t.Fatalf("Expected German to be matched, got %q", locale)
+	}
+	if name := catalog.LocalizeScene(&sceneID, nil, locale); name == nil || *name != "Drachenhort" {
+		t.Errorf("Expected the German scene name, got %v", name)
+	}
+	if name := catalog.LocalizeMonster(&monsterID, "Tina", "fr"); name != "Tina la reine dragon" {
+		t.Errorf("Expected the French boss name, got %q", name)
+	}
+}
+
+func TestUploadEncounters_IgnoresClientMonsterID(t *testing.T) {
+	useTestCatalog(t)
+
+	want := uploadFingerprint(t, encounterBody("Dragon's Lair", "Tina", ""))
+	// The ID of another boss doesn't make the fight look like a different one
+	if got := uploadFingerprint(t, encounterBody("Dragon's Lair", "Tina", `, "monsterId": 5002`)); got != want {
+		t.Errorf("Expected a client monsterId to be ignored, got %s, expected %s", got, want)
+	}
+	// Nor does it make an unknown boss look like a known one
+	unknown := uploadFingerprint(t, encounterBody("Dragon's Lair", "Someone Else", ""))
+	if got := uploadFingerprint(t, encounterBody("Dragon's Lair", "Someone Else", `, "monsterId": 5001`)); got != unknown || got == want {
+		t.Errorf("Expected an unknown boss to keep its name key, got %s", got)
+	}
+}
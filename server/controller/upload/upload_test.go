@@ -0,0 +1,78 @@
+package upload
+
+import "testing"
+
+func int64p(v int64) *int64 { return &v }
+
+func strp(v string) *string { return &v }
+
+func TestKillAttemptIndex(t *testing.T) {
+	defeated := []EncounterBossIn{{MonsterName: "Boss", IsDefeated: true}}
+	cases := []struct {
+		name     string
+		e        EncounterIn
+		want     int
+		wantKill bool
+	}{
+		{
+			name: "kill attempt is not the last one",
+			e: EncounterIn{EncounterBosses: defeated, Attempts: []AttemptIn{
+				{AttemptIndex: 1, BossHpEnd: int64p(5000)},
+				{AttemptIndex: 2, BossHpEnd: int64p(0)},
+				{AttemptIndex: 3, BossHpEnd: int64p(90000)},
+			}},
+			want: 2, wantKill: true,
+		},
+		{
+			name: "no attempt ended in a kill",
+			e: EncounterIn{EncounterBosses: defeated, Attempts: []AttemptIn{
+				{AttemptIndex: 1, BossHpEnd: int64p(5000)},
+				{AttemptIndex: 2, BossHpEnd: int64p(100)},
+			}},
+		},
+		{
+			name: "reason reports the kill",
+			e: EncounterIn{Attempts: []AttemptIn{
+				{AttemptIndex: 1, Reason: strp("wipe")},
+				{AttemptIndex: 2, Reason: strp("Kill")},
+			}},
+			want: 2, wantKill: true,
+		},
+		{
+			name: "single attempt without outcomes uses the defeated boss",
+			e:    EncounterIn{EncounterBosses: defeated, Attempts: []AttemptIn{{AttemptIndex: 1}}},
+			want: 1, wantKill: true,
+		},
+		{
+			name: "several attempts without outcomes are ambiguous",
+			e:    EncounterIn{EncounterBosses: defeated, Attempts: []AttemptIn{{AttemptIndex: 1}, {AttemptIndex: 2}}},
+		},
+	}
+	for _, tc := range cases {
+		got, ok := killAttemptIndex(tc.e)
+		if ok != tc.wantKill || (ok && got != tc.want) {
+			t.Errorf("%s: got (%d, %v), expected (%d, %v)", tc.name, got, ok, tc.want, tc.wantKill)
+		}
+	}
+}
+
+func TestSegmentTotals(t *testing.T) {
+	// Duration and DPS derived from the segment window when omitted
+	_, _, _, bossDmg, dps, dur := segmentTotals(SegmentActorStatIn{DamageDealt: 10000}, 1000, 11000)
+	if dur != 10 || dps != 1000 || bossDmg != 0 {
+		t.Errorf("Expected 10s at 1000 DPS, got %vs at %v DPS", dur, dps)
+	}
+
+	// Client values win
+	d, p := 4.0, 123.0
+	_, _, _, _, dps, dur = segmentTotals(SegmentActorStatIn{DamageDealt: 10000, Duration: &d, DPS: &p, BossDamageDealt: int64p(7)}, 1000, 11000)
+	if dur != 4 || dps != 123 {
+		t.Errorf("Expected the client's duration and DPS, got %vs at %v DPS", dur, dps)
+	}
+
+	// Open segment without a client duration
+	_, _, _, _, dps, dur = segmentTotals(SegmentActorStatIn{DamageDealt: 10000}, 1000, 0)
+	if dur != 0 || dps != 0 {
+		t.Errorf("Expected no duration for an open segment, got %vs at %v DPS", dur, dps)
+	}
+}
@@ -0,0 +1,95 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"strings"
+	"sync"
+	"time"
+
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// Statements records the SQL a dry-run DB generated, in order.
+type Statements struct {
+	mu  sync.Mutex
+	sql []string
+}
+
+// All returns the recorded statements with their bind values inlined.
+func (s *Statements) All() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]string(nil), s.sql...)
+}
+
+// Matching returns the recorded statements containing substr.
+func (s *Statements) Matching(substr string) []string {
+	var out []string
+	for _, q := range s.All() {
+		if strings.Contains(q, substr) {
+			out = append(out, q)
+		}
+	}
+	return out
+}
+
+// DryRun returns a Postgres DB that builds statements without a server: nothing is
+// executed, queries find no rows, and every statement is recorded. Handler and service
+// tests use it to check the SQL they issue.
+func DryRun() (*gorm.DB, *Statements, error) {
+	rec := &Statements{}
+	db, err := gorm.Open(postgres.New(postgres.Config{Conn: dryRunPool{}}), &gorm.Config{
+		DryRun:                 true,
+		DisableAutomaticPing:   true,
+		SkipDefaultTransaction: true,
+		Logger:                 statementLogger{rec},
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+	return db, rec, nil
+}
+
+var errDryRun = errors.New("dry-run database does not execute statements")
+
+// dryRunPool is a connection pool that only supports transactions, which DryRun still
+// opens and closes.
+type dryRunPool struct{}
+
+func (dryRunPool) PrepareContext(context.Context, string) (*sql.Stmt, error) {
+	return nil, errDryRun
+}
+func (dryRunPool) ExecContext(context.Context, string, ...interface{}) (sql.Result, error) {
+	return nil, errDryRun
+}
+func (dryRunPool) QueryContext(context.Context, string, ...interface{}) (*sql.Rows, error) {
+	return nil, errDryRun
+}
+func (dryRunPool) QueryRowContext(context.Context, string, ...interface{}) *sql.Row {
+	return nil
+}
+func (p dryRunPool) BeginTx(context.Context, *sql.TxOptions) (gorm.ConnPool, error) {
+	return p, nil
+}
+func (dryRunPool) Commit() error   { return nil }
+func (dryRunPool) Rollback() error { return nil }
+
+// statementLogger records every traced statement.
+type statementLogger struct {
+	rec *Statements
+}
+
+func (l statementLogger) LogMode(logger.LogLevel) logger.Interface    { return l }
+func (statementLogger) Info(context.Context, string, ...interface{})  {}
+func (statementLogger) Warn(context.Context, string, ...interface{})  {}
+func (statementLogger) Error(context.Context, string, ...interface{}) {}
+func (l statementLogger) Trace(_ context.Context, _ time.Time, fc func() (string, int64), _ error) {
+	q, _ := fc()
+	l.rec.mu.Lock()
+	l.rec.sql = append(l.rec.sql, q)
+	l.rec.mu.Unlock()
+}
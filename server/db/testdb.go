@@ -0,0 +1,51 @@
+package db
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"time"
+
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// ErrNoTestDB is returned by TestDB when TEST_DATABASE_URL is not set.
+var ErrNoTestDB = errors.New("TEST_DATABASE_URL is not set")
+
+// TestDB connects to the Postgres server in TEST_DATABASE_URL with a fresh, empty schema
+// as its search path, and returns a func that drops the schema again. Tests that need
+// real query results (rankings, dedupe) use it and skip without a server; the others use
+// DryRun.
+func TestDB() (*gorm.DB, func(), error) {
+	dsn := os.Getenv("TEST_DATABASE_URL")
+	if dsn == "" {
+		return nil, nil, ErrNoTestDB
+	}
+	db, err := gorm.Open(postgres.Open(dsn), &gorm.Config{Logger: logger.Discard})
+	if err != nil {
+		return nil, nil, err
+	}
+	sqlDB, err := db.DB()
+	if err != nil {
+		return nil, nil, err
+	}
+	// The search path is per connection, so keep to one
+	sqlDB.SetMaxOpenConns(1)
+
+	schema := fmt.Sprintf("test_%d", time.Now().UnixNano())
+	if err := db.Exec("CREATE SCHEMA " + schema).Error; err != nil {
+		sqlDB.Close()
+		return nil, nil, err
+	}
+	drop := func() {
+		db.Exec("DROP SCHEMA " + schema + " CASCADE")
+		sqlDB.Close()
+	}
+	if err := db.Exec("SET search_path TO " + schema).Error; err != nil {
+		drop()
+		return nil, nil, err
+	}
+	return db, drop, nil
+}
@@ -0,0 +1,61 @@
+package lib
+
+import (
+	"math"
+	"sort"
+)
+
+// PhaseWindow is the minimal phase information needed to compute boss-active time.
+type PhaseWindow struct {
+	PhaseType   string
+	StartTimeMs int64
+	EndTimeMs   *int64
+}
+
+// ComputeBossActiveSeconds returns the time covered by boss phases. Phases without an end
+// are closed at encounterEndMs; phases are clipped to the encounter window and overlapping
+// phases are merged, so bad client timestamps cannot produce more boss time than the
+// encounter lasted.
+func ComputeBossActiveSeconds(phases []PhaseWindow, encounterStartMs, encounterEndMs int64) float64 {
+	type interval struct{ start, end int64 }
+	var windows []interval
+	for _, p := range phases {
+		if p.PhaseType != "boss" {
+			continue
+		}
+		start := p.StartTimeMs
+		end := encounterEndMs
+		if p.EndTimeMs != nil {
+			end = *p.EndTimeMs
+		}
+		if start < encounterStartMs {
+			start = encounterStartMs
+		}
+		if encounterEndMs > 0 && end > encounterEndMs {
+			end = encounterEndMs
+		}
+		if end > start {
+			windows = append(windows, interval{start, end})
+		}
+	}
+	sort.Slice(windows, func(i, j int) bool { return windows[i].start < windows[j].start })
+
+	totalMs := int64(0)
+	covered := int64(math.MinInt64) // end of the time counted so far
+	for _, w := range windows {
+		if w.start < covered {
+			w.start = covered
+		}
+		if w.end > w.start {
+			totalMs += w.end - w.start
+			covered = w.end
+		}
+	}
+	return float64(totalMs) / 1000
+}
+
+// BossDurationSQL returns the SQL expression for an encounter's boss-active seconds,
+// falling back to the full duration for encounters without boss phases.
+func BossDurationSQL(encountersAlias string) string {
+	return "COALESCE(NULLIF(" + encountersAlias + ".boss_duration, 0), " + encountersAlias + ".duration)"
+}
@@ -0,0 +1,53 @@
+package lib
+
+import "testing"
+
+func TestComputeBossActiveSeconds_SkipsMobPhases(t *testing.T) {
+	end1 := int64(40000)
+	end2 := int64(100000)
+	phases := []PhaseWindow{
+		{PhaseType: "mob", StartTimeMs: 0, EndTimeMs: &end1},
+		{PhaseType: "boss", StartTimeMs: 40000, EndTimeMs: &end2},
+	}
+
+	got := ComputeBossActiveSeconds(phases, 0, 100000)
+	if got != 60 {
+		t.Errorf("Expected 60s of boss time, got %v", got)
+	}
+}
+
+func TestComputeBossActiveSeconds_OpenAndOutOfRangePhases(t *testing.T) {
+	late := int64(150000)
+	phases := []PhaseWindow{
+		{PhaseType: "boss", StartTimeMs: -5000, EndTimeMs: nil},   // open phase, starts before encounter
+		{PhaseType: "boss", StartTimeMs: 90000, EndTimeMs: &late}, // ends after encounter
+	}
+
+	// First phase clipped to [0, 100000], second to [90000, 100000] and merged into it
+	got := ComputeBossActiveSeconds(phases, 0, 100000)
+	if got != 100 {
+		t.Errorf("Expected 100s of boss time, got %v", got)
+	}
+}
+
+func TestComputeBossActiveSeconds_MergesOverlaps(t *testing.T) {
+	end1, end2, end3 := int64(50000), int64(60000), int64(90000)
+	phases := []PhaseWindow{
+		{PhaseType: "boss", StartTimeMs: 40000, EndTimeMs: &end2}, // overlaps the first
+		{PhaseType: "boss", StartTimeMs: 10000, EndTimeMs: &end1},
+		{PhaseType: "boss", StartTimeMs: 20000, EndTimeMs: &end1}, // contained in the first
+		{PhaseType: "boss", StartTimeMs: 80000, EndTimeMs: &end3},
+	}
+
+	// [10000, 60000] and [80000, 90000]
+	got := ComputeBossActiveSeconds(phases, 0, 100000)
+	if got != 60 {
+		t.Errorf("Expected 60s of boss time, got %v", got)
+	}
+}
+
+func TestComputeBossActiveSeconds_NoBossPhases(t *testing.T) {
+	if got := ComputeBossActiveSeconds(nil, 0, 100000); got != 0 {
+		t.Errorf("Expected 0s without boss phases, got %v", got)
+	}
+}
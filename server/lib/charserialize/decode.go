@@ -0,0 +1,125 @@
+// Package charserialize decodes the CharSerialize snapshot uploaded by the client (stored
+// in detailed_playerdata.char_serialize_json) into typed sections.
+//
+// The snapshot is the client's protobuf message dumped as JSON, and its encoding drifts
+// between client versions: 64-bit integers arrive as strings or numbers, key casing varies,
+// and sections come and go. The decoder therefore works section by section. A section that
+// fails to decode is reported in Document.Warnings and left nil instead of failing the whole
+// snapshot, and typed sections marshal back to the JSON they were decoded from so API
+// responses keep fields this package does not model.
+package charserialize
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// Document is a decoded CharSerialize snapshot. Sections missing from the snapshot, or
+// that could not be decoded, are nil.
+type Document struct {
+	CharBase              *CharBase
+	Equip                 *Equip
+	ProfessionList        *ProfessionList
+	DungeonList           *DungeonList
+	MasterModeDungeonInfo *MasterModeDungeonInfo
+	Mod                   *Mod
+	ItemPackage           *ItemPackage
+
+	// Warnings lists the sections that were present but could not be decoded.
+	Warnings []string
+
+	sections map[string]json.RawMessage
+}
+
+// Decode parses a CharSerialize snapshot. It only fails when the input is not a JSON
+// object; problems inside a section are reported in Document.Warnings.
+func Decode(data []byte) (*Document, error) {
+	var sections map[string]json.RawMessage
+	if err := json.Unmarshal(data, &sections); err != nil {
+		return nil, fmt.Errorf("charserialize: %w", err)
+	}
+	if sections == nil {
+		return nil, errors.New("charserialize: snapshot is not an object")
+	}
+
+	d := &Document{sections: sections}
+	d.CharBase = decodeSection[CharBase](d, "CharBase")
+	d.Equip = decodeSection[Equip](d, "Equip")
+	d.ProfessionList = decodeSection[ProfessionList](d, "ProfessionList")
+	d.DungeonList = decodeSection[DungeonList](d, "DungeonList")
+	d.MasterModeDungeonInfo = decodeSection[MasterModeDungeonInfo](d, "MasterModeDungeonInfo")
+	d.Mod = decodeSection[Mod](d, "Mod")
+	d.ItemPackage = decodeSection[ItemPackage](d, "ItemPackage")
+	return d, nil
+}
+
+// DecodeString is Decode for the string stored in the database.
+func DecodeString(s string) (*Document, error) {
+	return Decode([]byte(s))
+}
+
+// Raw returns a top-level section as sent, matching its name case-insensitively, or nil
+// when the snapshot does not carry it. Sections without a typed model (FightPoint,
+// GashaData, ...) are only available this way.
+func (d *Document) Raw(name string) json.RawMessage {
+	if d == nil {
+		return nil
+	}
+	raw, ok := d.sections[name]
+	if !ok {
+		for key, v := range d.sections {
+			if strings.EqualFold(key, name) {
+				raw = v
+				break
+			}
+		}
+	}
+	if isNull(raw) {
+		return nil
+	}
+	return raw
+}
+
+// rawSetter is implemented by the typed sections through the embedded section.
+type rawSetter interface {
+	setRaw(json.RawMessage)
+}
+
+// section keeps the JSON a typed section was decoded from.
+type section struct {
+	raw json.RawMessage
+}
+
+func (s *section) setRaw(raw json.RawMessage) { s.raw = raw }
+
+// marshalSection returns the original JSON when there is one, otherwise v encoded.
+func marshalSection(raw json.RawMessage, v any) ([]byte, error) {
+	if len(raw) > 0 {
+		return raw, nil
+	}
+	return json.Marshal(v)
+}
+
+func decodeSection[T any](d *Document, name string) *T {
+	raw := d.Raw(name)
+	if raw == nil {
+		return nil
+	}
+	v := new(T)
+	if err := json.Unmarshal(raw, v); err != nil {
+		d.Warnings = append(d.Warnings, fmt.Sprintf("%s: %v", name, err))
+		return nil
+	}
+	if s, ok := any(v).(rawSetter); ok {
+		s.setRaw(raw)
+	}
+	return v
+}
+
+func isNull(raw json.RawMessage) bool {
+	raw = bytes.TrimSpace(raw)
+	return len(raw) == 0 || bytes.Equal(raw, []byte("null"))
+}
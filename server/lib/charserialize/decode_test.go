@@ -0,0 +1,148 @@
+package charserialize
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"sort"
+	"testing"
+)
+
+func loadFixture(t *testing.T, name string) *Document {
+	t.Helper()
+	data, err := os.ReadFile(filepath.Join("testdata", name))
+	if err != nil {
+		t.Fatal(err)
+	}
+	doc, err := Decode(data)
+	if err != nil {
+		t.Fatalf("Decode(%s): %v", name, err)
+	}
+	return doc
+}
+
+func TestDecodeStringEncodedSnapshot(t *testing.T) {
+	doc := loadFixture(t, "snapshot_strings.json")
+	if len(doc.Warnings) != 0 {
+		t.Errorf("Expected no warnings, got %v", doc.Warnings)
+	}
+
+	b := doc.CharBase
+	if b == nil {
+		t.Fatal("Expected CharBase to be decoded")
+	}
+	if b.CharId != "20485731" || b.Name != "Lyra" || b.CreateTime != 1718000000 || b.TotalOnlineTime != 864000 {
+		t.Errorf("Unexpected CharBase %+v", b)
+	}
+	if b.AvatarInfo == nil || b.AvatarInfo.Profile == nil || b.AvatarInfo.Profile.Url == "" {
+		t.Errorf("Expected the profile avatar URL, got %+v", b.AvatarInfo)
+	}
+
+	if slot := doc.Equip.EquipList["200"]; slot.ItemUuid != "9007199254740993" || slot.EquipSlotRefineLevel != 12 {
+		t.Errorf("Unexpected equip slot %+v", slot)
+	}
+	if enchant := doc.Equip.EquipEnchant["200"]; enchant.EnchantType != "EnchantTypeAttack" {
+		t.Errorf("Unexpected enchant %+v", enchant)
+	}
+	if p := doc.ProfessionList.ProfessionList["2"]; doc.ProfessionList.CurProfessionId != 2 || len(p.ActiveSkillIds) != 3 || p.SkillInfoMap["1201"].RemodelLevel != 2 {
+		t.Errorf("Unexpected profession list %+v", doc.ProfessionList)
+	}
+	if d := doc.DungeonList.CompleteDungeon["7001"]; d.PassCount != 14 {
+		t.Errorf("Unexpected dungeon record %+v", d)
+	}
+	if d := doc.MasterModeDungeonInfo.DungeonInfo["7001"]; d.Difficulty != 8 || d.PassCount != 5 {
+		t.Errorf("Unexpected master-mode record %+v", d)
+	}
+	if doc.Raw("FightPoint") == nil || doc.Raw("GashaData") != nil {
+		t.Error("Expected untyped sections to be available raw only when present")
+	}
+}
+
+func TestDecodeToleratesVersionDrift(t *testing.T) {
+	doc := loadFixture(t, "snapshot_numbers.json")
+
+	// lowerCamel keys, numeric IDs, exponent numbers and unknown fields
+	b := doc.CharBase
+	if b == nil || b.CharId != "20485731" || b.Name != "Lyra" || b.TotalOnlineTime != 864000 || b.LastOfflineTime != 0 {
+		t.Errorf("Unexpected CharBase %+v", b)
+	}
+	if doc.Equip != nil {
+		t.Error("Expected a null section to decode as missing")
+	}
+
+	// A section in an unknown shape is dropped with a warning, the rest still decodes
+	if doc.DungeonList != nil {
+		t.Error("Expected the malformed DungeonList to be dropped")
+	}
+	if len(doc.Warnings) != 1 {
+		t.Errorf("Expected one warning, got %v", doc.Warnings)
+	}
+	if modules := doc.Modules(); len(modules) != 1 || modules[0].UUID != "5550001" {
+		t.Errorf("Unexpected modules %+v", modules)
+	}
+}
+
+func TestDecodeRejectsNonObject(t *testing.T) {
+	for _, in := range []string{`[]`, `null`, `"CharBase"`, `{`} {
+		if _, err := Decode([]byte(in)); err == nil {
+			t.Errorf("Expected an error for %s", in)
+		}
+	}
+}
+
+func TestSectionsMarshalAsUploaded(t *testing.T) {
+	doc := loadFixture(t, "snapshot_strings.json")
+	out, err := json.Marshal(doc.Equip)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got map[string]json.RawMessage
+	if err := json.Unmarshal(out, &got); err != nil {
+		t.Fatal(err)
+	}
+	// EquipScore is not part of the typed model
+	if string(got["EquipScore"]) != "3120" {
+		t.Errorf("Expected unmodelled fields to survive, got %s", out)
+	}
+	if string(got["SuitInfoDict"]) != `{"1":{"SuitId":1,"Count":4}}` {
+		t.Errorf("Expected SuitInfoDict to round-trip unchanged, got %s", got["SuitInfoDict"])
+	}
+}
+
+func TestModules(t *testing.T) {
+	doc := loadFixture(t, "snapshot_strings.json")
+
+	modules := doc.Modules()
+	sort.Slice(modules, func(i, j int) bool { return modules[i].Key < modules[j].Key })
+	if len(modules) != 2 {
+		t.Fatalf("Expected 2 modules, got %+v", modules)
+	}
+	if m := modules[0]; m.UUID != "5550001" || m.ConfigID != 5500101 || len(m.Parts) != 2 || m.Parts[1] != (ModulePart{PartID: 2104, Value: 4}) {
+		t.Errorf("Unexpected module %+v", m)
+	}
+
+	equipped, ok := doc.EquippedModules()
+	if !ok || len(equipped) != 1 || equipped[0].Key != "31" {
+		t.Errorf("Expected module 31 to be equipped, got %+v (ok=%v)", equipped, ok)
+	}
+
+	drift := loadFixture(t, "snapshot_numbers.json")
+	if _, ok := drift.EquippedModules(); ok {
+		t.Error("Expected ok=false without slot data")
+	}
+}
+
+func TestDungeonProgress(t *testing.T) {
+	doc := loadFixture(t, "snapshot_strings.json")
+
+	progress := doc.DungeonProgress()
+	want := DungeonProgress{DungeonID: 7001, PassCount: 14, BestTime: 412, MasterSeasonID: 3, MasterDifficulty: 8, MasterPassCount: 5, MasterPassTime: 655}
+	if len(progress) != 1 || progress[0] != want {
+		t.Errorf("Expected %+v, got %+v", want, progress)
+	}
+
+	drift := loadFixture(t, "snapshot_numbers.json")
+	if p := drift.DungeonProgress(); len(p) != 0 {
+		t.Errorf("Expected no progress from a malformed DungeonList, got %+v", p)
+	}
+}
@@ -0,0 +1,66 @@
+package charserialize
+
+// ModulePart is one attribute roll on a module.
+type ModulePart struct {
+	PartID int
+	Value  int
+}
+
+// Module is a module found in the character's inventory.
+type Module struct {
+	Key      string // item key, shared by ItemPackage and Mod.ModInfos
+	UUID     string
+	ConfigID int
+	Quality  int
+	Parts    []ModulePart
+}
+
+// Modules returns every module in the character's inventory with its attribute rolls.
+func (d *Document) Modules() []Module {
+	var modules []Module
+	if d == nil || d.ItemPackage == nil || d.Mod == nil || d.Mod.ModInfos == nil {
+		return modules
+	}
+
+	for _, pkg := range d.ItemPackage.Packages {
+		for key, item := range pkg.Items {
+			// Modules are the items with ModParts and a matching ModInfo
+			if item.ModNewAttr == nil || len(item.ModNewAttr.ModParts) == 0 {
+				continue
+			}
+			modInfo, ok := d.Mod.ModInfos[key]
+			if !ok {
+				continue
+			}
+
+			m := Module{Key: key, UUID: string(item.Uuid), ConfigID: item.ConfigId.Int(), Quality: item.Quality.Int()}
+			for i := 0; i < len(item.ModNewAttr.ModParts) && i < len(modInfo.InitLinkNums); i++ {
+				m.Parts = append(m.Parts, ModulePart{PartID: item.ModNewAttr.ModParts[i].Int(), Value: modInfo.InitLinkNums[i].Int()})
+			}
+			if len(m.Parts) > 0 {
+				modules = append(modules, m)
+			}
+		}
+	}
+	return modules
+}
+
+// EquippedModules returns the modules in the character's equip slots. ok is false when the
+// snapshot carries no slot information, so callers can tell "nothing equipped" from "unknown".
+func (d *Document) EquippedModules() (modules []Module, ok bool) {
+	if d == nil || d.Mod == nil || d.Mod.ModSlots == nil {
+		return nil, false
+	}
+	equipped := make(map[string]bool, len(d.Mod.ModSlots))
+	for _, uuid := range d.Mod.ModSlots {
+		if uuid != "" && uuid != "0" {
+			equipped[string(uuid)] = true
+		}
+	}
+	for _, m := range d.Modules() {
+		if equipped[m.UUID] || equipped[m.Key] {
+			modules = append(modules, m)
+		}
+	}
+	return modules, true
+}
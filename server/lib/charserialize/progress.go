@@ -0,0 +1,73 @@
+package charserialize
+
+import (
+	"sort"
+	"strconv"
+)
+
+// DungeonProgress is the character's record in one dungeon, merged from DungeonList and
+// MasterModeDungeonInfo. Master-mode fields are zero when the dungeon has no master-mode
+// clear.
+type DungeonProgress struct {
+	DungeonID int64
+	PassCount int64
+	BestTime  int64 // best clear time, seconds; 0 when unknown
+
+	MasterSeasonID   int64
+	MasterDifficulty int64 // highest cleared master-mode difficulty
+	MasterPassCount  int64
+	MasterPassTime   int64 // best clear time at MasterDifficulty, seconds
+}
+
+// DungeonProgress returns the character's progress per dungeon, ordered by dungeon ID.
+func (d *Document) DungeonProgress() []DungeonProgress {
+	if d == nil {
+		return nil
+	}
+	byID := map[int64]*DungeonProgress{}
+	get := func(key string, id Int64) *DungeonProgress {
+		dungeonID := int64(id)
+		if dungeonID == 0 {
+			// Older clients only key the records by dungeon ID
+			dungeonID, _ = strconv.ParseInt(key, 10, 64)
+		}
+		if dungeonID == 0 {
+			return nil
+		}
+		p, ok := byID[dungeonID]
+		if !ok {
+			p = &DungeonProgress{DungeonID: dungeonID}
+			byID[dungeonID] = p
+		}
+		return p
+	}
+
+	if d.DungeonList != nil {
+		for key, r := range d.DungeonList.CompleteDungeon {
+			if p := get(key, r.DungeonId); p != nil {
+				p.PassCount = int64(r.PassCount)
+				p.BestTime = int64(r.PassTime)
+			}
+		}
+	}
+	if d.MasterModeDungeonInfo != nil {
+		for key, r := range d.MasterModeDungeonInfo.DungeonInfo {
+			if r.Difficulty <= 0 && r.PassCount <= 0 {
+				continue
+			}
+			if p := get(key, r.DungeonId); p != nil {
+				p.MasterSeasonID = int64(d.MasterModeDungeonInfo.SeasonId)
+				p.MasterDifficulty = int64(r.Difficulty)
+				p.MasterPassCount = int64(r.PassCount)
+				p.MasterPassTime = int64(r.PassTime)
+			}
+		}
+	}
+
+	out := make([]DungeonProgress, 0, len(byID))
+	for _, p := range byID {
+		out = append(out, *p)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].DungeonID < out[j].DungeonID })
+	return out
+}
@@ -0,0 +1,60 @@
+package charserialize
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"math"
+	"strconv"
+)
+
+// Int64 is an integer that accepts both encodings the client uses: a JSON number or a
+// decimal string (protobuf's JSON form of 64-bit fields). null and "" decode to 0.
+type Int64 int64
+
+func (n *Int64) UnmarshalJSON(data []byte) error {
+	s := string(bytes.Trim(bytes.TrimSpace(data), `"`))
+	if s == "" || s == "null" {
+		*n = 0
+		return nil
+	}
+	if v, err := strconv.ParseInt(s, 10, 64); err == nil {
+		*n = Int64(v)
+		return nil
+	}
+	// Exponent or fractional forms, e.g. 1.7e+12 from a JavaScript round trip
+	f, err := strconv.ParseFloat(s, 64)
+	if err != nil || math.IsInf(f, 0) || math.IsNaN(f) {
+		return fmt.Errorf("invalid integer %s", data)
+	}
+	*n = Int64(f)
+	return nil
+}
+
+// Int returns the value as an int.
+func (n Int64) Int() int { return int(n) }
+
+// String is text that may also arrive as a bare JSON number, as identifiers (UUIDs,
+// character IDs) do depending on the client version. null decodes to "".
+type String string
+
+func (s *String) UnmarshalJSON(data []byte) error {
+	data = bytes.TrimSpace(data)
+	switch {
+	case bytes.Equal(data, []byte("null")):
+		*s = ""
+	case len(data) > 0 && data[0] == '"':
+		var v string
+		if err := json.Unmarshal(data, &v); err != nil {
+			return err
+		}
+		*s = String(v)
+	default:
+		var v json.Number
+		if err := json.Unmarshal(data, &v); err != nil {
+			return fmt.Errorf("invalid string %s", data)
+		}
+		*s = String(v)
+	}
+	return nil
+}
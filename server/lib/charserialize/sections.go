@@ -0,0 +1,171 @@
+package charserialize
+
+import "encoding/json"
+
+// Field names follow the client's protobuf messages. encoding/json matches them
+// case-insensitively, which covers clients that send lowerCamel keys. Parts that are
+// passed through without being interpreted stay json.RawMessage.
+
+// CharBase is the character's identity and account timestamps.
+type CharBase struct {
+	section
+	CharId          String      `json:"CharId"`
+	Name            string      `json:"Name"`
+	Level           Int64       `json:"Level"`
+	CreateTime      Int64       `json:"CreateTime"`      // unix seconds
+	TotalOnlineTime Int64       `json:"TotalOnlineTime"` // seconds
+	LastOfflineTime Int64       `json:"LastOfflineTime"` // unix seconds
+	AvatarInfo      *AvatarInfo `json:"AvatarInfo"`
+}
+
+func (b CharBase) MarshalJSON() ([]byte, error) {
+	type plain CharBase
+	return marshalSection(b.raw, plain(b))
+}
+
+// AvatarInfo holds the character's portrait images.
+type AvatarInfo struct {
+	HalfBody *AvatarImage `json:"HalfBody,omitempty"`
+	Profile  *AvatarImage `json:"Profile,omitempty"`
+}
+
+type AvatarImage struct {
+	Url string `json:"Url"`
+}
+
+// Equip is the gear worn by the character, keyed by equip slot.
+type Equip struct {
+	section
+	EquipList    map[string]EquipSlot        `json:"EquipList"`
+	EquipEnchant map[string]EquipEnchantItem `json:"EquipEnchant"`
+	EquipAttr    json.RawMessage             `json:"EquipAttr"`
+	SuitInfoDict json.RawMessage             `json:"SuitInfoDict"`
+}
+
+func (e Equip) MarshalJSON() ([]byte, error) {
+	type plain Equip
+	return marshalSection(e.raw, plain(e))
+}
+
+type EquipSlot struct {
+	EquipSlot                  Int64  `json:"EquipSlot"`
+	ItemUuid                   String `json:"ItemUuid"`
+	EquipSlotRefineLevel       Int64  `json:"EquipSlotRefineLevel"`
+	EquipSlotRefineFailedCount Int64  `json:"EquipSlotRefineFailedCount"`
+}
+
+type EquipEnchantItem struct {
+	EnchantItemTypeId Int64  `json:"EnchantItemTypeId"`
+	EnchantLevel      Int64  `json:"EnchantLevel"`
+	EnchantType       String `json:"EnchantType"`
+}
+
+// ProfessionList is the character's classes with their skills and talents.
+type ProfessionList struct {
+	section
+	CurProfessionId   Int64                      `json:"CurProfessionId"`
+	TotalTalentPoints Int64                      `json:"TotalTalentPoints"`
+	ProfessionList    map[string]ProfessionEntry `json:"ProfessionList"`
+	AoyiSkillInfoMap  map[string]SkillInfo       `json:"AoyiSkillInfoMap"`
+}
+
+func (p ProfessionList) MarshalJSON() ([]byte, error) {
+	type plain ProfessionList
+	return marshalSection(p.raw, plain(p))
+}
+
+type ProfessionEntry struct {
+	ProfessionId     Int64                `json:"ProfessionId"`
+	Level            Int64                `json:"Level"`
+	UseSkinId        Int64                `json:"UseSkinId"`
+	ActiveSkillIds   []Int64              `json:"ActiveSkillIds"`
+	SkillInfoMap     map[string]SkillInfo `json:"SkillInfoMap"`
+	SlotSkillInfoMap map[string]Int64     `json:"SlotSkillInfoMap"`
+	TalentList       json.RawMessage      `json:"TalentList"`
+}
+
+type SkillInfo struct {
+	SkillId         Int64   `json:"SkillId"`
+	Level           Int64   `json:"Level"`
+	RemodelLevel    Int64   `json:"RemodelLevel"`
+	ReplaceSkillIds []Int64 `json:"ReplaceSkillIds"`
+}
+
+// DungeonList is the character's dungeon completion record, keyed by dungeon ID.
+type DungeonList struct {
+	section
+	CompleteDungeon map[string]DungeonRecord `json:"CompleteDungeon"`
+}
+
+func (l DungeonList) MarshalJSON() ([]byte, error) {
+	type plain DungeonList
+	return marshalSection(l.raw, plain(l))
+}
+
+type DungeonRecord struct {
+	DungeonId Int64 `json:"DungeonId"`
+	PassCount Int64 `json:"PassCount"`
+	PassTime  Int64 `json:"PassTime"` // best clear time, seconds
+}
+
+// MasterModeDungeonInfo is the character's master-mode progress, keyed by dungeon ID.
+type MasterModeDungeonInfo struct {
+	section
+	SeasonId    Int64                        `json:"SeasonId"`
+	DungeonInfo map[string]MasterModeDungeon `json:"DungeonInfo"`
+}
+
+func (m MasterModeDungeonInfo) MarshalJSON() ([]byte, error) {
+	type plain MasterModeDungeonInfo
+	return marshalSection(m.raw, plain(m))
+}
+
+type MasterModeDungeon struct {
+	DungeonId  Int64 `json:"DungeonId"`
+	Difficulty Int64 `json:"Difficulty"` // highest cleared difficulty
+	PassCount  Int64 `json:"PassCount"`
+	PassTime   Int64 `json:"PassTime"` // best clear time at that difficulty, seconds
+}
+
+// Mod is the module state: the rolled values per module item and the equipped slots.
+type Mod struct {
+	section
+	ModInfos map[string]ModInfo `json:"ModInfos"`
+	// ModSlots maps an equip slot to the UUID (or item key) of the module in it.
+	ModSlots map[string]String `json:"ModSlots"`
+}
+
+func (m Mod) MarshalJSON() ([]byte, error) {
+	type plain Mod
+	return marshalSection(m.raw, plain(m))
+}
+
+type ModInfo struct {
+	InitLinkNums []Int64 `json:"InitLinkNums"`
+}
+
+// ItemPackage is the character's inventory, keyed by package then item key.
+type ItemPackage struct {
+	section
+	Packages map[string]Package `json:"Packages"`
+}
+
+func (p ItemPackage) MarshalJSON() ([]byte, error) {
+	type plain ItemPackage
+	return marshalSection(p.raw, plain(p))
+}
+
+type Package struct {
+	Items map[string]Item `json:"Items"`
+}
+
+type Item struct {
+	ConfigId   Int64       `json:"ConfigId"`
+	Uuid       String      `json:"Uuid"`
+	Quality    Int64       `json:"Quality"`
+	ModNewAttr *ModNewAttr `json:"ModNewAttr"`
+}
+
+type ModNewAttr struct {
+	ModParts []Int64 `json:"ModParts"`
+}
@@ -0,0 +1,31 @@
+package lib
+
+import "math"
+
+// Pearson returns the Pearson correlation coefficient of xs and ys (equal lengths). It
+// returns 0 when either series is constant or there are fewer than two points.
+func Pearson(xs, ys []float64) float64 {
+	n := len(xs)
+	if n < 2 || len(ys) != n {
+		return 0
+	}
+	var meanX, meanY float64
+	for i := range xs {
+		meanX += xs[i]
+		meanY += ys[i]
+	}
+	meanX /= float64(n)
+	meanY /= float64(n)
+
+	var sxy, sxx, syy float64
+	for i := range xs {
+		dx, dy := xs[i]-meanX, ys[i]-meanY
+		sxy += dx * dy
+		sxx += dx * dx
+		syy += dy * dy
+	}
+	if sxx == 0 || syy == 0 {
+		return 0
+	}
+	return sxy / math.Sqrt(sxx*syy)
+}
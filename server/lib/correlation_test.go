@@ -0,0 +1,19 @@
+package lib
+
+import (
+	"math"
+	"testing"
+)
+
+func TestPearson(t *testing.T) {
+	xs := []float64{1, 2, 3, 4}
+	if got := Pearson(xs, []float64{10, 20, 30, 40}); math.Abs(got-1) > 1e-9 {
+		t.Errorf("Expected 1 for a perfect positive relation, got %v", got)
+	}
+	if got := Pearson(xs, []float64{8, 6, 4, 2}); math.Abs(got+1) > 1e-9 {
+		t.Errorf("Expected -1 for a perfect negative relation, got %v", got)
+	}
+	if got := Pearson(xs, []float64{5, 5, 5, 5}); got != 0 {
+		t.Errorf("Expected 0 for a constant series, got %v", got)
+	}
+}
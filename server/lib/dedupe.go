@@ -26,6 +26,20 @@ type EncounterInput struct {
 // BossInput represents boss data for deduplication
 type BossInput struct {
 	MonsterName string
+	MonsterID   *int64 // set when the game data catalog resolved the name, never from the client
+}
+
+// FingerprintVersion identifies how ComputeEncounterFingerprint builds fingerprints. Bump it
+// when the fingerprint changes so stored ones are recomputed (see migrations).
+const FingerprintVersion = 1
+
+// bossKey identifies a boss across client languages: its catalog monster ID when the name
+// resolved to one, else its normalised name.
+func bossKey(name string, id *int64) string {
+	if id != nil {
+		return fmt.Sprintf("#%d", *id)
+	}
+	return strings.ToLower(strings.TrimSpace(name))
 }
 
 // ActorStatInput represents actor stat data for deduplication
@@ -78,7 +92,7 @@ func ComputeEncounterFingerprint(enc EncounterInput, config DedupeConfig) string
 	// 2. Boss names (sorted, lowercased, trimmed)
 	bossNames := make([]string, 0, len(enc.EncounterBosses))
 	for _, b := range enc.EncounterBosses {
-		bossNames = append(bossNames, strings.ToLower(strings.TrimSpace(b.MonsterName)))
+		bossNames = append(bossNames, bossKey(b.MonsterName, b.MonsterID))
 	}
 	sort.Strings(bossNames)
 	if len(bossNames) > 0 {
@@ -143,6 +157,29 @@ func ComputeEncounterFingerprint(enc EncounterInput, config DedupeConfig) string
 	return hex.EncodeToString(hash[:])
 }
 
+// EncounterInputFromModel rebuilds the dedupe input of a stored encounter with its Bosses,
+// Players and Attempts loaded, to recompute its fingerprint.
+func EncounterInputFromModel(enc models.Encounter) EncounterInput {
+	bosses := make([]BossInput, len(enc.Bosses))
+	for i, b := range enc.Bosses {
+		bosses[i] = BossInput{MonsterName: b.MonsterName, MonsterID: b.MonsterID}
+	}
+	actors := make([]ActorStatInput, len(enc.Players))
+	for i, a := range enc.Players {
+		actors[i] = ActorStatInput{ActorID: a.ActorID, DamageDealt: a.DamageDealt, IsPlayer: a.IsPlayer}
+	}
+	totalDmg := enc.TotalDmg
+	return EncounterInput{
+		StartedAtMs:         enc.StartedAt.UnixMilli(),
+		TotalDmg:            &totalDmg,
+		SceneID:             enc.SceneID,
+		SceneName:           enc.SceneName,
+		EncounterBosses:     bosses,
+		ActorEncounterStats: actors,
+		AttemptsCount:       len(enc.Attempts),
+	}
+}
+
 // ComputePlayerSetHash computes a deterministic hash of just the sorted player ActorIDs
 // This is used for fast candidate lookup when searching for potential fuzzy duplicates
 func ComputePlayerSetHash(enc EncounterInput) string {
@@ -253,16 +290,16 @@ func ComputeFuzzySimilarity(enc1 EncounterInput, enc2Preloaded models.Encounter)
 		sim.SceneMatch = false
 	}
 
-	// Boss match (compare sorted boss names)
+	// Boss match (compare sorted boss IDs, or names for bosses without one)
 	bossNames1 := make([]string, 0, len(enc1.EncounterBosses))
 	for _, b := range enc1.EncounterBosses {
-		bossNames1 = append(bossNames1, strings.ToLower(strings.TrimSpace(b.MonsterName)))
+		bossNames1 = append(bossNames1, bossKey(b.MonsterName, b.MonsterID))
 	}
 	sort.Strings(bossNames1)
 
 	bossNames2 := make([]string, 0, len(enc2Preloaded.Bosses))
 	for _, b := range enc2Preloaded.Bosses {
-		bossNames2 = append(bossNames2, strings.ToLower(strings.TrimSpace(b.MonsterName)))
+		bossNames2 = append(bossNames2, bossKey(b.MonsterName, b.MonsterID))
 	}
 	sort.Strings(bossNames2)
 
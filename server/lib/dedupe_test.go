@@ -133,6 +133,38 @@ func TestComputeEncounterFingerprint_BossOrderIndependent(t *testing.T) {
 	}
 }
 
+func TestComputeEncounterFingerprint_BossIDAcrossLanguages(t *testing.T) {
+	config := DefaultDedupeConfig()
+
+	sceneID := int64(101)
+	monsterID := int64(5001)
+	totalDmg := int64(10000)
+	startMs := time.Now().UnixMilli()
+
+	// Same fight uploaded from clients in different languages
+	enc := func(bossName string) EncounterInput {
+		return EncounterInput{
+			StartedAtMs: startMs,
+			TotalDmg:    &totalDmg,
+			SceneID:     &sceneID,
+			EncounterBosses: []BossInput{
+				{MonsterName: bossName, MonsterID: &monsterID},
+			},
+			ActorEncounterStats: []ActorStatInput{
+				{ActorID: 1001, DamageDealt: 10000, IsPlayer: true},
+			},
+			AttemptsCount: 1,
+		}
+	}
+
+	fp1 := ComputeEncounterFingerprint(enc("Ice Dragon"), config)
+	fp2 := ComputeEncounterFingerprint(enc("冰龙"), config)
+
+	if fp1 != fp2 {
+		t.Errorf("Fingerprint should match bosses by monster ID: %s != %s", fp1, fp2)
+	}
+}
+
 func TestComputeEncounterFingerprint_DifferentStartTimeBucket(t *testing.T) {
 	config := DefaultDedupeConfig()
 	config.StartTimeBucketSeconds = 30
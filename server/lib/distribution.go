@@ -0,0 +1,139 @@
+package lib
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"sort"
+)
+
+// Distribution is a metric's values in ascending order: the exact order statistics the
+// live statistics queries read, so quantiles taken from it equal Postgres'
+// percentile_cont over the same samples. Distributions of disjoint sample sets merge
+// into the distribution of their union.
+type Distribution struct {
+	values []float64
+}
+
+// NewDistribution returns the distribution of values, which need not be sorted.
+func NewDistribution(values ...float64) Distribution {
+	d := Distribution{values: append([]float64(nil), values...)}
+	sort.Float64s(d.values)
+	return d
+}
+
+// Add records one value.
+func (d *Distribution) Add(v float64) {
+	i := sort.SearchFloat64s(d.values, v)
+	d.values = append(d.values, 0)
+	copy(d.values[i+1:], d.values[i:])
+	d.values[i] = v
+}
+
+// Merge adds another distribution's values.
+func (d *Distribution) Merge(o Distribution) {
+	if len(o.values) == 0 {
+		return
+	}
+	if len(d.values) == 0 {
+		d.values = append([]float64(nil), o.values...)
+		return
+	}
+	merged := make([]float64, 0, len(d.values)+len(o.values))
+	i, j := 0, 0
+	for i < len(d.values) && j < len(o.values) {
+		if o.values[j] < d.values[i] {
+			merged = append(merged, o.values[j])
+			j++
+		} else {
+			merged = append(merged, d.values[i])
+			i++
+		}
+	}
+	merged = append(merged, d.values[i:]...)
+	d.values = append(merged, o.values[j:]...)
+}
+
+// Count is the number of values.
+func (d Distribution) Count() int64 {
+	return int64(len(d.values))
+}
+
+// Mean is the mean of the values, or 0 without any.
+func (d Distribution) Mean() float64 {
+	if len(d.values) == 0 {
+		return 0
+	}
+	sum := 0.0
+	for _, v := range d.values {
+		sum += v
+	}
+	return sum / float64(len(d.values))
+}
+
+// Min is the smallest value, or 0 without any.
+func (d Distribution) Min() float64 {
+	if len(d.values) == 0 {
+		return 0
+	}
+	return d.values[0]
+}
+
+// Max is the largest value, or 0 without any.
+func (d Distribution) Max() float64 {
+	if len(d.values) == 0 {
+		return 0
+	}
+	return d.values[len(d.values)-1]
+}
+
+// ValueAtRank is the rank-th smallest value (1-based, clamped to the values), or 0
+// without any.
+func (d Distribution) ValueAtRank(rank int64) float64 {
+	if len(d.values) == 0 {
+		return 0
+	}
+	rank = min(max(rank, 1), int64(len(d.values)))
+	return d.values[rank-1]
+}
+
+// Quantile is the q-quantile, interpolated between ranks like percentile_cont, or 0
+// without any values.
+func (d Distribution) Quantile(q float64) float64 {
+	if len(d.values) == 0 {
+		return 0
+	}
+	pos := q * float64(len(d.values)-1)
+	lo := math.Floor(pos)
+	v := d.values[int(lo)]
+	if frac := pos - lo; frac > 0 {
+		v += (d.values[int(lo)+1] - v) * frac
+	}
+	return v
+}
+
+// Each calls fn with every value, in ascending order.
+func (d Distribution) Each(fn func(v float64)) {
+	for _, v := range d.values {
+		fn(v)
+	}
+}
+
+// MarshalJSON encodes the values as a JSON array, as they are stored in the database.
+func (d Distribution) MarshalJSON() ([]byte, error) {
+	if d.values == nil {
+		return []byte("[]"), nil
+	}
+	return json.Marshal(d.values)
+}
+
+// UnmarshalJSON decodes a JSON array of values in any order.
+func (d *Distribution) UnmarshalJSON(raw []byte) error {
+	var values []float64
+	if err := json.Unmarshal(raw, &values); err != nil {
+		return fmt.Errorf("invalid distribution: %w", err)
+	}
+	sort.Float64s(values)
+	d.values = values
+	return nil
+}
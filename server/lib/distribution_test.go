@@ -0,0 +1,87 @@
+package lib
+
+import (
+	"encoding/json"
+	"math"
+	"math/rand"
+	"sort"
+	"testing"
+)
+
+// exactQuantile is Postgres' percentile_cont over the samples.
+func exactQuantile(samples []float64, q float64) float64 {
+	sorted := append([]float64(nil), samples...)
+	sort.Float64s(sorted)
+	pos := q * float64(len(sorted)-1)
+	lo := math.Floor(pos)
+	v := sorted[int(lo)]
+	if frac := pos - lo; frac > 0 {
+		v += (sorted[int(lo)+1] - v) * frac
+	}
+	return v
+}
+
+func distributionSamples(n int, seed int64) []float64 {
+	r := rand.New(rand.NewSource(seed))
+	out := make([]float64, n)
+	for i := range out {
+		if i%10 == 0 {
+			out[i] = 0 // e.g. HPS of non-healers
+			continue
+		}
+		out[i] = math.Exp(r.NormFloat64()*0.8 + 9)
+	}
+	return out
+}
+
+func TestDistribution_QuantilesMatchPercentileCont(t *testing.T) {
+	samples := distributionSamples(5000, 1)
+	d := NewDistribution(samples...)
+	for _, q := range []float64{0, 0.05, 0.25, 0.5, 0.75, 0.95, 1} {
+		if got, want := d.Quantile(q), exactQuantile(samples, q); got != want {
+			t.Errorf("Quantile(%v) = %v, want %v", q, got, want)
+		}
+	}
+	if d.Count() != 5000 || d.Min() != 0 || d.ValueAtRank(1) != 0 || d.ValueAtRank(5000) != d.Max() {
+		t.Errorf("Unexpected count or extremes: %d, %v..%v", d.Count(), d.Min(), d.Max())
+	}
+}
+
+func TestDistribution_MergeMatchesSingleDistribution(t *testing.T) {
+	samples := distributionSamples(3000, 2)
+	whole := NewDistribution(samples...)
+	var a Distribution
+	b := NewDistribution(samples[1000:]...)
+	for _, v := range samples[:1000] {
+		a.Add(v)
+	}
+	a.Merge(b)
+	if a.Count() != whole.Count() {
+		t.Fatalf("Merged count %d, want %d", a.Count(), whole.Count())
+	}
+	for rank := int64(1); rank <= whole.Count(); rank++ {
+		if a.ValueAtRank(rank) != whole.ValueAtRank(rank) {
+			t.Fatalf("Rank %d: %v, want %v", rank, a.ValueAtRank(rank), whole.ValueAtRank(rank))
+		}
+	}
+}
+
+func TestDistribution_JSONRoundTrip(t *testing.T) {
+	d := NewDistribution(distributionSamples(500, 3)...)
+	raw, err := json.Marshal(d)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var back Distribution
+	if err := json.Unmarshal(raw, &back); err != nil {
+		t.Fatal(err)
+	}
+	for _, q := range []float64{0.25, 0.5, 0.75} {
+		if back.Quantile(q) != d.Quantile(q) {
+			t.Errorf("Quantile(%v) changed in the round trip: %v, want %v", q, back.Quantile(q), d.Quantile(q))
+		}
+	}
+	if raw, _ := json.Marshal(Distribution{}); string(raw) != "[]" {
+		t.Errorf("Expected an empty distribution to encode as [], got %s", raw)
+	}
+}
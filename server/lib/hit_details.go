@@ -0,0 +1,82 @@
+package lib
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+)
+
+// MaxHitDetails caps the timeline stored per skill row. Longer timelines keep their latest
+// hits, which are the ones death recaps read.
+const MaxHitDetails = 2000
+
+// HitDetail represents a single timestamped hit stored in DamageSkillStat.HitDetails.
+// The desktop client records one entry per hit when timeline capture is enabled.
+type HitDetail struct {
+	TimestampMs int64 `json:"timestampMs"`
+	Value       int64 `json:"value"`
+	IsCrit      bool  `json:"isCrit"`
+	IsLucky     bool  `json:"isLucky"`
+	HpLoss      int64 `json:"hpLoss"`
+	ShieldLoss  int64 `json:"shieldLoss"`
+}
+
+// ParseHitDetails decodes the hit_details JSON column. Empty or null input yields no hits.
+func ParseHitDetails(raw []byte) ([]HitDetail, error) {
+	if len(raw) == 0 || string(raw) == "null" {
+		return nil, nil
+	}
+
+	var hits []HitDetail
+	if err := json.Unmarshal(raw, &hits); err != nil {
+		return nil, err
+	}
+	return hits, nil
+}
+
+// NormalizeHitDetails validates an uploaded hit timeline and returns it re-encoded with
+// only the known fields, capped at MaxHitDetails hits. Empty or null input yields nil.
+func NormalizeHitDetails(raw []byte) ([]byte, error) {
+	hits, err := ParseHitDetails(raw)
+	if err != nil {
+		return nil, fmt.Errorf("invalid hitDetails: %w", err)
+	}
+	if len(hits) == 0 {
+		return nil, nil
+	}
+	if len(hits) > MaxHitDetails {
+		sort.SliceStable(hits, func(i, j int) bool { return hits[i].TimestampMs < hits[j].TimestampMs })
+		hits = hits[len(hits)-MaxHitDetails:]
+	}
+	return json.Marshal(hits)
+}
+
+// LastHitAtOrBefore returns the latest hit at or before endMs.
+func LastHitAtOrBefore(hits []HitDetail, endMs int64) (HitDetail, bool) {
+	var last HitDetail
+	found := false
+	for _, h := range hits {
+		if h.TimestampMs <= endMs && (!found || h.TimestampMs >= last.TimestampMs) {
+			last = h
+			found = true
+		}
+	}
+	return last, found
+}
+
+// HitsInWindow returns the hits whose timestamp falls within (endMs - windowMs, endMs],
+// sorted by timestamp ascending
+func HitsInWindow(hits []HitDetail, endMs int64, windowMs int64) []HitDetail {
+	startMs := endMs - windowMs
+	out := make([]HitDetail, 0)
+	for _, h := range hits {
+		if h.TimestampMs > startMs && h.TimestampMs <= endMs {
+			out = append(out, h)
+		}
+	}
+
+	sort.SliceStable(out, func(i, j int) bool {
+		return out[i].TimestampMs < out[j].TimestampMs
+	})
+	return out
+}
@@ -0,0 +1,85 @@
+package lib
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestParseHitDetails_EmptyAndNull(t *testing.T) {
+	for _, raw := range [][]byte{nil, []byte(""), []byte("null")} {
+		hits, err := ParseHitDetails(raw)
+		if err != nil {
+			t.Errorf("Expected no error for %q, got %v", raw, err)
+		}
+		if len(hits) != 0 {
+			t.Errorf("Expected no hits for %q, got %d", raw, len(hits))
+		}
+	}
+}
+
+func TestHitsInWindow_FiltersAndSorts(t *testing.T) {
+	raw := []byte(`[
+		{"timestampMs": 10500, "value": 300},
+		{"timestampMs": 4000, "value": 100},
+		{"timestampMs": 9000, "value": 200, "isCrit": true},
+		{"timestampMs": 5000, "value": 50},
+		{"timestampMs": 10000, "value": 400}
+	]`)
+	hits, err := ParseHitDetails(raw)
+	if err != nil {
+		t.Fatalf("Failed to parse hit details: %v", err)
+	}
+
+	// Window (5000, 10000]: the hit at exactly 5000 and the one after the death are excluded
+	window := HitsInWindow(hits, 10000, 5000)
+	if len(window) != 2 {
+		t.Fatalf("Expected 2 hits in window, got %d", len(window))
+	}
+	if window[0].TimestampMs != 9000 || window[1].TimestampMs != 10000 {
+		t.Errorf("Hits should be sorted by timestamp, got %d then %d", window[0].TimestampMs, window[1].TimestampMs)
+	}
+	if !window[0].IsCrit {
+		t.Errorf("Crit flag should be preserved")
+	}
+}
+
+func TestNormalizeHitDetails(t *testing.T) {
+	if _, err := NormalizeHitDetails([]byte(`{"timestampMs": 1}`)); err == nil {
+		t.Error("Expected an error for a non-array timeline")
+	}
+	if out, err := NormalizeHitDetails([]byte("null")); err != nil || out != nil {
+		t.Errorf("Expected nil for null, got %s (%v)", out, err)
+	}
+
+	out, err := NormalizeHitDetails([]byte(`[{"timestampMs": 5, "value": 10, "extra": "dropped"}]`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(out) != `[{"timestampMs":5,"value":10,"isCrit":false,"isLucky":false,"hpLoss":0,"shieldLoss":0}]` {
+		t.Errorf("Unexpected normalised timeline %s", out)
+	}
+
+	long := make([]HitDetail, MaxHitDetails+10)
+	for i := range long {
+		long[len(long)-1-i] = HitDetail{TimestampMs: int64(i)}
+	}
+	raw, _ := json.Marshal(long)
+	out, err = NormalizeHitDetails(raw)
+	if err != nil {
+		t.Fatal(err)
+	}
+	hits, _ := ParseHitDetails(out)
+	if len(hits) != MaxHitDetails || hits[0].TimestampMs != 10 {
+		t.Errorf("Expected the latest %d hits, got %d starting at %d", MaxHitDetails, len(hits), hits[0].TimestampMs)
+	}
+}
+
+func TestLastHitAtOrBefore(t *testing.T) {
+	hits := []HitDetail{{TimestampMs: 9000, Value: 1}, {TimestampMs: 12000, Value: 3}, {TimestampMs: 10000, Value: 2}}
+	if h, ok := LastHitAtOrBefore(hits, 10000); !ok || h.Value != 2 {
+		t.Errorf("Expected the hit at 10000, got %+v (ok=%v)", h, ok)
+	}
+	if _, ok := LastHitAtOrBefore(hits, 8000); ok {
+		t.Error("Expected no hit before the first one")
+	}
+}
@@ -0,0 +1,57 @@
+package lib
+
+import (
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// MatchAcceptLanguage picks the supported locale best matching an Accept-Language header,
+// or "" when none matches (callers then use their default language). Tags are tried in
+// order of quality; each matches a supported locale exactly, then by primary language
+// ("zh-TW" matches "zh", "ja" matches "ja-JP"). Matching is case-insensitive and the
+// supported spelling is returned.
+func MatchAcceptLanguage(header string, supported []string) string {
+	type tag struct {
+		lang string
+		q    float64
+	}
+	var tags []tag
+	for _, part := range strings.Split(header, ",") {
+		fields := strings.Split(part, ";")
+		lang := strings.ToLower(strings.TrimSpace(fields[0]))
+		if lang == "" || lang == "*" {
+			continue
+		}
+		q := 1.0
+		for _, param := range fields[1:] {
+			if v, ok := strings.CutPrefix(strings.TrimSpace(param), "q="); ok {
+				if f, err := strconv.ParseFloat(v, 64); err == nil {
+					q = f
+				}
+			}
+		}
+		if q > 0 {
+			tags = append(tags, tag{lang: strings.ReplaceAll(lang, "_", "-"), q: q})
+		}
+	}
+	sort.SliceStable(tags, func(i, j int) bool { return tags[i].q > tags[j].q })
+
+	primary := func(s string) string {
+		p, _, _ := strings.Cut(s, "-")
+		return p
+	}
+	for _, t := range tags {
+		for _, s := range supported {
+			if strings.EqualFold(s, t.lang) {
+				return s
+			}
+		}
+		for _, s := range supported {
+			if strings.EqualFold(primary(s), primary(t.lang)) {
+				return s
+			}
+		}
+	}
+	return ""
+}
@@ -0,0 +1,25 @@
+package lib
+
+import "testing"
+
+func TestMatchAcceptLanguage(t *testing.T) {
+	supported := []string{"en", "zh-CN", "ja"}
+	cases := []struct {
+		header, want string
+	}{
+		{"", ""},
+		{"fr-FR,fr;q=0.9", ""},
+		{"ja", "ja"},
+		{"zh-cn", "zh-CN"},
+		{"zh-TW,zh;q=0.9", "zh-CN"},
+		{"ja-JP", "ja"},
+		{"fr;q=0.9, ja;q=0.5, en;q=0.8", "en"},
+		{"en;q=0, ja", "ja"},
+		{"*", ""},
+	}
+	for _, tc := range cases {
+		if got := MatchAcceptLanguage(tc.header, supported); got != tc.want {
+			t.Errorf("MatchAcceptLanguage(%q) = %q, expected %q", tc.header, got, tc.want)
+		}
+	}
+}
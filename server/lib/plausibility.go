@@ -0,0 +1,91 @@
+package lib
+
+import "fmt"
+
+// PlausibilityFlag is one reason an upload looks tampered with or broken.
+type PlausibilityFlag struct {
+	Code    string `json:"code"`
+	Message string `json:"message"`
+}
+
+// PlausibilityEncounter is the encounter-level input to CheckEncounterConsistency.
+type PlausibilityEncounter struct {
+	Duration float64 // seconds
+	TotalDmg int64
+}
+
+// PlausibilityActor is the per-actor input to CheckEncounterConsistency.
+type PlausibilityActor struct {
+	Name            string
+	IsPlayer        bool
+	DamageDealt     int64
+	HealDealt       int64
+	DamageTaken     int64
+	BossDamageDealt int64
+	HitsDealt       int64
+	CritHitsDealt   int64
+	LuckyHitsDealt  int64
+	CritTotalDealt  int64
+	LuckyTotalDealt int64
+	DPS             float64
+	Duration        float64 // seconds
+}
+
+// dpsMismatchFactor is how far a reported DPS may exceed damage over duration before
+// it is flagged. Clients compute DPS over slightly different windows, so this is loose.
+const dpsMismatchFactor = 2
+
+// CheckEncounterConsistency applies internal consistency rules to an upload: values that
+// contradict each other (more crits than hits, boss damage above total damage, a DPS that
+// damage and duration can't produce, ...). It returns nil for a consistent encounter.
+func CheckEncounterConsistency(enc PlausibilityEncounter, actors []PlausibilityActor) []PlausibilityFlag {
+	var flags []PlausibilityFlag
+	add := func(code, format string, args ...interface{}) {
+		flags = append(flags, PlausibilityFlag{Code: code, Message: fmt.Sprintf(format, args...)})
+	}
+
+	if enc.Duration < 0 || enc.TotalDmg < 0 {
+		add("negative_value", "encounter has a negative duration or total damage")
+	}
+
+	var playerDamage int64
+	for _, a := range actors {
+		if a.DamageDealt < 0 || a.HealDealt < 0 || a.DamageTaken < 0 || a.BossDamageDealt < 0 ||
+			a.HitsDealt < 0 || a.CritHitsDealt < 0 || a.LuckyHitsDealt < 0 || a.DPS < 0 || a.Duration < 0 {
+			add("negative_value", "%s has negative stats", a.Name)
+			continue
+		}
+		if a.BossDamageDealt > a.DamageDealt {
+			add("boss_damage_exceeds_damage", "%s dealt more boss damage (%d) than damage (%d)", a.Name, a.BossDamageDealt, a.DamageDealt)
+		}
+		if a.CritHitsDealt > a.HitsDealt || a.LuckyHitsDealt > a.HitsDealt {
+			add("crit_hits_exceed_hits", "%s has more crit or lucky hits than hits (%d)", a.Name, a.HitsDealt)
+		}
+		if a.CritTotalDealt > a.DamageDealt || a.LuckyTotalDealt > a.DamageDealt {
+			add("crit_damage_exceeds_damage", "%s has more crit or lucky damage than damage (%d)", a.Name, a.DamageDealt)
+		}
+		if enc.Duration > 0 && a.Duration > enc.Duration*1.05+1 {
+			add("actor_duration_exceeds_encounter", "%s was active %.0fs in a %.0fs encounter", a.Name, a.Duration, enc.Duration)
+		}
+
+		// The shortest plausible window gives the highest DPS the damage can explain
+		window := enc.Duration
+		if a.Duration > 0 && (window <= 0 || a.Duration < window) {
+			window = a.Duration
+		}
+		if window > 0 && a.DPS > 1 {
+			if maxDPS := float64(a.DamageDealt) / window; a.DPS > maxDPS*dpsMismatchFactor {
+				add("dps_mismatch", "%s reports %.0f DPS but dealt %d damage in %.0fs", a.Name, a.DPS, a.DamageDealt, window)
+			}
+		}
+
+		if a.IsPlayer {
+			playerDamage += a.DamageDealt
+		}
+	}
+
+	if enc.TotalDmg > 0 && float64(playerDamage) > float64(enc.TotalDmg)*1.01 {
+		add("player_damage_exceeds_total", "players dealt %d damage but the encounter total is %d", playerDamage, enc.TotalDmg)
+	}
+	return flags
+}
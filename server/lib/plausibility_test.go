@@ -0,0 +1,34 @@
+package lib
+
+import "testing"
+
+func TestCheckEncounterConsistency_ConsistentUpload(t *testing.T) {
+	enc := PlausibilityEncounter{Duration: 100, TotalDmg: 2000000}
+	actors := []PlausibilityActor{
+		{Name: "A", IsPlayer: true, DamageDealt: 1000000, BossDamageDealt: 800000, HitsDealt: 500, CritHitsDealt: 200, CritTotalDealt: 400000, DPS: 10500, Duration: 95},
+		{Name: "B", IsPlayer: true, DamageDealt: 900000, HitsDealt: 400, DPS: 9000, Duration: 100},
+	}
+
+	if flags := CheckEncounterConsistency(enc, actors); len(flags) != 0 {
+		t.Errorf("Expected no flags, got %+v", flags)
+	}
+}
+
+func TestCheckEncounterConsistency_TamperedUpload(t *testing.T) {
+	enc := PlausibilityEncounter{Duration: 100, TotalDmg: 1000000}
+	actors := []PlausibilityActor{
+		// DPS edited up without touching damage; boss damage above damage
+		{Name: "A", IsPlayer: true, DamageDealt: 900000, BossDamageDealt: 950000, HitsDealt: 100, CritHitsDealt: 150, DPS: 90000, Duration: 100},
+		{Name: "B", IsPlayer: true, DamageDealt: 200000, HitsDealt: 50, DPS: 2000, Duration: 100},
+	}
+
+	codes := map[string]bool{}
+	for _, f := range CheckEncounterConsistency(enc, actors) {
+		codes[f.Code] = true
+	}
+	for _, want := range []string{"dps_mismatch", "boss_damage_exceeds_damage", "crit_hits_exceed_hits", "player_damage_exceeds_total"} {
+		if !codes[want] {
+			t.Errorf("Expected flag %q, got %v", want, codes)
+		}
+	}
+}
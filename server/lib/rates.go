@@ -0,0 +1,14 @@
+package lib
+
+// ActorDurationSQL returns the SQL expression for an actor's active seconds in an
+// encounter, falling back to the encounter's duration when the client did not report one.
+func ActorDurationSQL(actorsAlias, encountersAlias string) string {
+	return "COALESCE(NULLIF(" + actorsAlias + ".duration, 0), " + encountersAlias + ".duration)"
+}
+
+// ActorRateSQL returns the SQL expression for valueColumn per second of the actor's
+// active time, the same basis the client uses for an actor's DPS.
+func ActorRateSQL(valueColumn, actorsAlias, encountersAlias string) string {
+	dur := ActorDurationSQL(actorsAlias, encountersAlias)
+	return "(CASE WHEN " + dur + " > 0 THEN " + valueColumn + "::double precision / " + dur + " ELSE 0 END)"
+}
@@ -0,0 +1,51 @@
+package lib
+
+import "strings"
+
+// Segment selects which part of an encounter actor stats are reported for.
+const (
+	SegmentAll  = "all"  // whole encounter (actor_encounter_stats)
+	SegmentKill = "kill" // the successful attempt only (attempt_actor_stats.is_kill)
+	SegmentBoss = "boss" // boss phases only, summed per actor (phase_actor_stats)
+)
+
+// ParseSegment normalises a segment query value. Empty input means SegmentAll.
+func ParseSegment(v string) (string, bool) {
+	switch strings.ToLower(strings.TrimSpace(v)) {
+	case "", SegmentAll, "encounter":
+		return SegmentAll, true
+	case SegmentKill, "kill_attempt":
+		return SegmentKill, true
+	case SegmentBoss, "boss_phase":
+		return SegmentBoss, true
+	}
+	return "", false
+}
+
+// SegmentActorStatsSQL returns a SELECT yielding one row per (encounter_id, actor_id) for the
+// given segment, with the columns encounter_id, actor_id, name, class_id, class_spec,
+// ability_score, is_player, damage_dealt, heal_dealt, damage_taken, boss_damage_dealt,
+// duration and dps. filter restricts the segment rows before the boss phases are summed,
+// so callers never aggregate the whole table: bind an encounter ("encounter_id = ?") or
+// correlate with an outer row and join the result LATERAL. It returns "" for SegmentAll.
+func SegmentActorStatsSQL(segment, filter string) string {
+	switch segment {
+	case SegmentKill:
+		return `
+			SELECT encounter_id, actor_id, name, class_id, class_spec, ability_score, is_player,
+				   damage_dealt, heal_dealt, damage_taken, boss_damage_dealt, duration, dps
+			FROM attempt_actor_stats
+			WHERE is_kill = true AND (` + filter + `)`
+	case SegmentBoss:
+		return `
+			SELECT encounter_id, actor_id, MAX(name) AS name, MAX(class_id) AS class_id,
+				   MAX(class_spec) AS class_spec, MAX(ability_score) AS ability_score, bool_or(is_player) AS is_player,
+				   SUM(damage_dealt) AS damage_dealt, SUM(heal_dealt) AS heal_dealt, SUM(damage_taken) AS damage_taken,
+				   SUM(boss_damage_dealt) AS boss_damage_dealt, SUM(duration) AS duration,
+				   (CASE WHEN SUM(duration) > 0 THEN SUM(damage_dealt)::double precision / SUM(duration) ELSE 0 END) AS dps
+			FROM phase_actor_stats
+			WHERE phase_type = 'boss' AND (` + filter + `)
+			GROUP BY encounter_id, actor_id`
+	}
+	return ""
+}
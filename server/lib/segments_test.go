@@ -0,0 +1,38 @@
+package lib
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestParseSegment(t *testing.T) {
+	cases := map[string]string{
+		"": SegmentAll, "encounter": SegmentAll, " ALL ": SegmentAll,
+		"kill": SegmentKill, "kill_attempt": SegmentKill,
+		"boss": SegmentBoss, "Boss_Phase": SegmentBoss,
+	}
+	for in, want := range cases {
+		if got, ok := ParseSegment(in); !ok || got != want {
+			t.Errorf("ParseSegment(%q) = %q, %v; expected %q", in, got, ok, want)
+		}
+	}
+	if _, ok := ParseSegment("trash"); ok {
+		t.Error("Expected an unknown segment to be rejected")
+	}
+}
+
+func TestSegmentActorStatsSQLAppliesFilter(t *testing.T) {
+	if SegmentActorStatsSQL(SegmentAll, "encounter_id = ?") != "" {
+		t.Error("Expected no subquery for the whole encounter")
+	}
+	for _, seg := range []string{SegmentKill, SegmentBoss} {
+		sql := SegmentActorStatsSQL(seg, "encounter_id = ?")
+		where := strings.Index(sql, "(encounter_id = ?)")
+		if where < 0 {
+			t.Errorf("%s: filter missing from %s", seg, sql)
+		}
+		if group := strings.Index(sql, "GROUP BY"); group >= 0 && group < where {
+			t.Errorf("%s: filter must apply before grouping", seg)
+		}
+	}
+}
@@ -4,11 +4,18 @@ import (
 	"log"
 	"os"
 	"path/filepath"
+	"time"
 
 	"server/db"
 	"server/middleware"
 	"server/migrations"
 	"server/routes"
+	"server/services/builds"
+	"server/services/gamedata"
+	"server/services/parses"
+	"server/services/progress"
+	"server/services/rollups"
+	"server/services/scenes"
 
 	"github.com/gin-contrib/cors"
 	"github.com/gin-gonic/gin"
@@ -27,6 +34,12 @@ func main() {
 	}
 	defer middleware.CloseRedis()
 
+	// Static reference tables: skills and classes are built in, scenes, monsters and talents
+	// come from GAMEDATA_DIR, which also overrides the built-in ones
+	if err := gamedata.Init(); err != nil {
+		log.Printf("Game data warning: %v (using built-in tables)", err)
+	}
+
 	router := gin.Default()
 
 	dbConn, err := db.InitDB()
@@ -38,6 +51,67 @@ func main() {
 		if err := migrations.RunMigrations(dbConn); err != nil {
 			log.Printf("Migration warning: %v", err)
 		}
+
+		// When the catalog's scenes or monsters changed, resolve names stored before,
+		// link scenes of encounters uploaded before scenes were tracked and apply the
+		// catalog's names and bosses; encounters moved to a scene change rollup and parse
+		// groups
+		resolved, err := scenes.NewSceneService(dbConn).SyncCatalog(gamedata.Get())
+		if err != nil {
+			log.Printf("Scene catalog warning: %v", err)
+		}
+		if len(resolved) > 0 {
+			go func() {
+				if err := rollups.NewRollupService(dbConn).RegroupEncounters(resolved); err != nil {
+					log.Printf("Rollup regroup warning: %v", err)
+				}
+				if err := parses.NewParseService(dbConn).RankResolvedEncounters(resolved); err != nil {
+					log.Printf("Parse rank warning: %v", err)
+				}
+			}()
+		}
+		// Recompute fingerprints older than lib.FingerprintVersion or invalidated by names
+		// resolved above; once none are left this finds nothing to do
+		if err := migrations.RecomputeFingerprints(dbConn); err != nil {
+			log.Printf("Fingerprint recompute warning: %v", err)
+		}
+
+		// Parse dungeon progress from snapshots uploaded before it was tracked
+		go func() {
+			if err := progress.NewProgressService(dbConn).Backfill(); err != nil {
+				log.Printf("Dungeon progress backfill warning: %v", err)
+			}
+		}()
+
+		// Derive builds from snapshots uploaded before they were extracted at ingest
+		go func() {
+			if err := builds.NewBuildService(dbConn).Backfill(); err != nil {
+				log.Printf("Character build backfill warning: %v", err)
+			}
+		}()
+
+		// Keep parse percentiles fresh as the peer population grows (default hourly)
+		parseInterval := time.Hour
+		if v := os.Getenv("PARSE_RECOMPUTE_INTERVAL"); v != "" {
+			if d, err := time.ParseDuration(v); err == nil && d > 0 {
+				parseInterval = d
+			} else {
+				log.Printf("Invalid PARSE_RECOMPUTE_INTERVAL %q, using %s", v, parseInterval)
+			}
+		}
+		parses.NewParseService(dbConn).RecomputePeriodically(parseInterval)
+
+		// Statistics rollups are updated after each upload; the periodic catch-up picks up
+		// anything changed outside ingest (default hourly)
+		rollupInterval := time.Hour
+		if v := os.Getenv("STATS_ROLLUP_INTERVAL"); v != "" {
+			if d, err := time.ParseDuration(v); err == nil && d > 0 {
+				rollupInterval = d
+			} else {
+				log.Printf("Invalid STATS_ROLLUP_INTERVAL %q, using %s", v, rollupInterval)
+			}
+		}
+		rollups.NewRollupService(dbConn).CatchUpPeriodically(rollupInterval)
 	}
 
 	// Get environment variables
@@ -50,10 +124,13 @@ func main() {
 		AllowMethods: []string{"GET", "POST", "PUT", "DELETE", "OPTIONS"},
 		// Allow X-Api-Key for desktop uploads and API clients
 		AllowHeaders:     []string{"Origin", "Content-Type", "Authorization", "X-Api-Key"},
-		ExposeHeaders:    []string{"Set-Cookie"},
+		ExposeHeaders:    []string{"Set-Cookie", "ETag"},
 		AllowCredentials: true,
 	}))
 
+	// Resolve the response language once per request
+	router.Use(middleware.Locale())
+
 	// If DB initialized, attach it to every request via middleware so controllers can reuse it
 	if dbConn != nil {
 		router.Use(func(c *gin.Context) {
@@ -0,0 +1,17 @@
+package middleware
+
+import (
+	"server/services/gamedata"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Locale resolves the request's Accept-Language against the game data catalog and stores
+// the result as "locale" ("" for English) for handlers and the response cache key.
+func Locale() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Set("locale", gamedata.Get().MatchLocale(c.GetHeader("Accept-Language")))
+		c.Header("Vary", "Accept-Language")
+		c.Next()
+	}
+}
@@ -114,6 +114,21 @@ func OptionalAuth() gin.HandlerFunc {
 	return AuthMiddleware(false)
 }
 
+// RequireAdmin rejects requests whose authenticated user is not an admin. It must run
+// after RequireAuth (or another middleware that attaches the user).
+func RequireAdmin() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userAny, _ := c.Get("user")
+		user, ok := userAny.(*models.User)
+		if !ok || user == nil || user.Role != "admin" {
+			c.JSON(http.StatusForbidden, gin.H{"error": "Admin access required"})
+			c.Abort()
+			return
+		}
+		c.Next()
+	}
+}
+
 // hashAPIKey creates a HMAC-SHA256 hash of the given key using a server-side secret pepper.
 func hashAPIKey(plaintext string) string {
 	pepper := os.Getenv("API_KEY_PEPPER")
@@ -97,5 +97,8 @@ func generateCacheKey(c *gin.Context) string {
 	h.Write([]byte(c.Request.Method))
 	h.Write([]byte(c.Request.URL.Path))
 	h.Write([]byte(c.Request.URL.RawQuery))
+	// Names in responses follow the requested language (see Locale)
+	h.Write([]byte{0})
+	h.Write([]byte(c.GetString("locale")))
 	return "cache:" + hex.EncodeToString(h.Sum(nil))
 }
@@ -0,0 +1,56 @@
+-- Per-actor statistics for each attempt (pull) and each phase of an encounter
+
+CREATE TABLE IF NOT EXISTS attempt_actor_stats (
+    id                bigserial PRIMARY KEY,
+    attempt_index     bigint NOT NULL,
+    is_kill           boolean DEFAULT false,
+    actor_id          bigint NOT NULL,
+    damage_dealt      bigint DEFAULT 0,
+    heal_dealt        bigint DEFAULT 0,
+    damage_taken      bigint DEFAULT 0,
+    hits_dealt        bigint DEFAULT 0,
+    hits_heal         bigint DEFAULT 0,
+    hits_taken        bigint DEFAULT 0,
+    boss_damage_dealt bigint DEFAULT 0,
+    dps               decimal DEFAULT 0,
+    duration          decimal DEFAULT 0,
+    name              varchar(255),
+    class_id          bigint,
+    class_spec        bigint,
+    ability_score     bigint,
+    is_player         boolean,
+    encounter_id      bigint NOT NULL,
+    CONSTRAINT fk_attempt_actor_stats_encounter FOREIGN KEY (encounter_id) REFERENCES encounters (id) ON DELETE CASCADE
+);
+CREATE INDEX IF NOT EXISTS idx_attempt_actor_stats_attempt ON attempt_actor_stats (attempt_index, encounter_id);
+CREATE INDEX IF NOT EXISTS idx_attempt_actor_stats_is_kill ON attempt_actor_stats (is_kill);
+CREATE INDEX IF NOT EXISTS idx_attempt_actor_stats_actor_id ON attempt_actor_stats (actor_id);
+CREATE INDEX IF NOT EXISTS idx_attempt_actor_stats_encounter_id ON attempt_actor_stats (encounter_id);
+
+CREATE TABLE IF NOT EXISTS phase_actor_stats (
+    id                bigserial PRIMARY KEY,
+    phase_type        varchar(10) NOT NULL,
+    actor_id          bigint NOT NULL,
+    damage_dealt      bigint DEFAULT 0,
+    heal_dealt        bigint DEFAULT 0,
+    damage_taken      bigint DEFAULT 0,
+    hits_dealt        bigint DEFAULT 0,
+    hits_heal         bigint DEFAULT 0,
+    hits_taken        bigint DEFAULT 0,
+    boss_damage_dealt bigint DEFAULT 0,
+    dps               decimal DEFAULT 0,
+    duration          decimal DEFAULT 0,
+    name              varchar(255),
+    class_id          bigint,
+    class_spec        bigint,
+    ability_score     bigint,
+    is_player         boolean,
+    phase_id          bigint NOT NULL,
+    encounter_id      bigint NOT NULL,
+    CONSTRAINT fk_phase_actor_stats_phase FOREIGN KEY (phase_id) REFERENCES encounter_phases (id) ON DELETE CASCADE,
+    CONSTRAINT fk_phase_actor_stats_encounter FOREIGN KEY (encounter_id) REFERENCES encounters (id) ON DELETE CASCADE
+);
+CREATE INDEX IF NOT EXISTS idx_phase_actor_stats_phase_type ON phase_actor_stats (phase_type);
+CREATE INDEX IF NOT EXISTS idx_phase_actor_stats_actor_id ON phase_actor_stats (actor_id);
+CREATE INDEX IF NOT EXISTS idx_phase_actor_stats_phase_id ON phase_actor_stats (phase_id);
+CREATE INDEX IF NOT EXISTS idx_phase_actor_stats_encounter_id ON phase_actor_stats (encounter_id);
@@ -0,0 +1,28 @@
+-- Seconds an encounter spent in boss phases, for boss DPS
+
+ALTER TABLE encounters ADD COLUMN IF NOT EXISTS boss_duration decimal DEFAULT 0;
+
+-- Backfill from the boss phases of existing encounters. Phases are clipped to the
+-- encounter and merged like lib.ComputeBossActiveSeconds: each phase, in start order, only
+-- counts the part after the latest end seen before it.
+UPDATE encounters e SET boss_duration = p.boss_seconds
+FROM (
+    SELECT encounter_id,
+           SUM(GREATEST(EXTRACT(EPOCH FROM (end_time - GREATEST(start_time, COALESCE(covered, start_time)))), 0)) AS boss_seconds
+    FROM (
+        SELECT encounter_id, start_time, end_time,
+               MAX(end_time) OVER (PARTITION BY encounter_id ORDER BY start_time, end_time
+                                   ROWS BETWEEN UNBOUNDED PRECEDING AND 1 PRECEDING) AS covered
+        FROM (
+            SELECT ph.encounter_id,
+                   GREATEST(ph.start_time, en.started_at) AS start_time,
+                   LEAST(COALESCE(ph.end_time, en.ended_at), COALESCE(en.ended_at, ph.end_time)) AS end_time
+            FROM encounter_phases ph
+            JOIN encounters en ON en.id = ph.encounter_id
+            WHERE ph.phase_type = 'boss'
+        ) clipped
+        WHERE end_time > start_time
+    ) ordered
+    GROUP BY encounter_id
+) p
+WHERE e.id = p.encounter_id AND p.boss_seconds > 0 AND e.boss_duration IS DISTINCT FROM p.boss_seconds;
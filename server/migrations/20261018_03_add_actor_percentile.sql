@@ -0,0 +1,5 @@
+-- DPS percentile of each player row within its peer group (scene, class spec and
+-- 1000-point ability-score bracket). Rows stay NULL until the parse service ranks them,
+-- which it does for every row at startup.
+
+ALTER TABLE actor_encounter_stats ADD COLUMN IF NOT EXISTS percentile decimal;
@@ -0,0 +1,40 @@
+-- Latest known identity of each player character, with the privacy opt-out and the
+-- account verified to own it
+
+CREATE TABLE IF NOT EXISTS characters (
+    actor_id      bigint PRIMARY KEY,
+    name          varchar(255),
+    class_id      bigint,
+    class_spec    bigint,
+    ability_score bigint,
+    last_seen_at  timestamptz NOT NULL,
+    is_private    boolean NOT NULL DEFAULT false,
+    owner_user_id bigint,
+    updated_at    timestamptz
+);
+CREATE INDEX IF NOT EXISTS idx_characters_owner_user_id ON characters (owner_user_id);
+
+-- Users' requests to be verified as a character's owner, resolved by an admin
+CREATE TABLE IF NOT EXISTS character_claims (
+    id             bigserial PRIMARY KEY,
+    actor_id       bigint NOT NULL,
+    user_id        bigint NOT NULL,
+    status         varchar(16) NOT NULL DEFAULT 'pending',
+    evidence       text,
+    note           text,
+    reviewed_by_id bigint,
+    reviewed_at    timestamptz,
+    created_at     timestamptz
+);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_character_claims_actor_user ON character_claims (actor_id, user_id);
+CREATE INDEX IF NOT EXISTS idx_character_claims_user_id ON character_claims (user_id);
+CREATE INDEX IF NOT EXISTS idx_character_claims_status ON character_claims (status);
+
+-- Seed characters from existing actor rows (latest appearance per actor)
+INSERT INTO characters (actor_id, name, class_id, class_spec, ability_score, last_seen_at, is_private, updated_at)
+SELECT DISTINCT ON (a.actor_id) a.actor_id, a.name, a.class_id, a.class_spec, a.ability_score, e.started_at, false, NOW()
+FROM actor_encounter_stats a
+JOIN encounters e ON e.id = a.encounter_id
+WHERE a.is_player = true
+ORDER BY a.actor_id, e.started_at DESC, a.id DESC
+ON CONFLICT (actor_id) DO NOTHING;
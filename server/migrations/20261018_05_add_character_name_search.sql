@@ -0,0 +1,7 @@
+-- Player name search: a prefix index, and a trigram index for fuzzy matches. Without
+-- pg_trgm, skip the last two statements; search then falls back to substring matching.
+
+CREATE INDEX IF NOT EXISTS idx_characters_name_prefix ON characters (LOWER(name) text_pattern_ops);
+
+CREATE EXTENSION IF NOT EXISTS pg_trgm;
+CREATE INDEX IF NOT EXISTS idx_characters_name_trgm ON characters USING gin (LOWER(name) gin_trgm_ops);
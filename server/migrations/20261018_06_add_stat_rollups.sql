@@ -0,0 +1,38 @@
+-- Daily statistics rollups. The tables start empty: the rollup catch-up that runs at
+-- startup counts every listed encounter, and the statistics endpoints query live until it
+-- has completed.
+
+CREATE TABLE IF NOT EXISTS stat_rollups (
+    id              bigserial PRIMARY KEY,
+    group_key       text NOT NULL,
+    day             date NOT NULL,
+    patch_id        bigint,
+    scene_id        bigint,
+    scene_name      varchar(255),
+    class_spec      bigint NOT NULL,
+    ability_bracket bigint,
+    count           bigint NOT NULL,
+    dps_values      jsonb NOT NULL,
+    hps_values      jsonb NOT NULL,
+    boss_dps_values jsonb NOT NULL,
+    updated_at      timestamptz
+);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_stat_rollups_group_key ON stat_rollups (group_key);
+CREATE INDEX IF NOT EXISTS idx_stat_rollups_day ON stat_rollups (day);
+CREATE INDEX IF NOT EXISTS idx_stat_rollups_scene_id ON stat_rollups (scene_id);
+CREATE INDEX IF NOT EXISTS idx_stat_rollups_scene_name ON stat_rollups (scene_name);
+
+-- Encounters counted in stat_rollups
+CREATE TABLE IF NOT EXISTS stat_rollup_encounters (
+    encounter_id bigint PRIMARY KEY,
+    day          date NOT NULL
+);
+
+-- Most recently ingested player row per actor
+CREATE TABLE IF NOT EXISTS stat_actor_latest (
+    actor_id      bigint PRIMARY KEY,
+    stat_id       bigint NOT NULL,
+    class_id      bigint,
+    class_spec    bigint,
+    ability_score bigint
+);
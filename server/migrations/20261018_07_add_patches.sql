@@ -0,0 +1,16 @@
+-- Admin-managed patch windows. Encounters are tagged with their patch at ingest, and all
+-- of them are retagged whenever a patch is created, changed or deleted.
+
+CREATE TABLE IF NOT EXISTS patches (
+    id         bigserial PRIMARY KEY,
+    name       varchar(64) NOT NULL,
+    starts_at  timestamptz NOT NULL,
+    ends_at    timestamptz,
+    created_at timestamptz,
+    updated_at timestamptz
+);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_patches_name ON patches (name);
+CREATE INDEX IF NOT EXISTS idx_patches_starts_at ON patches (starts_at);
+
+ALTER TABLE encounters ADD COLUMN IF NOT EXISTS patch_id bigint;
+CREATE INDEX IF NOT EXISTS idx_encounters_patch_id ON encounters (patch_id);
@@ -0,0 +1,18 @@
+-- Moderation of uploads that fail the plausibility checks at ingest: held encounters are
+-- kept off leaderboards and statistics while their review is pending or rejected.
+
+ALTER TABLE encounters ADD COLUMN IF NOT EXISTS held boolean NOT NULL DEFAULT false;
+
+CREATE TABLE IF NOT EXISTS encounter_reviews (
+    id             bigserial PRIMARY KEY,
+    encounter_id   bigint NOT NULL,
+    status         varchar(16) NOT NULL DEFAULT 'pending',
+    flags          jsonb,
+    note           text,
+    reviewed_by_id bigint,
+    reviewed_at    timestamptz,
+    created_at     timestamptz,
+    CONSTRAINT fk_encounter_reviews_encounter FOREIGN KEY (encounter_id) REFERENCES encounters (id) ON DELETE CASCADE
+);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_encounter_reviews_encounter_id ON encounter_reviews (encounter_id);
+CREATE INDEX IF NOT EXISTS idx_encounter_reviews_status ON encounter_reviews (status);
@@ -0,0 +1,10 @@
+-- Build information derived from each character's latest uploaded snapshot. Snapshots
+-- stored before are derived by the build backfill that runs at startup.
+
+CREATE TABLE IF NOT EXISTS character_builds (
+    player_id     bigint PRIMARY KEY,
+    profession_id bigint,
+    last_seen_ms  bigint NOT NULL,
+    updated_at    timestamptz
+);
+CREATE INDEX IF NOT EXISTS idx_character_builds_profession_id ON character_builds (profession_id);
@@ -0,0 +1,5 @@
+-- Equipped module attribute levels, and the derivation version so that snapshots derived
+-- by an older version (0 here) are derived again by the build backfill at startup
+
+ALTER TABLE character_builds ADD COLUMN IF NOT EXISTS module_levels jsonb;
+ALTER TABLE character_builds ADD COLUMN IF NOT EXISTS version bigint NOT NULL DEFAULT 0;
@@ -0,0 +1,24 @@
+-- Scenes keyed by the client's SceneID. Existing encounters are linked and the catalog is
+-- applied at the first startup, as no catalog version is recorded in gamedata_syncs yet.
+
+CREATE TABLE IF NOT EXISTS scenes (
+    id              bigint PRIMARY KEY,
+    name            varchar(255) NOT NULL,
+    names           jsonb NOT NULL DEFAULT '{}',
+    difficulty      varchar(32),
+    expected_bosses jsonb NOT NULL DEFAULT '[]',
+    aliases         jsonb NOT NULL DEFAULT '[]',
+    created_at      timestamptz,
+    updated_at      timestamptz
+);
+-- Scene name filters match any alias
+CREATE INDEX IF NOT EXISTS idx_scenes_aliases ON scenes USING gin (aliases);
+
+CREATE INDEX IF NOT EXISTS idx_encounters_scene_id ON encounters (scene_id);
+
+-- Game data catalog version last applied to the stored data
+CREATE TABLE IF NOT EXISTS gamedata_syncs (
+    name       varchar(64) PRIMARY KEY,
+    version    varchar(255) NOT NULL,
+    applied_at timestamptz
+);
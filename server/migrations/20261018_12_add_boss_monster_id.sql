@@ -0,0 +1,9 @@
+-- Boss monster IDs resolved from the catalog, and the fingerprint version of each
+-- encounter. Existing encounters start at version 0, so their fingerprints are recomputed
+-- once at the next startup, after the catalog sync has resolved their boss IDs.
+
+ALTER TABLE encounter_bosses ADD COLUMN IF NOT EXISTS monster_id bigint;
+CREATE INDEX IF NOT EXISTS idx_encounter_bosses_monster_id ON encounter_bosses (monster_id);
+
+ALTER TABLE encounters ADD COLUMN IF NOT EXISTS fingerprint_version bigint NOT NULL DEFAULT 0;
+CREATE INDEX IF NOT EXISTS idx_fingerprint_version ON encounters (fingerprint_version);
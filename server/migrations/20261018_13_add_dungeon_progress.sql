@@ -0,0 +1,18 @@
+-- Each character's record per dungeon, parsed from their latest uploaded snapshot.
+-- Snapshots stored before are parsed by the progress backfill that runs at startup.
+
+CREATE TABLE IF NOT EXISTS dungeon_progress (
+    player_id         bigint NOT NULL,
+    dungeon_id        bigint NOT NULL,
+    pass_count        bigint NOT NULL DEFAULT 0,
+    best_time         bigint NOT NULL DEFAULT 0,
+    master_season_id  bigint NOT NULL DEFAULT 0,
+    master_difficulty bigint NOT NULL DEFAULT 0,
+    master_pass_count bigint NOT NULL DEFAULT 0,
+    master_pass_time  bigint NOT NULL DEFAULT 0,
+    last_seen_ms      bigint NOT NULL,
+    updated_at        timestamptz,
+    PRIMARY KEY (player_id, dungeon_id)
+);
+CREATE INDEX IF NOT EXISTS idx_dungeon_progress_dungeon_id ON dungeon_progress (dungeon_id);
+CREATE INDEX IF NOT EXISTS idx_dungeon_progress_master ON dungeon_progress (master_season_id, master_difficulty);
@@ -0,0 +1,43 @@
+package migrations
+
+import (
+	"fmt"
+
+	"server/lib"
+	"server/models"
+
+	"gorm.io/gorm"
+)
+
+// RecomputeFingerprints recomputes the dedupe fingerprints of encounters stored with an
+// older lib.FingerprintVersion, or whose scene or boss IDs were resolved since (see
+// scenes.ResolveNames), so that new uploads dedupe against them. Encounters that turn out
+// to duplicate another one already holding the fingerprint keep none, as it is unique.
+// Recomputed encounters are stamped with the current version, so this is a one-shot data
+// migration: runs without stale fingerprints only check the fingerprint_version index.
+// main calls it once at startup, after the catalog sync.
+func RecomputeFingerprints(db *gorm.DB) error {
+	config := lib.DefaultDedupeConfig()
+	var batch []models.Encounter
+	err := db.Model(&models.Encounter{}).
+		Select("id", "started_at", "total_dmg", "scene_id", "scene_name").
+		Preload("Bosses").Preload("Players").Preload("Attempts").
+		Where("fingerprint_version < ?", lib.FingerprintVersion).
+		FindInBatches(&batch, 100, func(tx *gorm.DB, _ int) error {
+			for _, enc := range batch {
+				fingerprint := lib.ComputeEncounterFingerprint(lib.EncounterInputFromModel(enc), config)
+				if err := db.Model(&models.Encounter{}).Where("id = ?", enc.ID).Updates(map[string]interface{}{
+					"fingerprint": gorm.Expr(`CASE WHEN EXISTS (SELECT 1 FROM encounters o WHERE o.fingerprint = ? AND o.id <> ?)
+						THEN NULL ELSE ? END`, fingerprint, enc.ID, fingerprint),
+					"fingerprint_version": lib.FingerprintVersion,
+				}).Error; err != nil {
+					return err
+				}
+			}
+			return nil
+		}).Error
+	if err != nil {
+		return fmt.Errorf("fingerprint recompute failed: %w", err)
+	}
+	return nil
+}
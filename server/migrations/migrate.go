@@ -13,6 +13,19 @@ import (
 // RunMigrations runs DB migrations. In development, set AUTO_MIGRATE=true to run GORM AutoMigrate.
 // Note: For production, manually run SQL migrations from the migrations/ directory:
 //   - 20251110_add_encounter_fingerprint.sql (adds fingerprint, player_set_hash columns and indexes)
+//   - 20261018_01_add_segment_actor_stats.sql (adds attempt_actor_stats and phase_actor_stats)
+//   - 20261018_02_add_encounter_boss_duration.sql (adds encounters.boss_duration and backfills it from boss phases)
+//   - 20261018_03_add_actor_percentile.sql (adds actor_encounter_stats.percentile)
+//   - 20261018_04_add_characters.sql (adds characters and character_claims, and seeds characters from actor rows)
+//   - 20261018_05_add_character_name_search.sql (adds the characters name search indexes)
+//   - 20261018_06_add_stat_rollups.sql (adds stat_rollups, stat_rollup_encounters and stat_actor_latest)
+//   - 20261018_07_add_patches.sql (adds patches and encounters.patch_id)
+//   - 20261018_08_add_encounter_reviews.sql (adds encounters.held and encounter_reviews)
+//   - 20261018_09_add_character_builds.sql (adds character_builds)
+//   - 20261018_10_add_character_build_modules.sql (adds character_builds.module_levels and version)
+//   - 20261018_11_add_scenes.sql (adds scenes and gamedata_syncs)
+//   - 20261018_12_add_boss_monster_id.sql (adds encounter_bosses.monster_id and encounters.fingerprint_version)
+//   - 20261018_13_add_dungeon_progress.sql (adds dungeon_progress)
 //
 // Previous migrations:
 //   - ALTER TABLE encounters ADD COLUMN source_hash VARCHAR(64);
@@ -30,13 +43,26 @@ func RunMigrations(db *gorm.DB) error {
 		err := db.AutoMigrate(
 			&models.User{},
 			&models.ApiKey{},
+			&models.Patch{},
+			&models.Scene{},
+			&models.GameDataSync{},
 			&models.Encounter{},
+			&models.EncounterReview{},
 			&models.Attempt{},
 			&models.EncounterBoss{},
 			&models.EncounterPhase{},
 			&models.Entity{},
 			&models.ActorEncounterStat{},
+			&models.Character{},
+			&models.CharacterClaim{},
+			&models.StatRollup{},
+			&models.StatRollupEncounter{},
+			&models.ActorLatestStat{},
+			&models.AttemptActorStat{},
+			&models.PhaseActorStat{},
 			&models.DetailedPlayerData{},
+			&models.DungeonProgress{},
+			&models.CharacterBuild{},
 			&models.DeathEvent{},
 			&models.DamageSkillStat{},
 			&models.HealSkillStat{},
@@ -50,6 +76,63 @@ func RunMigrations(db *gorm.DB) error {
 			return fmt.Errorf("auto migrate failed: %w", err)
 		}
 
+		// Backfill boss-active time for encounters uploaded before it was computed at ingest,
+		// and recompute it where overlapping phases were counted twice. Phases are clipped
+		// to the encounter and merged like lib.ComputeBossActiveSeconds: each phase, in
+		// start order, only counts the part after the latest end seen before it.
+		if err := db.Exec(`
+			UPDATE encounters e SET boss_duration = p.boss_seconds
+			FROM (
+				SELECT encounter_id,
+					   SUM(GREATEST(EXTRACT(EPOCH FROM (end_time - GREATEST(start_time, COALESCE(covered, start_time)))), 0)) AS boss_seconds
+				FROM (
+					SELECT encounter_id, start_time, end_time,
+						   MAX(end_time) OVER (PARTITION BY encounter_id ORDER BY start_time, end_time
+											   ROWS BETWEEN UNBOUNDED PRECEDING AND 1 PRECEDING) AS covered
+					FROM (
+						SELECT ph.encounter_id,
+							   GREATEST(ph.start_time, en.started_at) AS start_time,
+							   LEAST(COALESCE(ph.end_time, en.ended_at), COALESCE(en.ended_at, ph.end_time)) AS end_time
+						FROM encounter_phases ph
+						JOIN encounters en ON en.id = ph.encounter_id
+						WHERE ph.phase_type = 'boss'
+					) clipped
+					WHERE end_time > start_time
+				) ordered
+				GROUP BY encounter_id
+			) p
+			WHERE e.id = p.encounter_id AND p.boss_seconds > 0 AND e.boss_duration IS DISTINCT FROM p.boss_seconds
+		`).Error; err != nil {
+			return fmt.Errorf("boss duration backfill failed: %w", err)
+		}
+
+		// Seed characters from existing actor rows (latest appearance per actor)
+		if err := db.Exec(`
+			INSERT INTO characters (actor_id, name, class_id, class_spec, ability_score, last_seen_at, is_private, updated_at)
+			SELECT DISTINCT ON (a.actor_id) a.actor_id, a.name, a.class_id, a.class_spec, a.ability_score, e.started_at, false, NOW()
+			FROM actor_encounter_stats a
+			JOIN encounters e ON e.id = a.encounter_id
+			WHERE a.is_player = true
+			ORDER BY a.actor_id, e.started_at DESC, a.id DESC
+			ON CONFLICT (actor_id) DO NOTHING
+		`).Error; err != nil {
+			return fmt.Errorf("characters backfill failed: %w", err)
+		}
+
+		// Scene name filters match any alias
+		if err := db.Exec(`CREATE INDEX IF NOT EXISTS idx_scenes_aliases ON scenes USING gin (aliases)`).Error; err != nil {
+			return fmt.Errorf("scenes alias index failed: %w", err)
+		}
+
+		// Name search: prefix index always, trigram index when pg_trgm can be enabled
+		if err := db.Exec(`CREATE INDEX IF NOT EXISTS idx_characters_name_prefix ON characters (LOWER(name) text_pattern_ops)`).Error; err != nil {
+			return fmt.Errorf("characters name index failed: %w", err)
+		}
+		if err := db.Exec(`CREATE EXTENSION IF NOT EXISTS pg_trgm`).Error; err != nil {
+			log.Printf("migrations: pg_trgm unavailable, player search falls back to substring matching: %v", err)
+		} else if err := db.Exec(`CREATE INDEX IF NOT EXISTS idx_characters_name_trgm ON characters USING gin (LOWER(name) gin_trgm_ops)`).Error; err != nil {
+			return fmt.Errorf("characters trigram index failed: %w", err)
+		}
 
 		log.Println("migrations: AutoMigrate completed successfully")
 		return nil
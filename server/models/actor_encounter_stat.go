@@ -53,6 +53,10 @@ type ActorEncounterStat struct {
 	Attributes    datatypes.JSON `gorm:"column:attributes;type:jsonb" json:"attributes,omitempty"`
 	Revives       int64          `gorm:"column:revives" json:"revives"`
 
+	// Percentile (0-100] of DPS against players of the same scene, class spec and
+	// 1000-point ability-score bracket. Set at ingest and refreshed periodically.
+	Percentile *float64 `gorm:"column:percentile" json:"percentile,omitempty"`
+
 	// Foreign Key To Encounter
 	EncounterID int64      `gorm:"column:encounter_id;index;not null;constraint:OnDelete:CASCADE" json:"encounterId"`
 	Encounter   *Encounter `gorm:"foreignKey:EncounterID;references:ID" json:"-"`
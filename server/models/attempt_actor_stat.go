@@ -0,0 +1,36 @@
+package models
+
+// AttemptActorStat aggregates per-actor stats for a single attempt (pull) within an encounter.
+type AttemptActorStat struct {
+	ID           int64 `gorm:"primaryKey;autoIncrement;column:id" json:"id"`
+	AttemptIndex int   `gorm:"column:attempt_index;not null;index:idx_attempt_actor_stats_attempt,composite:attempt_index" json:"attemptIndex"`
+	IsKill       bool  `gorm:"column:is_kill;default:false;index" json:"isKill"`
+	ActorID      int64 `gorm:"column:actor_id;index;not null" json:"actorId"`
+
+	DamageDealt     int64 `gorm:"column:damage_dealt;default:0" json:"damageDealt"`
+	HealDealt       int64 `gorm:"column:heal_dealt;default:0" json:"healDealt"`
+	DamageTaken     int64 `gorm:"column:damage_taken;default:0" json:"damageTaken"`
+	HitsDealt       int64 `gorm:"column:hits_dealt;default:0" json:"hitsDealt"`
+	HitsHeal        int64 `gorm:"column:hits_heal;default:0" json:"hitsHeal"`
+	HitsTaken       int64 `gorm:"column:hits_taken;default:0" json:"hitsTaken"`
+	BossDamageDealt int64 `gorm:"column:boss_damage_dealt;default:0" json:"bossDamageDealt"`
+
+	// Performance snapshot for the attempt only
+	DPS      float64 `gorm:"column:dps;default:0" json:"dps"`
+	Duration float64 `gorm:"column:duration;default:0" json:"duration"`
+
+	// Copied from the encounter-level actor row at ingest so segment queries need no extra join
+	Name         *string `gorm:"column:name;size:255" json:"name,omitempty"`
+	ClassID      *int64  `gorm:"column:class_id" json:"classId,omitempty"`
+	ClassSpec    *int64  `gorm:"column:class_spec" json:"classSpec,omitempty"`
+	AbilityScore *int64  `gorm:"column:ability_score" json:"abilityScore,omitempty"`
+	IsPlayer     bool    `gorm:"column:is_player" json:"isPlayer"`
+
+	// Foreign Key To Encounter
+	EncounterID int64      `gorm:"column:encounter_id;index;index:idx_attempt_actor_stats_attempt,composite:encounter_id;not null;constraint:OnDelete:CASCADE" json:"encounterId"`
+	Encounter   *Encounter `gorm:"foreignKey:EncounterID;references:ID" json:"-"`
+}
+
+func (AttemptActorStat) TableName() string {
+	return "attempt_actor_stats"
+}
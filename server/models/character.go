@@ -0,0 +1,27 @@
+package models
+
+import "time"
+
+// Character is the latest known identity of a player actor, kept up to date at ingest.
+// It also holds per-character settings such as the profile privacy opt-out.
+type Character struct {
+	ActorID      int64     `gorm:"primaryKey;autoIncrement:false;column:actor_id" json:"actorId"`
+	Name         *string   `gorm:"column:name;size:255" json:"name,omitempty"`
+	ClassID      *int64    `gorm:"column:class_id" json:"classId,omitempty"`
+	ClassSpec    *int64    `gorm:"column:class_spec" json:"classSpec,omitempty"`
+	AbilityScore *int64    `gorm:"column:ability_score" json:"abilityScore,omitempty"`
+	LastSeenAt   time.Time `gorm:"column:last_seen_at;not null" json:"lastSeenAt"`
+
+	// Private characters have no public profile or parse history, and are left out of (or
+	// unnamed in) every other listing
+	IsPrivate bool `gorm:"column:is_private;default:false;not null" json:"isPrivate"`
+	// The account verified to play this character (see CharacterClaim); only it may change
+	// the privacy opt-out
+	OwnerUserID *uint `gorm:"column:owner_user_id;index" json:"-"`
+
+	UpdatedAt time.Time `gorm:"column:updated_at" json:"updatedAt"`
+}
+
+func (Character) TableName() string {
+	return "characters"
+}
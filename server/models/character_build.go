@@ -0,0 +1,29 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/datatypes"
+)
+
+// CharacterBuild is the build information derived from a character's latest uploaded
+// CharSerialize snapshot, extracted at ingest so statistics don't decode snapshots per
+// request.
+type CharacterBuild struct {
+	PlayerID int64 `gorm:"primaryKey;autoIncrement:false;column:player_id" json:"playerId"`
+	// Class the character was playing when the snapshot was taken (ProfessionList.CurProfessionId)
+	ProfessionID *int64 `gorm:"column:profession_id;index" json:"professionId,omitempty"`
+	// Attribute levels of the equipped modules, {"attrID": level} for levels 1+; null when
+	// the snapshot has no equip slot information
+	ModuleLevels datatypes.JSON `gorm:"column:module_levels;type:jsonb" json:"moduleLevels,omitempty"`
+	// Derivation version, so stored snapshots are derived again when extraction changes
+	Version int `gorm:"column:version;not null;default:0" json:"-"`
+
+	// Snapshot time, so an older snapshot never overwrites a newer one
+	LastSeenMs int64     `gorm:"column:last_seen_ms;not null" json:"lastSeenMs"`
+	UpdatedAt  time.Time `gorm:"column:updated_at" json:"updatedAt"`
+}
+
+func (CharacterBuild) TableName() string {
+	return "character_builds"
+}
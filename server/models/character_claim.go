@@ -0,0 +1,29 @@
+package models
+
+import "time"
+
+// Character claim statuses
+const (
+	ClaimPending  = "pending"
+	ClaimApproved = "approved"
+	ClaimRejected = "rejected"
+)
+
+// CharacterClaim is a user's request to be linked to a character as its owner. Uploads
+// can't prove who plays a character, so an admin verifies each claim; approving it sets
+// Character.OwnerUserID.
+type CharacterClaim struct {
+	ID           int64      `gorm:"primaryKey;autoIncrement;column:id" json:"id"`
+	ActorID      int64      `gorm:"column:actor_id;not null;uniqueIndex:idx_character_claims_actor_user,priority:1" json:"actorId"`
+	UserID       uint       `gorm:"column:user_id;not null;uniqueIndex:idx_character_claims_actor_user,priority:2;index" json:"userId"`
+	Status       string     `gorm:"column:status;size:16;not null;default:pending;index" json:"status"`
+	Evidence     *string    `gorm:"column:evidence" json:"evidence,omitempty"` // free text from the claimant for the reviewer
+	Note         *string    `gorm:"column:note" json:"note,omitempty"`
+	ReviewedByID *uint      `gorm:"column:reviewed_by_id" json:"reviewedById,omitempty"`
+	ReviewedAt   *time.Time `gorm:"column:reviewed_at" json:"reviewedAt,omitempty"`
+	CreatedAt    time.Time  `gorm:"column:created_at" json:"createdAt"`
+}
+
+func (CharacterClaim) TableName() string {
+	return "character_claims"
+}
@@ -0,0 +1,26 @@
+package models
+
+import "time"
+
+// DungeonProgress is a character's record in one dungeon, parsed from the DungeonList and
+// MasterModeDungeonInfo sections of their latest uploaded CharSerialize snapshot.
+type DungeonProgress struct {
+	PlayerID  int64 `gorm:"primaryKey;autoIncrement:false;column:player_id" json:"playerId"`
+	DungeonID int64 `gorm:"primaryKey;autoIncrement:false;column:dungeon_id;index" json:"dungeonId"`
+	PassCount int64 `gorm:"column:pass_count;not null;default:0" json:"passCount"`
+	BestTime  int64 `gorm:"column:best_time;not null;default:0" json:"bestTime"` // seconds, 0 when unknown
+
+	// Master mode; zero when the dungeon has no master-mode clear
+	MasterSeasonID   int64 `gorm:"column:master_season_id;not null;default:0;index:idx_dungeon_progress_master,priority:1" json:"masterSeasonId"`
+	MasterDifficulty int64 `gorm:"column:master_difficulty;not null;default:0;index:idx_dungeon_progress_master,priority:2" json:"masterDifficulty"` // highest cleared difficulty
+	MasterPassCount  int64 `gorm:"column:master_pass_count;not null;default:0" json:"masterPassCount"`
+	MasterPassTime   int64 `gorm:"column:master_pass_time;not null;default:0" json:"masterPassTime"` // best time at MasterDifficulty, seconds
+
+	// Snapshot time, so an older snapshot never overwrites a newer one
+	LastSeenMs int64     `gorm:"column:last_seen_ms;not null" json:"lastSeenMs"`
+	UpdatedAt  time.Time `gorm:"column:updated_at" json:"updatedAt"`
+}
+
+func (DungeonProgress) TableName() string {
+	return "dungeon_progress"
+}
@@ -8,16 +8,23 @@ type Encounter struct {
 	StartedAt     time.Time  `gorm:"column:started_at;not null" json:"startedAt"`
 	EndedAt       *time.Time `gorm:"column:ended_at" json:"endedAt,omitempty"`
 	Duration      float64    `gorm:"column:duration;default:0" json:"duration"`
+	BossDuration  float64    `gorm:"column:boss_duration;default:0" json:"bossDuration"` // seconds spent in boss phases, computed at ingest
 	LocalPlayerID *int64     `gorm:"column:local_player_id;index" json:"localPlayerId,omitempty"`
 	TotalDmg      int64      `gorm:"column:total_dmg;default:0" json:"totalDmg"`
 	TotalHeal     int64      `gorm:"column:total_heal;default:0" json:"totalHeal"`
-	SceneID       *int64     `gorm:"column:scene_id" json:"sceneId,omitempty"`
+	SceneID       *int64     `gorm:"column:scene_id;index" json:"sceneId,omitempty"` // links to scenes.id, created at ingest
 	SceneName     *string    `gorm:"column:scene_name;size:255" json:"sceneName,omitempty"`
 	SourceHash    *string    `gorm:"column:source_hash;size:64;index:idx_user_source_hash,composite:user_id" json:"sourceHash,omitempty"`
+	PatchID       *int64     `gorm:"column:patch_id;index" json:"patchId,omitempty"` // set at ingest from the patches table
 
 	// Deduplication fields
 	Fingerprint   *string `gorm:"column:fingerprint;size:64;index:idx_fingerprint;uniqueIndex:uniq_fingerprint" json:"fingerprint,omitempty"`
 	PlayerSetHash *string `gorm:"column:player_set_hash;size:64;index:idx_player_set_hash" json:"playerSetHash,omitempty"`
+	// lib.FingerprintVersion the fingerprint was computed with; older ones are recomputed
+	FingerprintVersion int `gorm:"column:fingerprint_version;not null;default:0;index:idx_fingerprint_version" json:"-"`
+
+	// Held encounters failed plausibility checks and are kept off leaderboards until approved
+	Held bool `gorm:"column:held;default:false;not null" json:"held"`
 
 	// Ownership
 	UserID uint  `gorm:"column:user_id;index;index:idx_user_source_hash,composite:user_id" json:"-"`
@@ -4,6 +4,7 @@ package models
 type EncounterBoss struct {
 	ID          int64  `gorm:"primaryKey;autoIncrement;column:id" json:"id"`
 	MonsterName string `gorm:"column:monster_name;size:255;not null" json:"monsterName"`
+	MonsterID   *int64 `gorm:"column:monster_id;index" json:"monsterId,omitempty"` // resolved from the name at ingest
 	Hits        int64  `gorm:"column:hits;default:0" json:"hits"`
 	TotalDamage int64  `gorm:"column:total_damage;default:0" json:"totalDamage"`
 	MaxHP       *int64 `gorm:"column:max_hp" json:"maxHp,omitempty"`
@@ -0,0 +1,33 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/datatypes"
+)
+
+// Encounter review statuses
+const (
+	ReviewPending  = "pending"
+	ReviewApproved = "approved"
+	ReviewRejected = "rejected"
+)
+
+// EncounterReview is a moderation case for an encounter that failed the ingest-time
+// plausibility checks. While pending or rejected the encounter is held out of leaderboards
+// (Encounter.Held).
+type EncounterReview struct {
+	ID           int64          `gorm:"primaryKey;autoIncrement;column:id" json:"id"`
+	EncounterID  int64          `gorm:"column:encounter_id;not null;uniqueIndex;constraint:OnDelete:CASCADE" json:"encounterId"`
+	Encounter    *Encounter     `gorm:"foreignKey:EncounterID;references:ID" json:"encounter,omitempty"`
+	Status       string         `gorm:"column:status;size:16;not null;default:pending;index" json:"status"`
+	Flags        datatypes.JSON `gorm:"column:flags;type:jsonb" json:"flags"` // []lib.PlausibilityFlag
+	Note         *string        `gorm:"column:note" json:"note,omitempty"`
+	ReviewedByID *uint          `gorm:"column:reviewed_by_id" json:"reviewedById,omitempty"`
+	ReviewedAt   *time.Time     `gorm:"column:reviewed_at" json:"reviewedAt,omitempty"`
+	CreatedAt    time.Time      `gorm:"column:created_at" json:"createdAt"`
+}
+
+func (EncounterReview) TableName() string {
+	return "encounter_reviews"
+}
@@ -0,0 +1,15 @@
+package models
+
+import "time"
+
+// GameDataSync records the game data catalog version last applied to the stored data (see
+// services/scenes), so that derived rows are only rewritten when the catalog changes.
+type GameDataSync struct {
+	Name      string    `gorm:"primaryKey;column:name;size:64" json:"name"`
+	Version   string    `gorm:"column:version;size:255;not null" json:"version"`
+	AppliedAt time.Time `gorm:"column:applied_at" json:"appliedAt"`
+}
+
+func (GameDataSync) TableName() string {
+	return "gamedata_syncs"
+}
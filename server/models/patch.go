@@ -0,0 +1,19 @@
+package models
+
+import "time"
+
+// Patch is an admin-managed game patch (or season) window. Encounters are tagged with the
+// patch whose window contains their start time.
+type Patch struct {
+	ID       int64      `gorm:"primaryKey;autoIncrement;column:id" json:"id"`
+	Name     string     `gorm:"column:name;size:64;not null;uniqueIndex" json:"name"`
+	StartsAt time.Time  `gorm:"column:starts_at;not null;index" json:"startsAt"`
+	EndsAt   *time.Time `gorm:"column:ends_at" json:"endsAt,omitempty"` // nil while the patch is current
+
+	CreatedAt time.Time `gorm:"column:created_at" json:"createdAt"`
+	UpdatedAt time.Time `gorm:"column:updated_at" json:"updatedAt"`
+}
+
+func (Patch) TableName() string {
+	return "patches"
+}
@@ -0,0 +1,30 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/datatypes"
+)
+
+// Scene is a game scene (dungeon, raid, open-world area) keyed by the client's SceneID.
+// Rows are created when an encounter in the scene is first uploaded and filled in from the
+// game data catalog (see services/scenes).
+type Scene struct {
+	ID         int64          `gorm:"primaryKey;autoIncrement:false;column:id" json:"id"`
+	Name       string         `gorm:"column:name;size:255;not null" json:"name"`
+	Names      datatypes.JSON `gorm:"column:names;type:jsonb;not null;default:'{}'" json:"names"` // locale -> localized name
+	Difficulty string         `gorm:"column:difficulty;size:32" json:"difficulty,omitempty"`
+	// Boss names expected in the scene, from the catalog
+	ExpectedBosses datatypes.JSON `gorm:"column:expected_bosses;type:jsonb;not null;default:'[]'" json:"expectedBosses"`
+	// Lowercased names the catalog knows the scene by: its name and localized names. Scene
+	// name filters match against these, and against each encounter's own uploaded name.
+	// Empty for scenes the catalog doesn't know.
+	Aliases datatypes.JSON `gorm:"column:aliases;type:jsonb;not null;default:'[]'" json:"-"`
+
+	CreatedAt time.Time `gorm:"column:created_at" json:"createdAt"`
+	UpdatedAt time.Time `gorm:"column:updated_at" json:"updatedAt"`
+}
+
+func (Scene) TableName() string {
+	return "scenes"
+}
@@ -0,0 +1,58 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/datatypes"
+)
+
+// StatRollup aggregates the player samples of listed encounters for one UTC day, patch,
+// scene, class spec and 1000-point ability-score bracket. Each metric keeps its values in
+// ascending order (a lib.Distribution as a JSON array), so quantiles read from rollups are
+// the ones the live queries compute, without joining or scanning the actor rows.
+type StatRollup struct {
+	ID             int64     `gorm:"primaryKey;autoIncrement;column:id" json:"id"`
+	GroupKey       string    `gorm:"column:group_key;type:text;not null;uniqueIndex" json:"-"` // day|patch|scene|spec|bracket, for upserts
+	Day            time.Time `gorm:"column:day;type:date;not null;index" json:"day"`
+	PatchID        *int64    `gorm:"column:patch_id" json:"patchId,omitempty"`
+	SceneID        *int64    `gorm:"column:scene_id;index" json:"sceneId,omitempty"`
+	SceneName      *string   `gorm:"column:scene_name;size:255;index" json:"sceneName,omitempty"`
+	ClassSpec      int64     `gorm:"column:class_spec;not null" json:"classSpec"`            // -1 when unknown
+	AbilityBracket *int64    `gorm:"column:ability_bracket" json:"abilityBracket,omitempty"` // nil when ability score is unknown
+	Count          int64     `gorm:"column:count;not null" json:"count"`
+
+	DPSValues     datatypes.JSON `gorm:"column:dps_values;type:jsonb;not null" json:"-"`
+	HPSValues     datatypes.JSON `gorm:"column:hps_values;type:jsonb;not null" json:"-"`
+	BossDPSValues datatypes.JSON `gorm:"column:boss_dps_values;type:jsonb;not null" json:"-"`
+
+	UpdatedAt time.Time `gorm:"column:updated_at" json:"updatedAt"`
+}
+
+func (StatRollup) TableName() string {
+	return "stat_rollups"
+}
+
+// StatRollupEncounter records that an encounter's players are counted in stat_rollups, so
+// each encounter is added once and encounters that stop being listed can be taken out.
+type StatRollupEncounter struct {
+	EncounterID int64     `gorm:"primaryKey;autoIncrement:false;column:encounter_id" json:"encounterId"`
+	Day         time.Time `gorm:"column:day;type:date;not null" json:"day"`
+}
+
+func (StatRollupEncounter) TableName() string {
+	return "stat_rollup_encounters"
+}
+
+// ActorLatestStat points at the most recently ingested player row for each actor, which
+// the totals endpoints use for class and ability-score breakdowns.
+type ActorLatestStat struct {
+	ActorID      int64  `gorm:"primaryKey;autoIncrement:false;column:actor_id" json:"actorId"`
+	StatID       int64  `gorm:"column:stat_id;not null" json:"statId"`
+	ClassID      *int64 `gorm:"column:class_id" json:"classId,omitempty"`
+	ClassSpec    *int64 `gorm:"column:class_spec" json:"classSpec,omitempty"`
+	AbilityScore *int64 `gorm:"column:ability_score" json:"abilityScore,omitempty"`
+}
+
+func (ActorLatestStat) TableName() string {
+	return "stat_actor_latest"
+}
@@ -14,8 +14,13 @@ func RegisterAPIRoutes(router *gin.Engine) {
 	groups.RegisterApiKeyRoutes(rg)
 	groups.RegisterCombatRoutes(rg)
 	groups.RegisterPlayerRoutes(rg)
+	groups.RegisterPlayersRoutes(rg)
+	groups.RegisterLeaderboardRoutes(rg)
 	groups.RegisterUploadRoutes(rg)
 	groups.RegisterModuleOptimizerRoutes(rg)
 	groups.RegisterStatisticsRoutes(rg)
+	groups.RegisterPatchRoutes(rg)
+	groups.RegisterAdminRoutes(rg)
+	groups.RegisterGameDataRoutes(rg)
 
 }
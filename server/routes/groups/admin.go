@@ -0,0 +1,28 @@
+package groups
+
+import (
+	moderation "server/controller/moderation"
+	patch "server/controller/patch"
+	"server/middleware"
+
+	"github.com/gin-gonic/gin"
+)
+
+// RegisterAdminRoutes registers admin-only endpoints under /admin
+func RegisterAdminRoutes(rg *gin.RouterGroup) {
+	g := rg.Group("/admin")
+	g.Use(middleware.RequireAuth(), middleware.RequireAdmin())
+	{
+		g.POST("/patches", patch.CreatePatch)
+		g.PUT("/patches/:id", patch.UpdatePatch)
+		g.DELETE("/patches/:id", patch.DeletePatch)
+
+		g.GET("/reviews", moderation.ListReviews)
+		g.POST("/reviews/:id/approve", moderation.ApproveReview)
+		g.POST("/reviews/:id/reject", moderation.RejectReview)
+
+		g.GET("/claims", moderation.ListClaims)
+		g.POST("/claims/:id/approve", moderation.ApproveClaim)
+		g.POST("/claims/:id/reject", moderation.RejectClaim)
+	}
+}
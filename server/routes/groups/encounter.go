@@ -13,7 +13,13 @@ func RegisterCombatRoutes(rg *gin.RouterGroup) {
 	{
 		combatGroup.GET("", cc.GetEncounters)
 		combatGroup.GET("/scenes", cc.GetEncounterScenes)
+		combatGroup.GET("/batch", cc.GetEncountersBatch)
+		combatGroup.GET("/:id/deaths", cc.GetEncounterDeaths)
 		combatGroup.GET("/:id/:playerId", cc.GetPlayerSkillStats)
 		combatGroup.GET("/:id", cc.GetEncounterByID)
 	}
+
+	// Exports are attachments (zip or JSON) whose contents depend on the requester,
+	// so they are registered outside the cached group.
+	rg.GET("/encounter/:id/export", middleware.OptionalAuth(), cc.ExportEncounter)
 }
@@ -0,0 +1,17 @@
+package groups
+
+import (
+	gd "server/controller/gamedata"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Game data is served from memory with ETags, so it is not put behind the Redis cache.
+func RegisterGameDataRoutes(rg *gin.RouterGroup) {
+	gameDataGroup := rg.Group("/gamedata")
+	{
+		gameDataGroup.GET("", gd.ListTables)
+		gameDataGroup.GET("/:table", gd.GetTable)
+		gameDataGroup.GET("/:table/:id", gd.GetTableEntry)
+	}
+}
@@ -0,0 +1,19 @@
+package groups
+
+import (
+	cc "server/controller/leaderboard"
+	"server/middleware"
+
+	"github.com/gin-gonic/gin"
+)
+
+// RegisterLeaderboardRoutes registers leaderboard routes under /api/v1/leaderboard
+func RegisterLeaderboardRoutes(rg *gin.RouterGroup) {
+	leaderboardGroup := rg.Group("/leaderboard")
+	leaderboardGroup.Use(middleware.CacheMiddleware())
+	{
+		leaderboardGroup.GET("", cc.GetLeaderboard)
+		leaderboardGroup.GET("/speed", cc.GetSpeedLeaderboard)
+		leaderboardGroup.GET("/master-mode", cc.GetMasterModeLeaderboard)
+	}
+}
@@ -0,0 +1,13 @@
+package groups
+
+import (
+	cc "server/controller/patch"
+	"server/middleware"
+
+	"github.com/gin-gonic/gin"
+)
+
+// RegisterPatchRoutes registers the public patch list under /patches
+func RegisterPatchRoutes(rg *gin.RouterGroup) {
+	rg.GET("/patches", middleware.CacheMiddleware(), cc.ListPatches)
+}
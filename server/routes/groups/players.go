@@ -0,0 +1,24 @@
+package groups
+
+import (
+	cc "server/controller/player"
+	"server/middleware"
+
+	"github.com/gin-gonic/gin"
+)
+
+// RegisterPlayersRoutes registers per-character routes under /api/v1/players
+func RegisterPlayersRoutes(rg *gin.RouterGroup) {
+	playersGroup := rg.Group("/players")
+
+	// Search never returns private characters, so it is safe to cache
+	playersGroup.GET("/search", middleware.CacheMiddleware(), cc.SearchPlayers)
+
+	// Character pages honour the privacy opt-out, and owners can see their own private
+	// character, so responses depend on the requester and are not cached.
+	playersGroup.GET("/:actorId", middleware.OptionalAuth(), cc.GetPlayerProfile)
+	playersGroup.GET("/:actorId/parses", middleware.OptionalAuth(), cc.GetPlayerParses)
+	playersGroup.GET("/:actorId/progress", middleware.OptionalAuth(), cc.GetPlayerProgress)
+	playersGroup.PUT("/:actorId/privacy", middleware.RequireAuth(), cc.SetPlayerPrivacy)
+	playersGroup.POST("/:actorId/claim", middleware.RequireAuth(), cc.ClaimCharacter)
+}
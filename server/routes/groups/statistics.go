@@ -14,6 +14,11 @@ func RegisterStatisticsRoutes(rg *gin.RouterGroup) {
 	{
 		g.GET("", cc.GetOverview)
 		g.GET("/classes", cc.GetClassStats)
+		g.GET("/classes/trend", cc.GetClassTrend)
+		g.GET("/histogram", cc.GetHistogram)
+		g.GET("/ability-regression", cc.GetAbilityRegression)
+		g.GET("/talents", cc.GetTalentStats)
+		g.GET("/modules", cc.GetModuleStats)
 		g.GET("/total", cc.GetTotals)
 	}
 }
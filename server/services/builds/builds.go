@@ -0,0 +1,96 @@
+package builds
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"server/lib/charserialize"
+	"server/models"
+	"server/services/module_optimizer"
+
+	"gorm.io/datatypes"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// buildVersion is the current CharacterBuild.Version. Bump it when deriveBuild extracts
+// something new, and Backfill derives the stored snapshots again.
+const buildVersion = 1
+
+// BuildService maintains the character_builds records derived from CharSerialize
+// snapshots.
+type BuildService struct {
+	db *gorm.DB
+}
+
+// NewBuildService creates a new build service instance
+func NewBuildService(db *gorm.DB) *BuildService {
+	return &BuildService{db: db}
+}
+
+// deriveBuild derives a character's build record from a CharSerialize snapshot taken at
+// lastSeenMs. ok is false when the snapshot doesn't decode.
+func deriveBuild(playerID, lastSeenMs int64, charSerializeJSON string) (build models.CharacterBuild, ok bool) {
+	doc, err := charserialize.DecodeString(charSerializeJSON)
+	if err != nil {
+		return build, false
+	}
+	build = models.CharacterBuild{PlayerID: playerID, LastSeenMs: lastSeenMs, Version: buildVersion, UpdatedAt: time.Now()}
+	if doc.ProfessionList != nil && doc.ProfessionList.CurProfessionId > 0 {
+		id := int64(doc.ProfessionList.CurProfessionId)
+		build.ProfessionID = &id
+	}
+	if modules, ok := doc.EquippedModules(); ok && len(modules) > 0 {
+		levels := map[int]int{}
+		for attrID, total := range module_optimizer.AttributeTotals(modules) {
+			if level := module_optimizer.CalculateAttributeLevel(total); level > 0 {
+				levels[attrID] = level
+			}
+		}
+		if raw, err := json.Marshal(levels); err == nil {
+			build.ModuleLevels = datatypes.JSON(raw)
+		}
+	}
+	return build, true
+}
+
+// RecordSnapshot updates a character's build from a CharSerialize snapshot taken at
+// lastSeenMs. A newer stored snapshot is kept. Snapshots that don't decode are ignored.
+func (s *BuildService) RecordSnapshot(playerID, lastSeenMs int64, charSerializeJSON string) error {
+	if charSerializeJSON == "" {
+		return nil
+	}
+	build, ok := deriveBuild(playerID, lastSeenMs, charSerializeJSON)
+	if !ok {
+		return nil
+	}
+	if err := s.db.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "player_id"}},
+		DoUpdates: clause.AssignmentColumns([]string{"profession_id", "module_levels", "version", "last_seen_ms", "updated_at"}),
+		Where:     clause.Where{Exprs: []clause.Expression{gorm.Expr("character_builds.last_seen_ms <= excluded.last_seen_ms")}},
+	}).Create(&build).Error; err != nil {
+		return fmt.Errorf("failed to record build of player %d: %w", playerID, err)
+	}
+	return nil
+}
+
+// Backfill records the build of every stored snapshot without an up-to-date record, for
+// data uploaded before builds were derived at ingest or by an older buildVersion. Once
+// caught up it reads no snapshots.
+func (s *BuildService) Backfill() error {
+	var batch []models.DetailedPlayerData
+	return s.db.Model(&models.DetailedPlayerData{}).
+		Select("player_id", "last_seen_ms", "char_serialize_json").
+		Where(`NOT EXISTS (SELECT 1 FROM character_builds b
+			WHERE b.player_id = detailed_playerdata.player_id AND b.last_seen_ms >= detailed_playerdata.last_seen_ms
+			  AND b.version >= ?)`, buildVersion).
+		FindInBatches(&batch, 100, func(tx *gorm.DB, _ int) error {
+			for _, pd := range batch {
+				if err := s.RecordSnapshot(pd.PlayerID, pd.LastSeenMs, pd.CharSerializeJSON); err != nil {
+					return err
+				}
+			}
+			return nil
+		}).Error
+}
@@ -0,0 +1,70 @@
+package builds
+
+import (
+	"encoding/json"
+	"os"
+	"strings"
+	"testing"
+
+	dbpkg "server/db"
+)
+
+func TestDeriveBuild_ReadsCurrentProfession(t *testing.T) {
+	build, ok := deriveBuild(7, 1000, `{"ProfessionList": {"CurProfessionId": "11", "ProfessionList": {}}}`)
+	if !ok {
+		t.Fatal("Expected the snapshot to decode")
+	}
+	if build.ProfessionID == nil || *build.ProfessionID != 11 {
+		t.Errorf("Expected profession 11, got %v", build.ProfessionID)
+	}
+
+	if build, ok = deriveBuild(7, 1000, `{"CharBase": {}}`); !ok || build.ProfessionID != nil {
+		t.Errorf("Expected no profession without a ProfessionList, got %v", build.ProfessionID)
+	}
+	if _, ok = deriveBuild(7, 1000, `[]`); ok {
+		t.Error("Expected a non-object snapshot not to decode")
+	}
+}
+
+func TestDeriveBuild_StoresModuleLevels(t *testing.T) {
+	raw, err := os.ReadFile("../../lib/charserialize/testdata/snapshot_strings.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	build, ok := deriveBuild(7, 1000, string(raw))
+	if !ok || build.Version != buildVersion {
+		t.Fatalf("Expected a current build, got %+v (ok=%v)", build, ok)
+	}
+	var levels map[int]int
+	if err := json.Unmarshal(build.ModuleLevels, &levels); err != nil {
+		t.Fatalf("Expected module levels, got %s: %v", build.ModuleLevels, err)
+	}
+	for id, level := range levels {
+		if level < 1 {
+			t.Errorf("Expected only levels 1+, got %d for attribute %d", level, id)
+		}
+	}
+
+	// Snapshots without slot data have no module levels rather than empty ones
+	raw, err = os.ReadFile("../../lib/charserialize/testdata/snapshot_numbers.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if build, ok = deriveBuild(7, 1000, string(raw)); !ok || build.ModuleLevels != nil {
+		t.Errorf("Expected no module levels without slot data, got %s", build.ModuleLevels)
+	}
+}
+
+func TestRecordSnapshot_KeepsNewerSnapshot(t *testing.T) {
+	db, stmts, err := dbpkg.DryRun()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := NewBuildService(db).RecordSnapshot(7, 1000, `{"ProfessionList": {"CurProfessionId": 2}}`); err != nil {
+		t.Fatal(err)
+	}
+	inserts := stmts.Matching(`INSERT INTO "character_builds"`)
+	if len(inserts) != 1 || !strings.Contains(inserts[0], "character_builds.last_seen_ms <= excluded.last_seen_ms") {
+		t.Errorf("Expected an upsert keeping newer snapshots, got %v", stmts.All())
+	}
+}
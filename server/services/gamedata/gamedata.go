@@ -0,0 +1,328 @@
+// Package gamedata holds the static reference tables (skills, classes and specs, scenes and
+// bosses, monsters, talent nodes) used to turn the IDs stored with encounters into names.
+//
+// Each table is a versioned JSON file, {"version": "...", "entries": [...]}. Only skills
+// and classes ship in data/ and are built into the binary; there is no built-in scene,
+// monster or talent data. A directory named by GAMEDATA_DIR overrides the built-in tables
+// file by file and supplies the others, so a game patch only needs new files and a
+// restart. A table without a file or without entries is left out: it is not served, and
+// its lookups find nothing. Without scenes and monsters, uploaded scene and boss names
+// are stored as sent: they are not resolved to IDs, deduped across languages or localized.
+//
+// Names are in English, with translations keyed by locale in each entry's "names". The
+// catalog maps every spelling back to its ID, which is how names uploaded by clients in
+// other languages are canonicalised (see locale.go).
+package gamedata
+
+import (
+	"crypto/sha256"
+	"embed"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"log"
+	"os"
+	"path"
+	"sort"
+	"sync/atomic"
+)
+
+//go:embed data/*.json
+var embedded embed.FS
+
+// Table names, also the path segment under /gamedata.
+const (
+	TableSkills   = "skills"
+	TableClasses  = "classes"
+	TableScenes   = "scenes"
+	TableMonsters = "monsters"
+	TableTalents  = "talents"
+)
+
+// TableNames lists the tables in the order the index endpoint reports them.
+var TableNames = []string{TableSkills, TableClasses, TableScenes, TableMonsters, TableTalents}
+
+type Skill struct {
+	ID       int64             `json:"id"`
+	Name     string            `json:"name"`
+	Names    map[string]string `json:"names,omitempty"`    // locale -> localized name
+	AoyiName string            `json:"aoyiName,omitempty"` // the imagine granting an aoyi skill
+	Icon     string            `json:"icon,omitempty"`
+}
+
+type Class struct {
+	ID    int64  `json:"id"`
+	Name  string `json:"name"`
+	Specs []Spec `json:"specs"`
+}
+
+type Spec struct {
+	ID   int64  `json:"id"`
+	Name string `json:"name"`
+	Role string `json:"role"` // damage, healer or tank
+}
+
+type Scene struct {
+	ID         int64             `json:"id"`
+	Name       string            `json:"name"`
+	Names      map[string]string `json:"names,omitempty"` // locale -> localized name
+	Difficulty string            `json:"difficulty,omitempty"`
+	Bosses     []Boss            `json:"bosses,omitempty"`
+}
+
+type Boss struct {
+	ID   int64  `json:"id"`
+	Name string `json:"name"`
+}
+
+// Monster is an enemy, keyed by the game's monster ID. Bosses appear here under the same
+// ID as in their scene.
+type Monster struct {
+	ID    int64             `json:"id"`
+	Name  string            `json:"name"`
+	Names map[string]string `json:"names,omitempty"` // locale -> localized name
+}
+
+type TalentNode struct {
+	ID          int64  `json:"id"`
+	Name        string `json:"name"`
+	ClassID     int64  `json:"classId,omitempty"`
+	Description string `json:"description,omitempty"`
+}
+
+// Table is one loaded table as served by the API.
+type Table struct {
+	Name    string `json:"name"`
+	Version string `json:"version"`
+	ETag    string `json:"etag"`
+	Count   int    `json:"count"`
+
+	body    []byte
+	entries map[int64]json.RawMessage
+}
+
+// Body returns the table's JSON, {"version": "...", "entries": [...]}.
+func (t *Table) Body() []byte { return t.body }
+
+// Entry returns a single entry's JSON.
+func (t *Table) Entry(id int64) (json.RawMessage, bool) {
+	e, ok := t.entries[id]
+	return e, ok
+}
+
+// Catalog is a loaded set of tables. It is immutable once loaded.
+type Catalog struct {
+	skills   map[int64]Skill
+	classes  map[int64]Class
+	specs    map[int64]Spec
+	scenes   map[int64]Scene
+	monsters map[int64]Monster
+	talents  map[int64]TalentNode
+	tables   map[string]*Table
+
+	// Name lookups built by indexNames
+	sceneIDs   map[string]int64
+	monsterIDs map[string]int64
+	locales    []string
+}
+
+var current atomic.Pointer[Catalog]
+
+// Init loads the catalog from the built-in tables and GAMEDATA_DIR, and makes it the one
+// returned by Get.
+func Init() error {
+	c, err := Load(os.Getenv("GAMEDATA_DIR"))
+	if err != nil {
+		return err
+	}
+	// Neither is built in, and scene and boss names go unresolved without them
+	for _, name := range []string{TableScenes, TableMonsters} {
+		if _, ok := c.Table(name); !ok {
+			log.Printf("[GameData] No %s table, provide %s.json in GAMEDATA_DIR", name, name)
+		}
+	}
+	current.Store(c)
+	return nil
+}
+
+// Get returns the current catalog, loading the built-in tables if Init was not called
+// (or failed).
+func Get() *Catalog {
+	if c := current.Load(); c != nil {
+		return c
+	}
+	c, err := Load("")
+	if err != nil {
+		log.Printf("[GameData] %v", err)
+		c = &Catalog{tables: map[string]*Table{}}
+	}
+	current.CompareAndSwap(nil, c)
+	return current.Load()
+}
+
+// Load reads every table, preferring files in dir (when not empty) over the built-in ones.
+func Load(dir string) (*Catalog, error) {
+	var override fs.FS
+	if dir != "" {
+		override = os.DirFS(dir)
+	}
+	read := func(name string) ([]byte, error) {
+		file := name + ".json"
+		if override != nil {
+			data, err := fs.ReadFile(override, file)
+			if err == nil {
+				return data, nil
+			}
+			if !errors.Is(err, fs.ErrNotExist) {
+				return nil, err
+			}
+		}
+		return embedded.ReadFile(path.Join("data", file))
+	}
+
+	c := &Catalog{tables: make(map[string]*Table, len(TableNames))}
+	var err error
+	if c.skills, err = loadTable(c, TableSkills, read, func(s Skill) int64 { return s.ID }); err != nil {
+		return nil, err
+	}
+	if c.classes, err = loadTable(c, TableClasses, read, func(cl Class) int64 { return cl.ID }); err != nil {
+		return nil, err
+	}
+	if c.scenes, err = loadTable(c, TableScenes, read, func(s Scene) int64 { return s.ID }); err != nil {
+		return nil, err
+	}
+	if c.monsters, err = loadTable(c, TableMonsters, read, func(m Monster) int64 { return m.ID }); err != nil {
+		return nil, err
+	}
+	if c.talents, err = loadTable(c, TableTalents, read, func(t TalentNode) int64 { return t.ID }); err != nil {
+		return nil, err
+	}
+
+	c.specs = make(map[int64]Spec)
+	for _, cl := range c.classes {
+		for _, s := range cl.Specs {
+			if _, dup := c.specs[s.ID]; dup {
+				return nil, fmt.Errorf("gamedata: %s: duplicate spec id %d", TableClasses, s.ID)
+			}
+			c.specs[s.ID] = s
+		}
+	}
+	c.indexNames()
+	return c, nil
+}
+
+type tableFile[T any] struct {
+	Version string `json:"version"`
+	Entries []T    `json:"entries"`
+}
+
+func loadTable[T any](c *Catalog, name string, read func(string) ([]byte, error), id func(T) int64) (map[int64]T, error) {
+	data, err := read(name)
+	if errors.Is(err, fs.ErrNotExist) {
+		return map[int64]T{}, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("gamedata: %s: %w", name, err)
+	}
+	var f tableFile[T]
+	if err := json.Unmarshal(data, &f); err != nil {
+		return nil, fmt.Errorf("gamedata: %s: %w", name, err)
+	}
+	if f.Version == "" {
+		return nil, fmt.Errorf("gamedata: %s: missing version", name)
+	}
+	if len(f.Entries) == 0 {
+		return map[int64]T{}, nil
+	}
+	sort.SliceStable(f.Entries, func(i, j int) bool { return id(f.Entries[i]) < id(f.Entries[j]) })
+
+	byID := make(map[int64]T, len(f.Entries))
+	raw := make(map[int64]json.RawMessage, len(f.Entries))
+	for _, e := range f.Entries {
+		k := id(e)
+		if k <= 0 {
+			return nil, fmt.Errorf("gamedata: %s: entry without a positive id", name)
+		}
+		if _, dup := byID[k]; dup {
+			return nil, fmt.Errorf("gamedata: %s: duplicate id %d", name, k)
+		}
+		byID[k] = e
+		if raw[k], err = json.Marshal(e); err != nil {
+			return nil, fmt.Errorf("gamedata: %s: %w", name, err)
+		}
+	}
+
+	body, err := json.Marshal(f)
+	if err != nil {
+		return nil, fmt.Errorf("gamedata: %s: %w", name, err)
+	}
+	sum := sha256.Sum256(body)
+	c.tables[name] = &Table{
+		Name:    name,
+		Version: f.Version,
+		ETag:    `"` + f.Version + "-" + hex.EncodeToString(sum[:8]) + `"`,
+		Count:   len(f.Entries),
+		body:    body,
+		entries: raw,
+	}
+	return byID, nil
+}
+
+// Table returns a table by name.
+func (c *Catalog) Table(name string) (*Table, bool) {
+	t, ok := c.tables[name]
+	return t, ok
+}
+
+// Tables returns every table in TableNames order.
+func (c *Catalog) Tables() []*Table {
+	out := make([]*Table, 0, len(c.tables))
+	for _, name := range TableNames {
+		if t, ok := c.tables[name]; ok {
+			out = append(out, t)
+		}
+	}
+	return out
+}
+
+func (c *Catalog) Skill(id int64) (Skill, bool) {
+	s, ok := c.skills[id]
+	return s, ok
+}
+
+func (c *Catalog) Class(id int64) (Class, bool) {
+	cl, ok := c.classes[id]
+	return cl, ok
+}
+
+func (c *Catalog) Spec(id int64) (Spec, bool) {
+	s, ok := c.specs[id]
+	return s, ok
+}
+
+func (c *Catalog) Scene(id int64) (Scene, bool) {
+	s, ok := c.scenes[id]
+	return s, ok
+}
+
+// Scenes returns every scene, ordered by ID.
+func (c *Catalog) Scenes() []Scene {
+	out := make([]Scene, 0, len(c.scenes))
+	for _, s := range c.scenes {
+		out = append(out, s)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].ID < out[j].ID })
+	return out
+}
+
+func (c *Catalog) Monster(id int64) (Monster, bool) {
+	m, ok := c.monsters[id]
+	return m, ok
+}
+
+func (c *Catalog) Talent(id int64) (TalentNode, bool) {
+	t, ok := c.talents[id]
+	return t, ok
+}
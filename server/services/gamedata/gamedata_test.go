@@ -0,0 +1,49 @@
+package gamedata
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestLoad_LeavesOutTablesWithoutData(t *testing.T) {
+	c, err := Load("")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, name := range []string{TableSkills, TableClasses} {
+		if tbl, ok := c.Table(name); !ok || tbl.Count == 0 {
+			t.Errorf("Expected the built-in %s table", name)
+		}
+	}
+	for _, name := range []string{TableScenes, TableMonsters, TableTalents} {
+		if _, ok := c.Table(name); ok {
+			t.Errorf("Expected no %s table without data", name)
+		}
+	}
+}
+
+func TestLoad_DirSuppliesTables(t *testing.T) {
+	dir := t.TempDir()
+	write := func(name, body string) {
+		if err := os.WriteFile(filepath.Join(dir, name+".json"), []byte(body), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	write(TableScenes, `{"version": "1", "entries": [{"id": 1001, "name": "Dragon's Lair"}]}`)
+	write(TableTalents, `{"version": "1", "entries": []}`)
+
+	c, err := Load(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if tbl, ok := c.Table(TableScenes); !ok || tbl.Count != 1 {
+		t.Errorf("Expected the scenes table from the directory, got %+v", tbl)
+	}
+	if _, ok := c.Scene(1001); !ok {
+		t.Error("Expected scene 1001")
+	}
+	if _, ok := c.Table(TableTalents); ok {
+		t.Error("Expected an empty table not to be served")
+	}
+}
@@ -0,0 +1,164 @@
+package gamedata
+
+import (
+	"sort"
+	"strings"
+
+	"server/lib"
+)
+
+// ambiguous marks a name shared by several IDs, which is never resolved.
+const ambiguous = -1
+
+// normalizeName is the form names are compared in.
+func normalizeName(name string) string {
+	return strings.ToLower(strings.Join(strings.Fields(name), " "))
+}
+
+// indexNames builds the name-to-ID lookups and the locale list.
+func (c *Catalog) indexNames() {
+	locales := map[string]bool{}
+	index := func(m map[string]int64, id int64, name string, names map[string]string) {
+		add := func(n string) {
+			key := normalizeName(n)
+			if key == "" {
+				return
+			}
+			if prev, ok := m[key]; ok && prev != id {
+				m[key] = ambiguous
+				return
+			}
+			m[key] = id
+		}
+		add(name)
+		for locale, n := range names {
+			locales[locale] = true
+			add(n)
+		}
+	}
+
+	c.sceneIDs = map[string]int64{}
+	c.monsterIDs = map[string]int64{}
+	for _, s := range c.scenes {
+		index(c.sceneIDs, s.ID, s.Name, s.Names)
+		for _, b := range s.Bosses {
+			index(c.monsterIDs, b.ID, b.Name, nil)
+		}
+	}
+	for _, m := range c.monsters {
+		index(c.monsterIDs, m.ID, m.Name, m.Names)
+	}
+	for _, s := range c.skills {
+		for locale := range s.Names {
+			locales[locale] = true
+		}
+	}
+
+	c.locales = make([]string, 0, len(locales))
+	for l := range locales {
+		c.locales = append(c.locales, l)
+	}
+	sort.Strings(c.locales)
+}
+
+// Locales returns the locales the catalog has translations for. English, the language of
+// the base names, is the default and is not listed.
+func (c *Catalog) Locales() []string {
+	return c.locales
+}
+
+// MatchLocale resolves an Accept-Language header to one of Locales, or "" for English.
+func (c *Catalog) MatchLocale(acceptLanguage string) string {
+	if len(c.locales) == 0 {
+		return ""
+	}
+	return lib.MatchAcceptLanguage(acceptLanguage, c.locales)
+}
+
+// SceneID resolves a scene name in any language to its ID.
+func (c *Catalog) SceneID(name string) (int64, bool) {
+	id, ok := c.sceneIDs[normalizeName(name)]
+	return id, ok && id != ambiguous
+}
+
+// MonsterID resolves a monster or boss name in any language to its ID.
+func (c *Catalog) MonsterID(name string) (int64, bool) {
+	id, ok := c.monsterIDs[normalizeName(name)]
+	return id, ok && id != ambiguous
+}
+
+// SceneNameIDs returns every scene name (normalised) that resolves to a single scene.
+func (c *Catalog) SceneNameIDs() map[string]int64 {
+	return resolvable(c.sceneIDs)
+}
+
+// MonsterNameIDs returns every monster name (normalised) that resolves to a single monster.
+func (c *Catalog) MonsterNameIDs() map[string]int64 {
+	return resolvable(c.monsterIDs)
+}
+
+func resolvable(index map[string]int64) map[string]int64 {
+	out := make(map[string]int64, len(index))
+	for name, id := range index {
+		if id != ambiguous {
+			out[name] = id
+		}
+	}
+	return out
+}
+
+// localize returns the name for locale, falling back to the base name.
+func localize(name string, names map[string]string, locale string) string {
+	if n := names[locale]; locale != "" && n != "" {
+		return n
+	}
+	return name
+}
+
+// SceneName returns a scene's name in locale ("" for English).
+func (c *Catalog) SceneName(id int64, locale string) (string, bool) {
+	s, ok := c.scenes[id]
+	if !ok {
+		return "", false
+	}
+	return localize(s.Name, s.Names, locale), true
+}
+
+// MonsterName returns a monster's name in locale ("" for English).
+func (c *Catalog) MonsterName(id int64, locale string) (string, bool) {
+	if m, ok := c.monsters[id]; ok {
+		return localize(m.Name, m.Names, locale), true
+	}
+	return "", false
+}
+
+// SkillName returns a skill's name in locale ("" for English).
+func (c *Catalog) SkillName(id int64, locale string) (string, bool) {
+	s, ok := c.skills[id]
+	if !ok {
+		return "", false
+	}
+	return localize(s.Name, s.Names, locale), true
+}
+
+// LocalizeScene returns the name of scene id in locale, or uploaded (the name the
+// encounter was uploaded with) when id is nil or not in the catalog.
+func (c *Catalog) LocalizeScene(id *int64, uploaded *string, locale string) *string {
+	if id != nil {
+		if name, ok := c.SceneName(*id, locale); ok {
+			return &name
+		}
+	}
+	return uploaded
+}
+
+// LocalizeMonster returns the name of monster id in locale, or uploaded when id is nil or
+// not in the catalog.
+func (c *Catalog) LocalizeMonster(id *int64, uploaded string, locale string) string {
+	if id != nil {
+		if name, ok := c.MonsterName(*id, locale); ok {
+			return name
+		}
+	}
+	return uploaded
+}
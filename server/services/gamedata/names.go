@@ -0,0 +1,71 @@
+package gamedata
+
+// Names maps the IDs appearing in a response to their display names, in the request's
+// language where the catalog has a translation. Endpoints attach one when the client asks
+// for names (?names=true) instead of mapping IDs itself. IDs missing from the catalog are
+// left out.
+type Names struct {
+	Skills   map[int64]string `json:"skills,omitempty"`
+	Classes  map[int64]string `json:"classes,omitempty"`
+	Specs    map[int64]string `json:"specs,omitempty"`
+	Scenes   map[int64]string `json:"scenes,omitempty"`
+	Monsters map[int64]string `json:"monsters,omitempty"`
+
+	catalog *Catalog
+	locale  string
+}
+
+// NewNames starts an empty set of names resolved against c in locale ("" for English).
+func (c *Catalog) NewNames(locale string) *Names {
+	return &Names{catalog: c, locale: locale}
+}
+
+func (n *Names) AddSkill(id int64) {
+	if name, ok := n.catalog.SkillName(id, n.locale); ok {
+		n.Skills = addName(n.Skills, id, name)
+	}
+}
+
+func (n *Names) AddClass(id *int64) {
+	if id == nil {
+		return
+	}
+	if cl, ok := n.catalog.Class(*id); ok {
+		n.Classes = addName(n.Classes, *id, cl.Name)
+	}
+}
+
+func (n *Names) AddSpec(id *int64) {
+	if id == nil {
+		return
+	}
+	if s, ok := n.catalog.Spec(*id); ok {
+		n.Specs = addName(n.Specs, *id, s.Name)
+	}
+}
+
+func (n *Names) AddScene(id *int64) {
+	if id == nil {
+		return
+	}
+	if name, ok := n.catalog.SceneName(*id, n.locale); ok {
+		n.Scenes = addName(n.Scenes, *id, name)
+	}
+}
+
+func (n *Names) AddMonster(id *int64) {
+	if id == nil {
+		return
+	}
+	if name, ok := n.catalog.MonsterName(*id, n.locale); ok {
+		n.Monsters = addName(n.Monsters, *id, name)
+	}
+}
+
+func addName(m map[int64]string, id int64, name string) map[int64]string {
+	if m == nil {
+		m = make(map[int64]string)
+	}
+	m[id] = name
+	return m
+}
@@ -0,0 +1,170 @@
+package moderation
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"server/lib"
+	"server/models"
+	"server/services/scenes"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// Distribution check: a player's DPS is flagged when it lies beyond Q3 + OutlierIQRs·IQR
+// of their class spec in the same scene, once there are at least MinPeerSamples peers.
+// This is far stricter than the 1.5·IQR outliers GetClassStats reports.
+const (
+	OutlierIQRs    = 3.0
+	MinPeerSamples = 30
+)
+
+// ListedSQL is the condition keeping held encounters (alias e) off leaderboards.
+func ListedSQL(alias string) string {
+	return alias + ".held = false"
+}
+
+// peerFencesSQL returns, for each player of the encounters @ids, their DPS and the
+// distribution of listed peers with the same class spec in the same scene. The quartiles
+// are computed once per (spec, scene) of the batch, over encounters outside it. %[1]s and
+// %[2]s are the scene keys of e2 and e; %[3]s keeps listed encounters e2.
+const peerFencesSQL = `
+	WITH batch AS (
+		SELECT a.encounter_id, a.name, a.class_spec, a.dps, %[2]s AS scene_key
+		FROM actor_encounter_stats a
+		JOIN encounters e ON e.id = a.encounter_id
+		WHERE a.encounter_id IN @ids AND a.is_player = true
+		  AND a.class_spec IS NOT NULL AND %[2]s IS NOT NULL
+	), fences AS (
+		SELECT a2.class_spec, %[1]s AS scene_key, COUNT(*) AS n,
+			   COALESCE(percentile_cont(0.25) WITHIN GROUP (ORDER BY a2.dps), 0) AS q1,
+			   COALESCE(percentile_cont(0.75) WITHIN GROUP (ORDER BY a2.dps), 0) AS q3
+		FROM actor_encounter_stats a2
+		JOIN encounters e2 ON e2.id = a2.encounter_id
+		WHERE a2.is_player = true AND %[3]s AND e2.id NOT IN @ids
+		  AND (a2.class_spec, %[1]s) IN (SELECT DISTINCT class_spec, scene_key FROM batch)
+		GROUP BY a2.class_spec, %[1]s
+	)
+	SELECT b.encounter_id, b.name, b.class_spec, b.dps, f.n, f.q1, f.q3
+	FROM batch b
+	JOIN fences f ON f.class_spec = b.class_spec AND f.scene_key = b.scene_key`
+
+// ModerationService runs plausibility checks and manages the review queue.
+type ModerationService struct {
+	db *gorm.DB
+}
+
+// NewModerationService creates a new moderation service instance
+func NewModerationService(db *gorm.DB) *ModerationService {
+	return &ModerationService{db: db}
+}
+
+// CheckEncounters runs the plausibility checks on freshly ingested encounters. Encounters
+// that fail get a pending review and are held. An encounter that already has a review
+// keeps it, so checking it again never overrides a moderator's decision. Run it in the
+// ingest transaction, after the encounters are linked to their scenes, so no encounter is
+// ever listed unchecked.
+func (s *ModerationService) CheckEncounters(encounterIDs []int64) error {
+	if len(encounterIDs) == 0 {
+		return nil
+	}
+
+	var encounters []models.Encounter
+	if err := s.db.Preload("Players").Where("id IN ?", encounterIDs).Find(&encounters).Error; err != nil {
+		return fmt.Errorf("failed to load encounters for checks: %w", err)
+	}
+
+	flags := make(map[int64][]lib.PlausibilityFlag, len(encounters))
+	for _, enc := range encounters {
+		actors := make([]lib.PlausibilityActor, 0, len(enc.Players))
+		for _, a := range enc.Players {
+			name := fmt.Sprintf("actor %d", a.ActorID)
+			if a.Name != nil && *a.Name != "" {
+				name = *a.Name
+			}
+			actors = append(actors, lib.PlausibilityActor{
+				Name:            name,
+				IsPlayer:        a.IsPlayer,
+				DamageDealt:     a.DamageDealt,
+				HealDealt:       a.HealDealt,
+				DamageTaken:     a.DamageTaken,
+				BossDamageDealt: a.BossDamageDealt,
+				HitsDealt:       a.HitsDealt,
+				CritHitsDealt:   a.CritHitsDealt,
+				LuckyHitsDealt:  a.LuckyHitsDealt,
+				CritTotalDealt:  a.CritTotalDealt,
+				LuckyTotalDealt: a.LuckyTotalDealt,
+				DPS:             a.DPS,
+				Duration:        a.Duration,
+			})
+		}
+		if f := lib.CheckEncounterConsistency(lib.PlausibilityEncounter{Duration: enc.Duration, TotalDmg: enc.TotalDmg}, actors); len(f) > 0 {
+			flags[enc.ID] = f
+		}
+	}
+
+	var peers []struct {
+		EncounterID int64   `gorm:"column:encounter_id"`
+		Name        *string `gorm:"column:name"`
+		ClassSpec   int64   `gorm:"column:class_spec"`
+		DPS         float64 `gorm:"column:dps"`
+		N           int64   `gorm:"column:n"`
+		Q1          float64 `gorm:"column:q1"`
+		Q3          float64 `gorm:"column:q3"`
+	}
+	query := fmt.Sprintf(peerFencesSQL, scenes.KeySQL("e2"), scenes.KeySQL("e"), ListedSQL("e2"))
+	if err := s.db.Raw(query, map[string]interface{}{"ids": encounterIDs}).Scan(&peers).Error; err != nil {
+		return fmt.Errorf("failed to compare with peer distributions: %w", err)
+	}
+	for _, p := range peers {
+		if p.N < MinPeerSamples {
+			continue
+		}
+		if fence := p.Q3 + OutlierIQRs*(p.Q3-p.Q1); p.DPS > fence {
+			name := "a player"
+			if p.Name != nil && *p.Name != "" {
+				name = *p.Name
+			}
+			flags[p.EncounterID] = append(flags[p.EncounterID], lib.PlausibilityFlag{
+				Code:    "dps_outlier",
+				Message: fmt.Sprintf("%s's %.0f DPS is far above spec %d's range in this scene (fence %.0f over %d parses)", name, p.DPS, p.ClassSpec, fence, p.N),
+			})
+		}
+	}
+
+	for encounterID, f := range flags {
+		payload, err := json.Marshal(f)
+		if err != nil {
+			return err
+		}
+		err = s.db.Transaction(func(tx *gorm.DB) error {
+			res := tx.Clauses(clause.OnConflict{Columns: []clause.Column{{Name: "encounter_id"}}, DoNothing: true}).
+				Create(&models.EncounterReview{EncounterID: encounterID, Status: models.ReviewPending, Flags: payload})
+			if res.Error != nil || res.RowsAffected == 0 {
+				return res.Error
+			}
+			return tx.Model(&models.Encounter{}).Where("id = ?", encounterID).Update("held", true).Error
+		})
+		if err != nil {
+			return fmt.Errorf("failed to queue encounter %d for review: %w", encounterID, err)
+		}
+	}
+	return nil
+}
+
+// Resolve records a moderator's decision. Approved encounters return to leaderboards;
+// rejected ones stay held.
+func (s *ModerationService) Resolve(review *models.EncounterReview, status string, reviewerID uint, note *string) error {
+	return s.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(review).Updates(map[string]interface{}{
+			"status":         status,
+			"note":           note,
+			"reviewed_by_id": reviewerID,
+			"reviewed_at":    gorm.Expr("NOW()"),
+		}).Error; err != nil {
+			return err
+		}
+		return tx.Model(&models.Encounter{}).Where("id = ?", review.EncounterID).Update("held", status != models.ReviewApproved).Error
+	})
+}
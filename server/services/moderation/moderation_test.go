@@ -0,0 +1,73 @@
+package moderation
+
+import (
+	"strings"
+	"testing"
+
+	dbpkg "server/db"
+	"server/models"
+)
+
+func TestCheckEncounters_ComputesFencesOncePerBatch(t *testing.T) {
+	db, stmts, err := dbpkg.DryRun()
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Raw scans fail on a dry-run DB, after the statement is recorded
+	_ = NewModerationService(db).CheckEncounters([]int64{4, 7})
+	fences := stmts.Matching("percentile_cont")
+	if len(fences) != 1 {
+		t.Fatalf("Expected one peer query for the batch, got %v", stmts.All())
+	}
+	q := fences[0]
+	if strings.Contains(q, "LATERAL") {
+		t.Errorf("Expected fences not to be computed per player, got %s", q)
+	}
+	for _, want := range []string{
+		"a.encounter_id IN (4,7)",
+		"e2.id NOT IN (4,7)",
+		"e2.held = false",
+		"GROUP BY a2.class_spec",
+	} {
+		if !strings.Contains(q, want) {
+			t.Errorf("Expected the peer query to contain %q, got %s", want, q)
+		}
+	}
+}
+
+func TestCheckEncounters_NoEncounters(t *testing.T) {
+	db, stmts, err := dbpkg.DryRun()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := NewModerationService(db).CheckEncounters(nil); err != nil {
+		t.Fatal(err)
+	}
+	if got := stmts.All(); len(got) != 0 {
+		t.Errorf("Expected no statements, got %v", got)
+	}
+}
+
+func TestResolve_SetsHeldFromStatus(t *testing.T) {
+	for status, held := range map[string]string{
+		models.ReviewApproved: `"held"=false`,
+		models.ReviewRejected: `"held"=true`,
+	} {
+		db, stmts, err := dbpkg.DryRun()
+		if err != nil {
+			t.Fatal(err)
+		}
+		review := &models.EncounterReview{ID: 3, EncounterID: 7, Status: models.ReviewPending}
+		if err := NewModerationService(db).Resolve(review, status, 2, nil); err != nil {
+			t.Fatal(err)
+		}
+		reviews := stmts.Matching(`UPDATE "encounter_reviews"`)
+		if len(reviews) != 1 || !strings.Contains(reviews[0], "'"+status+"'") || !strings.Contains(reviews[0], `"id" = 3`) {
+			t.Errorf("%s: expected review 3 to be resolved, got %v", status, stmts.All())
+		}
+		encounters := stmts.Matching(`UPDATE "encounters"`)
+		if len(encounters) != 1 || !strings.Contains(encounters[0], held) || !strings.Contains(encounters[0], "id = 7") {
+			t.Errorf("%s: expected encounter 7 to be updated with %s, got %v", status, held, stmts.All())
+		}
+	}
+}
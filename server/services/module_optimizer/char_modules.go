@@ -0,0 +1,15 @@
+package module_optimizer
+
+import "server/lib/charserialize"
+
+// AttributeTotals sums attribute values across modules, keyed by attribute (part) ID.
+// Attribute levels follow from the totals (see CalculateAttributeLevel).
+func AttributeTotals(modules []charserialize.Module) map[int]int {
+	totals := make(map[int]int)
+	for _, m := range modules {
+		for _, p := range m.Parts {
+			totals[p.PartID] += p.Value
+		}
+	}
+	return totals
+}
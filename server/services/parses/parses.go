@@ -0,0 +1,152 @@
+package parses
+
+import (
+	"fmt"
+	"log"
+	"time"
+
+	"server/services/moderation"
+	"server/services/scenes"
+
+	"gorm.io/gorm"
+)
+
+// AbilityBracketSize is the width of the ability-score brackets parses are compared within,
+// matching the brackets reported by the statistics totals endpoint.
+const AbilityBracketSize = 1000
+
+// ParseService ranks player performances against their peers.
+type ParseService struct {
+	db *gorm.DB
+}
+
+// NewParseService creates a new parse ranking service instance
+func NewParseService(db *gorm.DB) *ParseService {
+	return &ParseService{db: db}
+}
+
+// peerKeySQL is the peer group (scene, class spec, ability-score bracket) of the player row
+// a of encounter e.
+func peerKeySQL() string {
+	return peerKeyInSceneSQL(scenes.KeySQL("e"))
+}
+
+// peerKeyInSceneSQL is peerKeySQL with the scene key given by sceneKey.
+func peerKeyInSceneSQL(sceneKey string) string {
+	return fmt.Sprintf("COALESCE(%s, ''), COALESCE(a.class_spec, -1), FLOOR(COALESCE(a.ability_score, 0)::double precision / %d)",
+		sceneKey, AbilityBracketSize)
+}
+
+// percentileSQL ranks player rows of listed (unheld) encounters by DPS within their peer
+// group. cume_dist gives the share of peers at or below the row, so the best parse in a
+// group is 100 and a lone parse is 100 as well. scope restricts the rows that are ranked and
+// must keep whole peer groups, or ranks would be computed against a partial group. Rows
+// whose percentile is unchanged are not rewritten.
+func percentileSQL(scope string) string {
+	return `
+	WITH ranked AS (
+		SELECT a.id,
+			   cume_dist() OVER (PARTITION BY ` + peerKeySQL() + ` ORDER BY a.dps) * 100 AS pct
+		FROM actor_encounter_stats a
+		JOIN encounters e ON e.id = a.encounter_id
+		WHERE a.is_player = true AND ` + moderation.ListedSQL("e") + scope + `
+	)
+	UPDATE actor_encounter_stats t
+	SET percentile = r.pct
+	FROM ranked r
+	WHERE t.id = r.id AND t.percentile IS DISTINCT FROM r.pct`
+}
+
+// affectedGroupsSQL limits percentileSQL to the peer groups the player rows of the
+// encounters bound to its placeholder belong to.
+func affectedGroupsSQL() string {
+	return `
+		  AND (` + peerKeySQL() + `) IN (
+			  SELECT ` + peerKeySQL() + `
+			  FROM actor_encounter_stats a
+			  JOIN encounters e ON e.id = a.encounter_id
+			  WHERE a.is_player = true AND a.encounter_id IN @ids
+		  )`
+}
+
+// resolvedGroupsSQL extends affectedGroupsSQL to the peer groups the player rows of the
+// encounters bound to its placeholder belonged to before their scene was resolved, which
+// were keyed by the encounter's scene name.
+func resolvedGroupsSQL() string {
+	return `
+		  AND ((` + peerKeySQL() + `) IN (
+			  SELECT ` + peerKeySQL() + `
+			  FROM actor_encounter_stats a
+			  JOIN encounters e ON e.id = a.encounter_id
+			  WHERE a.is_player = true AND a.encounter_id IN @ids
+		  ) OR (` + peerKeySQL() + `) IN (
+			  SELECT ` + peerKeyInSceneSQL("'name:' || LOWER(e.scene_name)") + `
+			  FROM actor_encounter_stats a
+			  JOIN encounters e ON e.id = a.encounter_id
+			  WHERE a.is_player = true AND a.encounter_id IN @ids
+		  ))`
+}
+
+// unrankedSQL clears the percentiles of held encounters' rows, which are not ranked.
+var unrankedSQL = `
+	UPDATE actor_encounter_stats a
+	SET percentile = NULL
+	FROM encounters e
+	WHERE e.id = a.encounter_id AND NOT (` + moderation.ListedSQL("e") + `) AND a.percentile IS NOT NULL`
+
+// RankEncounters recomputes percentiles for the peer groups the given encounters belong to.
+// Other parses in the same groups are updated too, since a new parse shifts their rank.
+// Only those groups are ranked, so the cost follows the size of the affected groups rather
+// than the whole table.
+func (s *ParseService) RankEncounters(encounterIDs []int64) error {
+	if len(encounterIDs) == 0 {
+		return nil
+	}
+	args := map[string]interface{}{"ids": encounterIDs}
+	if err := s.db.Exec(percentileSQL(affectedGroupsSQL()), args).Error; err != nil {
+		return fmt.Errorf("failed to rank encounters: %w", err)
+	}
+	if err := s.db.Exec(unrankedSQL+" AND a.encounter_id IN @ids", args).Error; err != nil {
+		return fmt.Errorf("failed to clear held percentiles: %w", err)
+	}
+	return nil
+}
+
+// RankResolvedEncounters recomputes percentiles after the given encounters' scenes were
+// resolved (see scenes.SceneService.SyncCatalog): for the peer groups they joined and the
+// ones, keyed by their scene name, they left.
+func (s *ParseService) RankResolvedEncounters(encounterIDs []int64) error {
+	if len(encounterIDs) == 0 {
+		return nil
+	}
+	if err := s.db.Exec(percentileSQL(resolvedGroupsSQL()), map[string]interface{}{"ids": encounterIDs}).Error; err != nil {
+		return fmt.Errorf("failed to rank resolved encounters: %w", err)
+	}
+	return nil
+}
+
+// RecomputeAll recomputes percentiles for every player row.
+func (s *ParseService) RecomputeAll() error {
+	if err := s.db.Exec(percentileSQL("")).Error; err != nil {
+		return fmt.Errorf("failed to recompute percentiles: %w", err)
+	}
+	if err := s.db.Exec(unrankedSQL).Error; err != nil {
+		return fmt.Errorf("failed to clear held percentiles: %w", err)
+	}
+	return nil
+}
+
+// RecomputePeriodically runs RecomputeAll now and then every interval in the background.
+func (s *ParseService) RecomputePeriodically(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	go func() {
+		for ; true; <-ticker.C {
+			start := time.Now()
+			if err := s.RecomputeAll(); err != nil {
+				log.Printf("[Parses] %v", err)
+				continue
+			}
+			log.Printf("[Parses] percentiles recomputed in %s", time.Since(start).Round(time.Millisecond))
+		}
+	}()
+}
@@ -0,0 +1,39 @@
+package parses
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestPercentileSQL_ScopeRestrictsRankedRows(t *testing.T) {
+	q := percentileSQL(affectedGroupsSQL())
+	update := strings.Index(q, "UPDATE")
+	scope := strings.Index(q, "a.encounter_id IN @ids")
+	if scope < 0 || scope > update {
+		t.Fatalf("Expected the affected groups to restrict the ranked CTE, got %s", q)
+	}
+	if strings.Contains(q[update:], "@ids") {
+		t.Errorf("Expected the update not to filter ranked rows after the window, got %s", q)
+	}
+}
+
+func TestPercentileSQL_SkipsHeldAndUnchangedRows(t *testing.T) {
+	for _, q := range []string{percentileSQL(""), percentileSQL(affectedGroupsSQL())} {
+		if !strings.Contains(q, "e.held = false") {
+			t.Errorf("Expected held encounters to be left out of the ranking, got %s", q)
+		}
+		if !strings.Contains(q, "t.percentile IS DISTINCT FROM r.pct") {
+			t.Errorf("Expected unchanged percentiles not to be rewritten, got %s", q)
+		}
+	}
+}
+
+func TestResolvedGroupsSQL_RanksTheNameKeyedGroupsLeft(t *testing.T) {
+	q := percentileSQL(resolvedGroupsSQL())
+	if !strings.Contains(q, "'name:' || LOWER(e.scene_name)") {
+		t.Errorf("Expected the groups keyed by scene name to be ranked again, got %s", q)
+	}
+	if !strings.Contains(q, peerKeySQL()+") IN (") {
+		t.Errorf("Expected the groups the encounters joined to be ranked, got %s", q)
+	}
+}
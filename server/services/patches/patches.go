@@ -0,0 +1,60 @@
+package patches
+
+import (
+	"fmt"
+
+	"gorm.io/gorm"
+)
+
+// FilterSQL restricts the given encounter patch_id column to the patch named by the single
+// bind argument (case-insensitive). Unknown names match nothing.
+func FilterSQL(column string) string {
+	return column + " IN (SELECT id FROM patches WHERE LOWER(name) = LOWER(?))"
+}
+
+// tagSQL sets encounters.patch_id to the patch whose window contains started_at, only
+// writing rows whose tag changes. %s filters encounters e2.
+const tagSQL = `
+	UPDATE encounters e SET patch_id = t.patch_id
+	FROM (
+		SELECT e2.id, (
+			SELECT p.id FROM patches p
+			WHERE p.starts_at <= e2.started_at AND (p.ends_at IS NULL OR e2.started_at < p.ends_at)
+			ORDER BY p.starts_at DESC
+			LIMIT 1
+		) AS patch_id
+		FROM encounters e2
+		WHERE %s
+	) t
+	WHERE e.id = t.id AND e.patch_id IS DISTINCT FROM t.patch_id`
+
+// PatchService tags encounters with their game patch.
+type PatchService struct {
+	db *gorm.DB
+}
+
+// NewPatchService creates a new patch service instance
+func NewPatchService(db *gorm.DB) *PatchService {
+	return &PatchService{db: db}
+}
+
+// TagEncounters tags the given encounters with their patch. Call it after ingest.
+func (s *PatchService) TagEncounters(encounterIDs []int64) error {
+	if len(encounterIDs) == 0 {
+		return nil
+	}
+	if err := s.db.Exec(fmt.Sprintf(tagSQL, "e2.id IN ?"), encounterIDs).Error; err != nil {
+		return fmt.Errorf("failed to tag encounter patches: %w", err)
+	}
+	return nil
+}
+
+// RetagAll re-tags every encounter, returning how many changed. Call it after the patches
+// table is edited.
+func (s *PatchService) RetagAll() (int64, error) {
+	res := s.db.Exec(fmt.Sprintf(tagSQL, "TRUE"))
+	if res.Error != nil {
+		return 0, fmt.Errorf("failed to retag encounter patches: %w", res.Error)
+	}
+	return res.RowsAffected, nil
+}
@@ -0,0 +1,37 @@
+package patches
+
+import (
+	"strings"
+	"testing"
+
+	dbpkg "server/db"
+)
+
+func TestTagEncounters_TagsOnlyGivenEncounters(t *testing.T) {
+	db, stmts, err := dbpkg.DryRun()
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := NewPatchService(db)
+
+	if err := s.TagEncounters(nil); err != nil || len(stmts.All()) != 0 {
+		t.Fatalf("Expected no statements without encounters, got %v (%v)", stmts.All(), err)
+	}
+	if err := s.TagEncounters([]int64{4, 7}); err != nil {
+		t.Fatal(err)
+	}
+	all := stmts.All()
+	if len(all) != 1 || !strings.Contains(all[0], "e2.id IN (4,7)") {
+		t.Errorf("Expected one update restricted to the encounters, got %v", all)
+	}
+	if !strings.Contains(all[0], "IS DISTINCT FROM t.patch_id") {
+		t.Errorf("Expected unchanged tags not to be rewritten, got %s", all[0])
+	}
+}
+
+func TestFilterSQL(t *testing.T) {
+	got := FilterSQL("e.patch_id")
+	if got != "e.patch_id IN (SELECT id FROM patches WHERE LOWER(name) = LOWER(?))" {
+		t.Errorf("Unexpected filter %s", got)
+	}
+}
@@ -0,0 +1,99 @@
+package privacy
+
+import (
+	"fmt"
+
+	"server/models"
+
+	"gorm.io/gorm"
+)
+
+// PublicSQL is the condition leaving out characters that opted out of public listings.
+// actorColumn is the column holding the character's actor ID.
+func PublicSQL(actorColumn string) string {
+	return "NOT EXISTS (SELECT 1 FROM characters pc WHERE pc.actor_id = " + actorColumn + " AND pc.is_private = true)"
+}
+
+// NameSQL is nameColumn, or NULL for characters that opted out of public listings. Use it
+// where a private character's row must stay (e.g. a member of a ranked party) but must not
+// name them.
+func NameSQL(actorColumn, nameColumn string) string {
+	return "(CASE WHEN " + PublicSQL(actorColumn) + " THEN " + nameColumn + " END)"
+}
+
+// PrivateActors returns which of the given actors opted out of public listings.
+func PrivateActors(db *gorm.DB, actorIDs []int64) (map[int64]bool, error) {
+	private := make(map[int64]bool)
+	if len(actorIDs) == 0 {
+		return private, nil
+	}
+	var ids []int64
+	if err := db.Model(&models.Character{}).
+		Where("actor_id IN ? AND is_private = ?", actorIDs, true).
+		Pluck("actor_id", &ids).Error; err != nil {
+		return nil, fmt.Errorf("failed to load private characters: %w", err)
+	}
+	for _, id := range ids {
+		private[id] = true
+	}
+	return private, nil
+}
+
+// RedactActors clears, in place, the names of private characters among encounters' actor
+// rows.
+func RedactActors(db *gorm.DB, groups ...[]models.ActorEncounterStat) error {
+	var ids []int64
+	for _, actors := range groups {
+		for _, a := range actors {
+			ids = append(ids, a.ActorID)
+		}
+	}
+	private, err := PrivateActors(db, ids)
+	if err != nil {
+		return err
+	}
+	for _, actors := range groups {
+		for i := range actors {
+			if private[actors[i].ActorID] {
+				actors[i].Name = nil
+			}
+		}
+	}
+	return nil
+}
+
+// ResolveClaim records an admin's decision on a character claim. Approving links the
+// character to the claimant and rejects the other pending claims on it; rejecting a claim
+// that had been approved unlinks its owner.
+func ResolveClaim(db *gorm.DB, claim *models.CharacterClaim, status string, reviewerID uint, note *string) error {
+	return db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(claim).Updates(map[string]interface{}{
+			"status":         status,
+			"note":           note,
+			"reviewed_by_id": reviewerID,
+			"reviewed_at":    gorm.Expr("NOW()"),
+		}).Error; err != nil {
+			return err
+		}
+
+		if status != models.ClaimApproved {
+			return tx.Model(&models.Character{}).
+				Where("actor_id = ? AND owner_user_id = ?", claim.ActorID, claim.UserID).
+				Update("owner_user_id", nil).Error
+		}
+		res := tx.Model(&models.Character{}).Where("actor_id = ?", claim.ActorID).Update("owner_user_id", claim.UserID)
+		if res.Error != nil {
+			return res.Error
+		}
+		if res.RowsAffected == 0 {
+			return gorm.ErrRecordNotFound
+		}
+		return tx.Model(&models.CharacterClaim{}).
+			Where("actor_id = ? AND id <> ? AND status = ?", claim.ActorID, claim.ID, models.ClaimPending).
+			Updates(map[string]interface{}{
+				"status":         models.ClaimRejected,
+				"reviewed_by_id": reviewerID,
+				"reviewed_at":    gorm.Expr("NOW()"),
+			}).Error
+	})
+}
@@ -0,0 +1,90 @@
+package progress
+
+import (
+	"fmt"
+	"time"
+
+	"server/lib/charserialize"
+	"server/models"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// ProgressService maintains the dungeon_progress records parsed from CharSerialize
+// snapshots.
+type ProgressService struct {
+	db *gorm.DB
+}
+
+// NewProgressService creates a new progress service instance
+func NewProgressService(db *gorm.DB) *ProgressService {
+	return &ProgressService{db: db}
+}
+
+// RecordSnapshot updates a character's dungeon progress from a CharSerialize snapshot taken
+// at lastSeenMs. Records from a newer snapshot are kept, and dungeons missing from the
+// snapshot are left as they were. Snapshots that don't decode are ignored.
+func (s *ProgressService) RecordSnapshot(playerID, lastSeenMs int64, charSerializeJSON string) error {
+	if charSerializeJSON == "" {
+		return nil
+	}
+	doc, err := charserialize.DecodeString(charSerializeJSON)
+	if err != nil {
+		return nil
+	}
+	dungeons := doc.DungeonProgress()
+	if len(dungeons) == 0 {
+		return nil
+	}
+
+	now := time.Now()
+	rows := make([]models.DungeonProgress, 0, len(dungeons))
+	for _, d := range dungeons {
+		rows = append(rows, models.DungeonProgress{
+			PlayerID:         playerID,
+			DungeonID:        d.DungeonID,
+			PassCount:        d.PassCount,
+			BestTime:         d.BestTime,
+			MasterSeasonID:   d.MasterSeasonID,
+			MasterDifficulty: d.MasterDifficulty,
+			MasterPassCount:  d.MasterPassCount,
+			MasterPassTime:   d.MasterPassTime,
+			LastSeenMs:       lastSeenMs,
+			UpdatedAt:        now,
+		})
+	}
+	if err := s.db.Clauses(clause.OnConflict{
+		Columns: []clause.Column{{Name: "player_id"}, {Name: "dungeon_id"}},
+		DoUpdates: clause.AssignmentColumns([]string{"pass_count", "best_time", "master_season_id", "master_difficulty",
+			"master_pass_count", "master_pass_time", "last_seen_ms", "updated_at"}),
+		Where: clause.Where{Exprs: []clause.Expression{gorm.Expr("dungeon_progress.last_seen_ms <= excluded.last_seen_ms")}},
+	}).Create(&rows).Error; err != nil {
+		return fmt.Errorf("failed to record dungeon progress of player %d: %w", playerID, err)
+	}
+	return nil
+}
+
+// Backfill records the progress of every stored snapshot, for data uploaded before
+// progress was tracked. It does nothing once any progress has been recorded.
+func (s *ProgressService) Backfill() error {
+	var existing int64
+	if err := s.db.Model(&models.DungeonProgress{}).Limit(1).Count(&existing).Error; err != nil {
+		return fmt.Errorf("failed to check dungeon progress: %w", err)
+	}
+	if existing > 0 {
+		return nil
+	}
+
+	var batch []models.DetailedPlayerData
+	return s.db.Model(&models.DetailedPlayerData{}).
+		Select("player_id", "last_seen_ms", "char_serialize_json").
+		FindInBatches(&batch, 100, func(tx *gorm.DB, _ int) error {
+			for _, pd := range batch {
+				if err := s.RecordSnapshot(pd.PlayerID, pd.LastSeenMs, pd.CharSerializeJSON); err != nil {
+					return err
+				}
+			}
+			return nil
+		}).Error
+}
@@ -0,0 +1,355 @@
+package rollups
+
+import (
+	"fmt"
+	"log"
+	"sync/atomic"
+	"time"
+
+	"server/lib"
+	"server/services/moderation"
+
+	"gorm.io/gorm"
+)
+
+// Per-sample metric expressions over actor_encounter_stats a joined with encounters e.
+// The statistics endpoints use the same expressions for their live queries, so rollups
+// and live results agree.
+const SampleDPSSQL = "a.dps"
+
+// SampleHPSSQL is healing over the actor's active time, the leaderboard's HPS.
+var SampleHPSSQL = lib.ActorRateSQL("a.heal_dealt", "a", "e")
+
+// SampleBossDPSSQL is boss damage over boss-active time (see lib.BossDurationSQL).
+var SampleBossDPSSQL = fmt.Sprintf("(CASE WHEN %[1]s > 0 THEN a.boss_damage_dealt::double precision / %[1]s ELSE 0 END)", lib.BossDurationSQL("e"))
+
+// DaySQL is the UTC day an encounter belongs to.
+const DaySQL = "(e.started_at AT TIME ZONE 'UTC')::date"
+
+// BracketSize is the width of the ability-score brackets rollups are split by.
+const BracketSize = 1000
+
+// advisory lock key serialising rollup writers across instances
+const rollupLockKey = 73260431
+
+// ready is set once a catch-up has completed in this process; until then readers must
+// fall back to live queries.
+var ready atomic.Bool
+
+// Ready reports whether the rollup tables are complete and can be read.
+func Ready() bool {
+	return ready.Load()
+}
+
+// RollupService maintains pre-computed statistics tables.
+type RollupService struct {
+	db *gorm.DB
+}
+
+// NewRollupService creates a new rollup service instance
+func NewRollupService(db *gorm.DB) *RollupService {
+	return &RollupService{db: db}
+}
+
+// groupSQL is the rollup row a player row a of encounter e belongs to, as the columns
+// group_key, day, patch_id, scene_id, scene_name, class_spec and ability_bracket. The scene
+// name is quoted in the key so names containing the separator, and empty vs missing names,
+// can't collide.
+var groupSQL = fmt.Sprintf(`
+		concat_ws('|', %[1]s::text, COALESCE(e.patch_id::text, ''), COALESCE(e.scene_id::text, ''), COALESCE(quote_literal(e.scene_name), ''),
+				  COALESCE(a.class_spec, -1)::text, COALESCE((FLOOR(a.ability_score::double precision / %[2]d) * %[2]d)::bigint::text, '')) AS group_key,
+		%[1]s AS day, e.patch_id, e.scene_id, e.scene_name, COALESCE(a.class_spec, -1) AS class_spec,
+		(FLOOR(a.ability_score::double precision / %[2]d) * %[2]d)::bigint AS ability_bracket`, DaySQL, BracketSize)
+
+// rollupColumns are the stat_rollups columns aggregateSQL selects, in order.
+const rollupColumns = `group_key, day, patch_id, scene_id, scene_name, class_spec, ability_bracket, count,
+	dps_values, hps_values, boss_dps_values, updated_at`
+
+// aggregateSQL aggregates the player rows of encounters counted in the rollups (see
+// models.StatRollupEncounter) into rollup rows, keeping each metric's values in ascending
+// order. %s filters actor_encounter_stats a joined with encounters e.
+var aggregateSQL = fmt.Sprintf(`
+	WITH s AS (
+		SELECT %[1]s,
+			   %[2]s AS dps, %[3]s AS hps, %[4]s AS boss_dps
+		FROM actor_encounter_stats a
+		JOIN encounters e ON e.id = a.encounter_id
+		WHERE a.is_player = true AND %%s
+	)
+	SELECT group_key, day, patch_id, scene_id, scene_name, class_spec, ability_bracket, COUNT(*),
+		   COALESCE(jsonb_agg(dps ORDER BY dps) FILTER (WHERE dps IS NOT NULL), '[]'),
+		   COALESCE(jsonb_agg(hps ORDER BY hps) FILTER (WHERE hps IS NOT NULL), '[]'),
+		   COALESCE(jsonb_agg(boss_dps ORDER BY boss_dps) FILTER (WHERE boss_dps IS NOT NULL), '[]'),
+		   NOW()
+	FROM s
+	GROUP BY 1, 2, 3, 4, 5, 6, 7`,
+	groupSQL, SampleDPSSQL, SampleHPSSQL, SampleBossDPSSQL)
+
+// mergeValuesSQL merges two stored ascending value arrays into one.
+func mergeValuesSQL(a, b string) string {
+	return `(SELECT COALESCE(jsonb_agg(v ORDER BY v::double precision), '[]') FROM (
+		SELECT jsonb_array_elements(` + a + `) AS v UNION ALL SELECT jsonb_array_elements(` + b + `)
+	) m)`
+}
+
+// mergeSQL adds aggregated rows into existing rollup rows of the same group.
+var mergeSQL = `
+	ON CONFLICT (group_key) DO UPDATE SET
+		count = stat_rollups.count + excluded.count,
+		dps_values = ` + mergeValuesSQL("stat_rollups.dps_values", "excluded.dps_values") + `,
+		hps_values = ` + mergeValuesSQL("stat_rollups.hps_values", "excluded.hps_values") + `,
+		boss_dps_values = ` + mergeValuesSQL("stat_rollups.boss_dps_values", "excluded.boss_dps_values") + `,
+		updated_at = NOW()`
+
+// countedSQL keeps player rows of encounters counted in the rollups.
+const countedSQL = "EXISTS (SELECT 1 FROM stat_rollup_encounters t WHERE t.encounter_id = a.encounter_id)"
+
+// latestSQL upserts the newest player row per actor among listed encounters. %s filters
+// actor_encounter_stats a.
+var latestSQL = `
+	INSERT INTO stat_actor_latest (actor_id, stat_id, class_id, class_spec, ability_score)
+	SELECT DISTINCT ON (a.actor_id) a.actor_id, a.id, a.class_id, a.class_spec, a.ability_score
+	FROM actor_encounter_stats a
+	JOIN encounters e ON e.id = a.encounter_id
+	WHERE a.is_player = true AND ` + moderation.ListedSQL("e") + ` AND %s
+	ORDER BY a.actor_id, a.id DESC
+	ON CONFLICT (actor_id) DO UPDATE SET
+		stat_id = excluded.stat_id, class_id = excluded.class_id,
+		class_spec = excluded.class_spec, ability_score = excluded.ability_score
+	WHERE stat_actor_latest.stat_id < excluded.stat_id`
+
+// AddEncounters adds freshly ingested encounters to the rollups, merging their players
+// into the existing rows, and updates the latest row of their players. Encounters already
+// counted and held encounters are skipped, so calling it twice is harmless. Call it after
+// ingest.
+func (s *RollupService) AddEncounters(encounterIDs []int64) error {
+	if len(encounterIDs) == 0 {
+		return nil
+	}
+	return s.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Exec("SELECT pg_advisory_xact_lock(?)", rollupLockKey).Error; err != nil {
+			return err
+		}
+		if err := addEncounters(tx, encounterIDs); err != nil {
+			return err
+		}
+		if err := tx.Exec(fmt.Sprintf(latestSQL, "a.encounter_id IN ?"), encounterIDs).Error; err != nil {
+			return fmt.Errorf("failed to update latest actor stats: %w", err)
+		}
+		return nil
+	})
+}
+
+// addEncounters counts the listed, not yet counted encounters among ids and merges their
+// player rows into the rollups.
+func addEncounters(tx *gorm.DB, encounterIDs []int64) error {
+	var added []int64
+	if err := tx.Raw(`
+		INSERT INTO stat_rollup_encounters (encounter_id, day)
+		SELECT e.id, `+DaySQL+` FROM encounters e
+		WHERE e.id IN ? AND `+moderation.ListedSQL("e")+`
+		ON CONFLICT (encounter_id) DO NOTHING
+		RETURNING encounter_id`, encounterIDs).Scan(&added).Error; err != nil {
+		return fmt.Errorf("failed to track rollup encounters: %w", err)
+	}
+	if len(added) == 0 {
+		return nil
+	}
+	q := "INSERT INTO stat_rollups (" + rollupColumns + ") " + fmt.Sprintf(aggregateSQL, "a.encounter_id IN ?") + mergeSQL
+	if err := tx.Exec(q, added).Error; err != nil {
+		return fmt.Errorf("failed to add encounters to rollups: %w", err)
+	}
+	return nil
+}
+
+// RefreshEncounters re-derives the rollup rows of encounters whose listing changed (held or
+// approved by moderation): they are counted if listed and not otherwise, and the rows of
+// their groups are recomputed from the counted encounters. Only those groups, and the latest
+// rows of their players, are rebuilt.
+func (s *RollupService) RefreshEncounters(encounterIDs []int64) error {
+	if len(encounterIDs) == 0 {
+		return nil
+	}
+	return s.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Exec("SELECT pg_advisory_xact_lock(?)", rollupLockKey).Error; err != nil {
+			return err
+		}
+		if err := tx.Exec("DELETE FROM stat_rollup_encounters WHERE encounter_id IN ?", encounterIDs).Error; err != nil {
+			return fmt.Errorf("failed to untrack rollup encounters: %w", err)
+		}
+		if err := tx.Exec(`
+			INSERT INTO stat_rollup_encounters (encounter_id, day)
+			SELECT e.id, `+DaySQL+` FROM encounters e
+			WHERE e.id IN ? AND `+moderation.ListedSQL("e"), encounterIDs).Error; err != nil {
+			return fmt.Errorf("failed to track rollup encounters: %w", err)
+		}
+
+		actorsSQL := "a.actor_id IN (SELECT actor_id FROM actor_encounter_stats WHERE encounter_id IN ? AND is_player = true)"
+		if err := tx.Exec("DELETE FROM stat_actor_latest a WHERE "+actorsSQL, encounterIDs).Error; err != nil {
+			return fmt.Errorf("failed to clear latest actor stats: %w", err)
+		}
+		if err := tx.Exec(fmt.Sprintf(latestSQL, actorsSQL), encounterIDs).Error; err != nil {
+			return fmt.Errorf("failed to rebuild latest actor stats: %w", err)
+		}
+
+		var groups []struct {
+			GroupKey string `gorm:"column:group_key"`
+			Day      string `gorm:"column:day"`
+		}
+		if err := tx.Raw(`
+			SELECT DISTINCT g.group_key, to_char(g.day, 'YYYY-MM-DD') AS day FROM (
+				SELECT `+groupSQL+`
+				FROM actor_encounter_stats a
+				JOIN encounters e ON e.id = a.encounter_id
+				WHERE a.is_player = true AND a.encounter_id IN ?
+			) g`, encounterIDs).Scan(&groups).Error; err != nil {
+			return fmt.Errorf("failed to resolve rollup groups: %w", err)
+		}
+		if len(groups) == 0 {
+			return nil
+		}
+		keys := make([]string, 0, len(groups))
+		days := make([]string, 0, len(groups))
+		for _, g := range groups {
+			keys = append(keys, g.GroupKey)
+			days = append(days, g.Day)
+		}
+		if err := tx.Exec("DELETE FROM stat_rollups WHERE group_key IN ?", keys).Error; err != nil {
+			return fmt.Errorf("failed to clear rollup groups: %w", err)
+		}
+		q := "INSERT INTO stat_rollups (" + rollupColumns + ") " +
+			fmt.Sprintf(aggregateSQL, countedSQL+" AND "+DaySQL+"::text IN ?") +
+			" HAVING group_key IN ?"
+		if err := tx.Exec(q, days, keys).Error; err != nil {
+			return fmt.Errorf("failed to rebuild rollup groups: %w", err)
+		}
+		return nil
+	})
+}
+
+// CatchUp reconciles the rollups with the encounters table: listed encounters that are not
+// counted yet are added, and the days of counted encounters that were deleted or held
+// outside RefreshEncounters are rebuilt. Both checks read the encounters table only, so an
+// up-to-date instance pays little for them.
+func (s *RollupService) CatchUp() error {
+	return s.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Exec("SELECT pg_advisory_xact_lock(?)", rollupLockKey).Error; err != nil {
+			return err
+		}
+
+		var days []string
+		if err := tx.Raw(`
+			DELETE FROM stat_rollup_encounters t
+			WHERE NOT EXISTS (SELECT 1 FROM encounters e WHERE e.id = t.encounter_id AND ` + moderation.ListedSQL("e") + `)
+			RETURNING to_char(t.day, 'YYYY-MM-DD')`).Scan(&days).Error; err != nil {
+			return fmt.Errorf("failed to untrack stale rollup encounters: %w", err)
+		}
+		if err := rebuildDays(tx, days); err != nil {
+			return err
+		}
+
+		var missing []int64
+		if err := tx.Raw(`
+			SELECT e.id FROM encounters e
+			WHERE ` + moderation.ListedSQL("e") + `
+			  AND NOT EXISTS (SELECT 1 FROM stat_rollup_encounters t WHERE t.encounter_id = e.id)`).Scan(&missing).Error; err != nil {
+			return fmt.Errorf("failed to find uncounted encounters: %w", err)
+		}
+		if len(missing) > 0 {
+			if err := addEncounters(tx, missing); err != nil {
+				return err
+			}
+			if err := tx.Exec(fmt.Sprintf(latestSQL, "a.encounter_id IN ?"), missing).Error; err != nil {
+				return fmt.Errorf("failed to update latest actor stats: %w", err)
+			}
+		}
+		return nil
+	})
+}
+
+// RegroupEncounters re-derives the rollups of counted encounters that moved to other
+// groups, e.g. whose scene was resolved (see scenes.SceneService.SyncCatalog). The rows of
+// the days they fall on are recomputed from the counted encounters, which covers both the
+// groups they left and the ones they joined.
+func (s *RollupService) RegroupEncounters(encounterIDs []int64) error {
+	if len(encounterIDs) == 0 {
+		return nil
+	}
+	return s.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Exec("SELECT pg_advisory_xact_lock(?)", rollupLockKey).Error; err != nil {
+			return err
+		}
+		var days []string
+		if err := tx.Raw(`
+			SELECT DISTINCT to_char(day, 'YYYY-MM-DD') FROM stat_rollup_encounters
+			WHERE encounter_id IN ?`, encounterIDs).Scan(&days).Error; err != nil {
+			return fmt.Errorf("failed to resolve rollup days: %w", err)
+		}
+		return rebuildDays(tx, days)
+	})
+}
+
+// rebuildDays recomputes the rollup rows of the given days (YYYY-MM-DD) from the counted
+// encounters.
+func rebuildDays(tx *gorm.DB, days []string) error {
+	if len(days) == 0 {
+		return nil
+	}
+	if err := tx.Exec("DELETE FROM stat_rollups WHERE day::text IN ?", days).Error; err != nil {
+		return fmt.Errorf("failed to clear rollup days: %w", err)
+	}
+	q := "INSERT INTO stat_rollups (" + rollupColumns + ") " + fmt.Sprintf(aggregateSQL, countedSQL+" AND "+DaySQL+"::text IN ?")
+	if err := tx.Exec(q, days).Error; err != nil {
+		return fmt.Errorf("failed to rebuild rollup days: %w", err)
+	}
+	return nil
+}
+
+// RebuildAll recomputes every rollup from scratch. Use it when encounters change groups,
+// e.g. after they are re-tagged with patches.
+func (s *RollupService) RebuildAll() error {
+	return s.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Exec("SELECT pg_advisory_xact_lock(?)", rollupLockKey).Error; err != nil {
+			return err
+		}
+		if err := tx.Exec("DELETE FROM stat_rollups").Error; err != nil {
+			return fmt.Errorf("failed to clear rollups: %w", err)
+		}
+		if err := tx.Exec("DELETE FROM stat_rollup_encounters").Error; err != nil {
+			return fmt.Errorf("failed to clear rollup encounters: %w", err)
+		}
+		if err := tx.Exec(`
+			INSERT INTO stat_rollup_encounters (encounter_id, day)
+			SELECT e.id, ` + DaySQL + ` FROM encounters e WHERE ` + moderation.ListedSQL("e")).Error; err != nil {
+			return fmt.Errorf("failed to track rollup encounters: %w", err)
+		}
+		if err := tx.Exec("INSERT INTO stat_rollups (" + rollupColumns + ") " + fmt.Sprintf(aggregateSQL, countedSQL)).Error; err != nil {
+			return fmt.Errorf("failed to rebuild rollups: %w", err)
+		}
+		if err := tx.Exec("DELETE FROM stat_actor_latest").Error; err != nil {
+			return fmt.Errorf("failed to clear latest actor stats: %w", err)
+		}
+		if err := tx.Exec(fmt.Sprintf(latestSQL, "TRUE")).Error; err != nil {
+			return fmt.Errorf("failed to rebuild latest actor stats: %w", err)
+		}
+		return nil
+	})
+}
+
+// CatchUpPeriodically runs CatchUp now and then every interval in the background, picking
+// up changes made outside ingest and moderation (backfills, deletions). The rollups are
+// ready once the first catch-up has completed.
+func (s *RollupService) CatchUpPeriodically(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	go func() {
+		for ; true; <-ticker.C {
+			start := time.Now()
+			if err := s.CatchUp(); err != nil {
+				log.Printf("[Rollups] %v", err)
+				continue
+			}
+			ready.Store(true)
+			log.Printf("[Rollups] statistics rollups caught up in %s", time.Since(start).Round(time.Millisecond))
+		}
+	}()
+}
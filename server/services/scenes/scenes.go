@@ -0,0 +1,269 @@
+package scenes
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"server/models"
+	"server/services/gamedata"
+
+	"gorm.io/gorm"
+)
+
+// NameFilter restricts encounters (or rollups) aliased as alias to the scene called name:
+// rows linked to a scene the catalog knows by that name in any language, and rows uploaded
+// with exactly that name (case-insensitive).
+func NameFilter(alias, name string) (string, []interface{}) {
+	return "(" + alias + ".scene_id IN (SELECT id FROM scenes WHERE aliases @> jsonb_build_array(LOWER(?))) OR " +
+			"LOWER(" + alias + ".scene_name) = LOWER(?))",
+		[]interface{}{name, name}
+}
+
+// BossFilter matches encounter bosses aliased as alias against a boss name in any
+// language: bosses whose monster ID the catalog resolves the name to, and bosses uploaded
+// with exactly that name (case-insensitive).
+func BossFilter(alias, name string) (string, []interface{}) {
+	if id, ok := gamedata.Get().MonsterID(name); ok {
+		return "(" + alias + ".monster_id = ? OR LOWER(" + alias + ".monster_name) = LOWER(?))",
+			[]interface{}{id, name}
+	}
+	return "LOWER(" + alias + ".monster_name) = LOWER(?)", []interface{}{name}
+}
+
+// KeySQL is an expression identifying the scene of the encounter aliased as alias: its
+// SceneID, or its lowercased name for encounters uploaded without one. Group and partition
+// by it instead of scene_name so names in different client languages don't split a scene.
+func KeySQL(alias string) string {
+	return "COALESCE(" + alias + ".scene_id::text, 'name:' || LOWER(" + alias + ".scene_name))"
+}
+
+// linkSQL creates the scenes of the matched encounters not seen before. Uploaded names
+// never become aliases, so one upload can't make its name match another scene's
+// encounters; aliases come from the catalog only (see ApplyCatalog). %s filters
+// encounters e.
+const linkSQL = `
+	INSERT INTO scenes (id, name, names, expected_bosses, aliases, created_at, updated_at)
+	SELECT e.scene_id,
+		   COALESCE(MIN(NULLIF(TRIM(e.scene_name), '')), 'Scene ' || e.scene_id),
+		   '{}', '[]', '[]', NOW(), NOW()
+	FROM encounters e
+	WHERE e.scene_id IS NOT NULL AND %s
+	GROUP BY e.scene_id
+	ON CONFLICT (id) DO NOTHING`
+
+// SceneService maintains the scenes table.
+type SceneService struct {
+	db *gorm.DB
+}
+
+// NewSceneService creates a new scene service instance
+func NewSceneService(db *gorm.DB) *SceneService {
+	return &SceneService{db: db}
+}
+
+// LinkEncounters makes sure the scenes of the given encounters exist. Call it after ingest.
+func (s *SceneService) LinkEncounters(encounterIDs []int64) error {
+	if len(encounterIDs) == 0 {
+		return nil
+	}
+	if err := s.db.Exec(fmt.Sprintf(linkSQL, "e.id IN ?"), encounterIDs).Error; err != nil {
+		return fmt.Errorf("failed to link encounter scenes: %w", err)
+	}
+	return nil
+}
+
+// LinkAll creates the scenes of every stored encounter, for data uploaded before scenes
+// were tracked.
+func (s *SceneService) LinkAll() error {
+	if err := s.db.Exec(fmt.Sprintf(linkSQL, "TRUE")).Error; err != nil {
+		return fmt.Errorf("failed to link encounter scenes: %w", err)
+	}
+	return nil
+}
+
+// ApplyCatalog writes the catalog's scene names, difficulty, bosses and aliases to the
+// scenes table, creating scenes not seen in any upload yet. Scenes the catalog doesn't
+// know lose their aliases, which prunes names recorded from uploads by earlier versions.
+func (s *SceneService) ApplyCatalog(c *gamedata.Catalog) error {
+	return s.db.Transaction(func(tx *gorm.DB) error {
+		ids := []int64{}
+		for _, sc := range c.Scenes() {
+			ids = append(ids, sc.ID)
+			names, err := json.Marshal(sc.Names)
+			if err != nil {
+				return err
+			}
+			if sc.Names == nil {
+				names = []byte("{}")
+			}
+			bosses := make([]string, 0, len(sc.Bosses))
+			for _, b := range sc.Bosses {
+				bosses = append(bosses, b.Name)
+			}
+			bossesJSON, err := json.Marshal(bosses)
+			if err != nil {
+				return err
+			}
+			aliasesJSON, err := json.Marshal(aliases(sc))
+			if err != nil {
+				return err
+			}
+			if err := tx.Exec(`
+				INSERT INTO scenes (id, name, names, difficulty, expected_bosses, aliases, created_at, updated_at)
+				VALUES (?, ?, ?::jsonb, ?, ?::jsonb, ?::jsonb, NOW(), NOW())
+				ON CONFLICT (id) DO UPDATE SET
+					name = excluded.name, names = excluded.names, difficulty = excluded.difficulty,
+					expected_bosses = excluded.expected_bosses, aliases = excluded.aliases, updated_at = NOW()`,
+				sc.ID, sc.Name, string(names), sc.Difficulty, string(bossesJSON), string(aliasesJSON)).Error; err != nil {
+				return fmt.Errorf("failed to apply catalog scene %d: %w", sc.ID, err)
+			}
+		}
+		prune := tx.Model(&models.Scene{}).Where("aliases <> '[]'::jsonb")
+		if len(ids) > 0 {
+			prune = prune.Where("id NOT IN ?", ids)
+		}
+		if err := prune.Updates(map[string]interface{}{"aliases": gorm.Expr("'[]'::jsonb"), "updated_at": gorm.Expr("NOW()")}).Error; err != nil {
+			return fmt.Errorf("failed to prune scene aliases: %w", err)
+		}
+		return nil
+	})
+}
+
+// normalizedSQL is the SQL equivalent of the catalog's name normalisation for column %s.
+const normalizedSQL = `LOWER(REGEXP_REPLACE(TRIM(%s), '\s+', ' ', 'g'))`
+
+// resolveScenesSQL sets the scene of encounters uploaded without one whose name is one of
+// the names in the JSON object bound to it (normalised name -> ID), and returns their IDs.
+// Their fingerprints change with it, so they are marked for recomputation.
+var resolveScenesSQL = `
+	UPDATE encounters t SET scene_id = v.value::bigint, fingerprint_version = 0
+	FROM jsonb_each_text(?::jsonb) v
+	WHERE t.scene_id IS NULL AND ` + fmt.Sprintf(normalizedSQL, "t.scene_name") + ` = v.key
+	RETURNING t.id`
+
+// resolveBossesSQL sets every boss's monster ID to the one its name resolves to in the JSON
+// object bound to it, or NULL, and marks the encounters whose bosses changed for
+// fingerprint recomputation. IDs sent by earlier clients are replaced the same way.
+var resolveBossesSQL = `
+	WITH resolved AS (
+		SELECT b.id, b.encounter_id, v.value::bigint AS monster_id
+		FROM encounter_bosses b
+		LEFT JOIN jsonb_each_text(?::jsonb) v ON v.key = ` + fmt.Sprintf(normalizedSQL, "b.monster_name") + `
+	), changed AS (
+		UPDATE encounter_bosses t SET monster_id = r.monster_id
+		FROM resolved r
+		WHERE t.id = r.id AND t.monster_id IS DISTINCT FROM r.monster_id
+		RETURNING t.encounter_id
+	)
+	UPDATE encounters SET fingerprint_version = 0 WHERE id IN (SELECT encounter_id FROM changed)`
+
+// ResolveNames sets the scene IDs of encounters uploaded without them, and the monster IDs
+// of all bosses, from the catalog's names, as ingest does for new uploads. Encounters it
+// changes get their fingerprints recomputed (see migrations.RecomputeFingerprints). It
+// returns the encounters whose scene was resolved, which moves them to other statistics
+// rollups and parse peer groups. Run it before LinkAll.
+func (s *SceneService) ResolveNames(c *gamedata.Catalog) ([]int64, error) {
+	resolved := []int64{}
+	if sceneIDs := c.SceneNameIDs(); len(sceneIDs) > 0 {
+		names, err := json.Marshal(sceneIDs)
+		if err != nil {
+			return nil, err
+		}
+		if err := s.db.Raw(resolveScenesSQL, string(names)).Find(&resolved).Error; err != nil {
+			return nil, fmt.Errorf("failed to resolve scene names: %w", err)
+		}
+	}
+	// Run even without monsters in the catalog, so IDs it no longer gives are cleared
+	names, err := json.Marshal(c.MonsterNameIDs())
+	if err != nil {
+		return nil, err
+	}
+	if err := s.db.Exec(resolveBossesSQL, string(names)).Error; err != nil {
+		return nil, fmt.Errorf("failed to resolve boss names: %w", err)
+	}
+	return resolved, nil
+}
+
+// syncName is the GameDataSync row recording the catalog applied by SyncCatalog.
+const syncName = "scenes"
+
+// advisory lock key serialising catalog syncs across instances
+const syncLockKey = 73260432
+
+// CatalogVersion identifies the scene and monster tables of a catalog, the ones
+// SyncCatalog applies: their ETags, empty for a table the catalog lacks.
+func CatalogVersion(c *gamedata.Catalog) string {
+	version := ""
+	for i, name := range []string{gamedata.TableScenes, gamedata.TableMonsters} {
+		if i > 0 {
+			version += ","
+		}
+		if t, ok := c.Table(name); ok {
+			version += t.ETag
+		}
+	}
+	return version
+}
+
+// SyncCatalog resolves names, links scenes and applies the catalog (ResolveNames, LinkAll
+// and ApplyCatalog) when its scenes or monsters differ from the ones last applied, and
+// records the version. It returns the encounters whose scene was resolved, whose rollups
+// and parses must be rebuilt; with an unchanged catalog it does nothing and returns none.
+func (s *SceneService) SyncCatalog(c *gamedata.Catalog) ([]int64, error) {
+	version := CatalogVersion(c)
+	var resolved []int64
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Exec("SELECT pg_advisory_xact_lock(?)", syncLockKey).Error; err != nil {
+			return err
+		}
+		var applied []string
+		if err := tx.Model(&models.GameDataSync{}).Where("name = ?", syncName).Pluck("version", &applied).Error; err != nil {
+			return fmt.Errorf("failed to read the applied catalog version: %w", err)
+		}
+		if len(applied) > 0 && applied[0] == version {
+			return nil
+		}
+
+		txs := NewSceneService(tx)
+		var err error
+		if resolved, err = txs.ResolveNames(c); err != nil {
+			return err
+		}
+		if err := txs.LinkAll(); err != nil {
+			return err
+		}
+		if err := txs.ApplyCatalog(c); err != nil {
+			return err
+		}
+		if err := tx.Exec(`
+			INSERT INTO gamedata_syncs (name, version, applied_at) VALUES (?, ?, NOW())
+			ON CONFLICT (name) DO UPDATE SET version = excluded.version, applied_at = excluded.applied_at`,
+			syncName, version).Error; err != nil {
+			return fmt.Errorf("failed to record the applied catalog version: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return resolved, nil
+}
+
+// aliases returns the lowercased distinct names of a catalog scene.
+func aliases(sc gamedata.Scene) []string {
+	seen := map[string]bool{}
+	out := []string{}
+	add := func(name string) {
+		name = strings.ToLower(strings.TrimSpace(name))
+		if name != "" && !seen[name] {
+			seen[name] = true
+			out = append(out, name)
+		}
+	}
+	add(sc.Name)
+	for _, n := range sc.Names {
+		add(n)
+	}
+	return out
+}
@@ -0,0 +1,157 @@
+package scenes
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	dbpkg "server/db"
+	"server/services/gamedata"
+)
+
+func TestLinkEncounters_RecordsNoUploadedAliases(t *testing.T) {
+	db, stmts, err := dbpkg.DryRun()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := NewSceneService(db).LinkEncounters([]int64{4, 7}); err != nil {
+		t.Fatal(err)
+	}
+	links := stmts.Matching("INSERT INTO scenes")
+	if len(links) != 1 {
+		t.Fatalf("Expected one scene insert, got %v", stmts.All())
+	}
+	if strings.Contains(links[0], "jsonb_agg") || !strings.Contains(links[0], "ON CONFLICT (id) DO NOTHING") {
+		t.Errorf("Expected uploaded names not to become aliases, got %s", links[0])
+	}
+}
+
+func TestApplyCatalog_AliasesComeFromCatalog(t *testing.T) {
+	dir := t.TempDir()
+	body := `{"version": "1", "entries": [{"id": 1001, "name": "Dragon's Lair", "names": {"de": "Drachenhort"}}]}`
+	if err := os.WriteFile(filepath.Join(dir, "scenes.json"), []byte(body), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	catalog, err := gamedata.Load(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	db, stmts, err := dbpkg.DryRun()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := NewSceneService(db).ApplyCatalog(catalog); err != nil {
+		t.Fatal(err)
+	}
+
+	upserts := stmts.Matching("INSERT INTO scenes")
+	if len(upserts) != 1 || !strings.Contains(upserts[0], "aliases = excluded.aliases") || !strings.Contains(upserts[0], `"drachenhort"`) {
+		t.Errorf("Expected the catalog's names to replace the aliases, got %v", stmts.All())
+	}
+	prunes := stmts.Matching(`UPDATE "scenes" SET "aliases"='[]'::jsonb`)
+	if len(prunes) != 1 || !strings.Contains(prunes[0], "id NOT IN (1001)") {
+		t.Errorf("Expected other scenes' aliases to be pruned, got %v", stmts.All())
+	}
+}
+
+func TestNameFilter_MatchesOwnUploadedName(t *testing.T) {
+	q, args := NameFilter("e", "Drachenhort")
+	if !strings.Contains(q, "aliases @> jsonb_build_array(LOWER(?))") || !strings.Contains(q, "LOWER(e.scene_name) = LOWER(?)") {
+		t.Errorf("Expected catalog aliases or the encounter's own name, got %s", q)
+	}
+	if strings.Contains(q, "scene_id IS NULL") {
+		t.Errorf("Expected linked encounters to match their own name too, got %s", q)
+	}
+	if len(args) != 2 {
+		t.Errorf("Expected 2 args, got %v", args)
+	}
+}
+
+func TestResolveNames_ClearsBossIDsWithoutCatalog(t *testing.T) {
+	catalog, err := gamedata.Load("")
+	if err != nil {
+		t.Fatal(err)
+	}
+	db, stmts, err := dbpkg.DryRun()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := NewSceneService(db).ResolveNames(catalog); err != nil {
+		t.Fatal(err)
+	}
+	if got := stmts.Matching("SET scene_id"); len(got) != 0 {
+		t.Errorf("Expected no scene resolution without scenes, got %v", got)
+	}
+	bosses := stmts.Matching("UPDATE encounter_bosses")
+	if len(bosses) != 1 || !strings.Contains(bosses[0], "'{}'") || !strings.Contains(bosses[0], "fingerprint_version = 0") {
+		t.Fatalf("Expected boss IDs to be re-resolved against no names and fingerprints flagged, got %v", stmts.All())
+	}
+}
+
+func TestSyncCatalog_AppliesAndRecordsANewVersion(t *testing.T) {
+	dir := t.TempDir()
+	body := `{"version": "1", "entries": [{"id": 1001, "name": "Dragon's Lair"}]}`
+	if err := os.WriteFile(filepath.Join(dir, "scenes.json"), []byte(body), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	catalog, err := gamedata.Load(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	db, stmts, err := dbpkg.DryRun()
+	if err != nil {
+		t.Fatal(err)
+	}
+	// The dry run finds no applied version, so the catalog counts as new
+	if _, err := NewSceneService(db).SyncCatalog(catalog); err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{"SET scene_id", "UPDATE encounter_bosses", "INSERT INTO scenes", "INSERT INTO gamedata_syncs"} {
+		if len(stmts.Matching(want)) == 0 {
+			t.Errorf("Expected a statement containing %q, got %v", want, stmts.All())
+		}
+	}
+	version := CatalogVersion(catalog)
+	if record := stmts.Matching("INSERT INTO gamedata_syncs"); len(record) != 1 || !strings.Contains(record[0], "'"+version+"'") {
+		t.Errorf("Expected version %q to be recorded, got %v", version, record)
+	}
+	if resolve := stmts.Matching("SET scene_id"); len(resolve) != 1 || !strings.Contains(resolve[0], "RETURNING t.id") {
+		t.Errorf("Expected the resolved encounters to be returned, got %v", resolve)
+	}
+}
+
+func TestCatalogVersion_ChangesWithScenesAndMonsters(t *testing.T) {
+	write := func(dir, table, version string) {
+		body := `{"version": "` + version + `", "entries": [{"id": 1, "name": "One"}]}`
+		if err := os.WriteFile(filepath.Join(dir, table+".json"), []byte(body), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	load := func(dir string) string {
+		catalog, err := gamedata.Load(dir)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return CatalogVersion(catalog)
+	}
+
+	dir := t.TempDir()
+	none := load(dir)
+	write(dir, "talents", "1")
+	if got := load(dir); got != none {
+		t.Errorf("Expected talents not to change the version, got %q, expected %q", got, none)
+	}
+	write(dir, "scenes", "1")
+	scenes := load(dir)
+	if scenes == none {
+		t.Errorf("Expected a scenes table to change the version %q", none)
+	}
+	write(dir, "monsters", "1")
+	if got := load(dir); got == scenes {
+		t.Errorf("Expected a monsters table to change the version %q", scenes)
+	}
+	if got := load(dir); got != load(dir) {
+		t.Errorf("Expected the same tables to give the same version, got %q", got)
+	}
+}